@@ -0,0 +1,35 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/pisarevaa/gophermart/internal/fakeaccrual"
+)
+
+// Заменитель системы расчета начислений для локального запуска и интеграционных тестов.
+func main() {
+	var (
+		address        string
+		delay          time.Duration
+		processingTime time.Duration
+	)
+	flag.StringVar(&address, "a", "localhost:8080", "address and port to run fake accrual system")
+	flag.DurationVar(&delay, "d", 0, "delay before each response")
+	flag.DurationVar(&processingTime, "p", 5*time.Second, "time registered orders stay in PROCESSING status")
+	flag.Parse()
+
+	server := fakeaccrual.NewServer()
+	server.SetDelay(delay)
+	server.SetProcessingTime(processingTime)
+
+	log.Println("Run fake accrual system on", address)
+	srv := &http.Server{
+		Addr:              address,
+		Handler:           server.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	log.Fatal(srv.ListenAndServe())
+}
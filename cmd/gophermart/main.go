@@ -8,15 +8,18 @@ import (
 	"github.com/fvbock/endless"
 
 	server "github.com/pisarevaa/gophermart/internal"
+	"github.com/pisarevaa/gophermart/internal/accrual"
 	"github.com/pisarevaa/gophermart/internal/configs"
+	"github.com/pisarevaa/gophermart/internal/events"
 	"github.com/pisarevaa/gophermart/internal/storage"
 	"github.com/pisarevaa/gophermart/internal/tasks"
-	"github.com/pisarevaa/gophermart/internal/utils"
 )
 
-// @title		Swagger Gophermart Service API
-// @version	1.0
-// @host		localhost:8080
+// @title			Swagger Gophermart Service API
+// @version		1.0
+// @description	Errors are returned as application/problem+json (RFC 7807) with stable machine-readable code.
+// @description	Internal error details are not returned, use requestId from the response to find them in logs.
+// @host			localhost:8080
 
 func main() {
 	ctx, cancel := context.WithCancel(context.Background())
@@ -27,12 +30,21 @@ func main() {
 	cfg := configs.NewConfig()
 	logger := server.NewLogger()
 	repo := storage.NewDB(cfg.DatabaseURI, logger)
-	r := server.NewRouter(cfg, logger, repo)
+	broker := events.NewBroker()
+	accrualRouter := accrual.NewRouterFromConfig(cfg, logger)
+	r := server.NewRouter(cfg, logger, repo, broker, accrualRouter)
 
 	// Запускаем фоновую задачу по обновлению статусов заказов
-	client := utils.NewClient()
-	task := tasks.NewTask(cfg, logger, repo, client)
+	task := tasks.NewTask(cfg, logger, repo, accrualRouter, broker)
 	go task.RunUpdateOrderStatuses(exit)
+	// Доставляем сообщения вебхуков из outbox
+	go task.RunDeliverWebhooks(exit)
+	// Списываем сгоревшие баллы
+	go task.RunExpirePoints(exit)
+	// Снимаем просроченные холды
+	go task.RunReleaseHolds(exit)
+	// Пересчитываем уровни лояльности
+	go task.RunRecalculateTiers(exit)
 
 	logger.Info("Run Server")
 	logger.Fatal(endless.ListenAndServe(cfg.Host, r))
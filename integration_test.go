@@ -17,10 +17,14 @@ import (
 	"go.uber.org/zap"
 
 	server "github.com/pisarevaa/gophermart/internal"
+	"github.com/pisarevaa/gophermart/internal/accrual"
 	"github.com/pisarevaa/gophermart/internal/configs"
+	"github.com/pisarevaa/gophermart/internal/events"
+	"github.com/pisarevaa/gophermart/internal/fakeaccrual"
 	"github.com/pisarevaa/gophermart/internal/handlers"
 	"github.com/pisarevaa/gophermart/internal/storage"
 	"github.com/pisarevaa/gophermart/internal/tasks"
+	"github.com/pisarevaa/gophermart/internal/utils"
 )
 
 type OrderReponse struct {
@@ -77,10 +81,19 @@ func (suite *ServerTestSuite) TestFullProccess() {
 	exit, stop := signal.NotifyContext(ctx, os.Interrupt)
 	defer stop()
 
-	ts := httptest.NewServer(server.NewRouter(suite.cfg, suite.logger, suite.repo))
+	broker := events.NewBroker()
+	// Вместо внешней системы расчета начислений используется встроенный заменитель
+	accrualServer := httptest.NewServer(fakeaccrual.NewServer().Handler())
+	defer accrualServer.Close()
+	accrualRouter := accrual.NewRouter(accrual.Provider{
+		Name:   accrual.DefaultProvider,
+		Client: accrual.NewHTTPClient(accrualServer.URL, utils.NewClient(), suite.logger),
+	})
+
+	ts := httptest.NewServer(server.NewRouter(suite.cfg, suite.logger, suite.repo, broker, accrualRouter))
 	defer ts.Close()
 
-	task := tasks.NewTask(suite.cfg, suite.logger, suite.repo, suite.client)
+	task := tasks.NewTask(suite.cfg, suite.logger, suite.repo, accrualRouter, broker)
 	go task.RunUpdateOrderStatuses(exit)
 
 	password := "123"
@@ -141,7 +154,7 @@ func (suite *ServerTestSuite) TestFullProccess() {
 		resp, err := suite.client.R().
 			SetBody(rewardSchema).
 			SetHeader("Content-Type", "application/json").
-			Post(suite.cfg.AccrualSystemAddress + "/api/goods")
+			Post(accrualServer.URL + "/api/goods")
 		suite.Require().NoError(err)
 		suite.Require().Equal(200, resp.StatusCode())
 	})
@@ -157,7 +170,7 @@ func (suite *ServerTestSuite) TestFullProccess() {
 		resp, err := suite.client.R().
 			SetBody(accraulOrder).
 			SetHeader("Content-Type", "application/json").
-			Post(suite.cfg.AccrualSystemAddress + "/api/orders")
+			Post(accrualServer.URL + "/api/orders")
 		suite.Require().NoError(err)
 		suite.Require().Equal(202, resp.StatusCode())
 	})
@@ -0,0 +1,118 @@
+package accrual
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/pisarevaa/gophermart/internal/storage"
+)
+
+const (
+	StateClosed   = "closed"
+	StateOpen     = "open"
+	StateHalfOpen = "half-open"
+)
+
+var ErrCircuitOpen = errors.New("accrual system circuit breaker is open")
+
+// CircuitBreaker перестает обращаться к системе расчета начислений после threshold ошибок подряд.
+// Через openTimeout пропускается один пробный запрос: при успехе выключатель закрывается,
+// при ошибке снова открывается. Ответы 204 и 429 не считаются ошибками - система доступна.
+type CircuitBreaker struct {
+	Client Client
+	Logger *zap.SugaredLogger
+
+	threshold   int
+	openTimeout time.Duration
+
+	mu       sync.Mutex
+	state    string
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+func NewCircuitBreaker(client Client, logger *zap.SugaredLogger, threshold int, openTimeout time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		Client:      client,
+		Logger:      logger,
+		threshold:   threshold,
+		openTimeout: openTimeout,
+		state:       StateClosed,
+	}
+}
+
+// State возвращает текущее состояние. Открытый выключатель, у которого истек openTimeout,
+// считается полуоткрытым, пока пробный запрос не выполнен.
+func (b *CircuitBreaker) State() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.currentState()
+}
+
+func (b *CircuitBreaker) currentState() string {
+	if b.state == StateOpen && time.Since(b.openedAt) >= b.openTimeout {
+		return StateHalfOpen
+	}
+	return b.state
+}
+
+func (b *CircuitBreaker) GetOrderStatus(ctx context.Context, number string) (storage.OrderStatus, error) {
+	if err := b.acquire(); err != nil {
+		return storage.OrderStatus{}, err
+	}
+	status, err := b.Client.GetOrderStatus(ctx, number)
+	b.release(err)
+	return status, err
+}
+
+func (b *CircuitBreaker) acquire() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.currentState() {
+	case StateOpen:
+		return ErrCircuitOpen
+	case StateHalfOpen:
+		if b.probing {
+			return ErrCircuitOpen
+		}
+		if b.state != StateHalfOpen {
+			b.Logger.Info("accrual circuit breaker is half-open, probing accrual system")
+		}
+		b.state = StateHalfOpen
+		b.probing = true
+	}
+	return nil
+}
+
+func (b *CircuitBreaker) release(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+
+	var tooManyRequests *TooManyRequestsError
+	if err == nil || errors.Is(err, ErrOrderNotRegistered) || errors.As(err, &tooManyRequests) {
+		if b.state != StateClosed {
+			b.Logger.Info("accrual circuit breaker is closed")
+		}
+		b.state = StateClosed
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.state == StateHalfOpen || b.failures >= b.threshold {
+		if b.state != StateOpen {
+			b.Logger.Warn("accrual circuit breaker is open after ", b.failures, " failures, last error: ", err)
+		}
+		b.state = StateOpen
+		b.openedAt = time.Now()
+	}
+}
@@ -0,0 +1,47 @@
+package accrual_test
+
+import (
+	"context"
+	"time"
+
+	server "github.com/pisarevaa/gophermart/internal"
+	"github.com/pisarevaa/gophermart/internal/accrual"
+)
+
+func (suite *ClientTestSuite) TestCircuitBreaker() {
+	ctx := context.Background()
+	breaker := accrual.NewCircuitBreaker(suite.client, server.NewLogger(), 2, 50*time.Millisecond)
+	suite.fake.SetOrder("12345678903", "PROCESSED", 100)
+	suite.fake.SetInternalErrors(3)
+
+	_, err := breaker.GetOrderStatus(ctx, "12345678903")
+	suite.Require().Error(err)
+	suite.Require().Equal(accrual.StateClosed, breaker.State())
+	_, err = breaker.GetOrderStatus(ctx, "12345678903")
+	suite.Require().Error(err)
+	suite.Require().Equal(accrual.StateOpen, breaker.State())
+
+	_, err = breaker.GetOrderStatus(ctx, "12345678903")
+	suite.Require().ErrorIs(err, accrual.ErrCircuitOpen)
+
+	// Пробный запрос завершается ошибкой, и выключатель снова открывается
+	time.Sleep(60 * time.Millisecond)
+	suite.Require().Equal(accrual.StateHalfOpen, breaker.State())
+	_, err = breaker.GetOrderStatus(ctx, "12345678903")
+	suite.Require().Error(err)
+	suite.Require().NotErrorIs(err, accrual.ErrCircuitOpen)
+	suite.Require().Equal(accrual.StateOpen, breaker.State())
+
+	time.Sleep(60 * time.Millisecond)
+	status, err := breaker.GetOrderStatus(ctx, "12345678903")
+	suite.Require().NoError(err)
+	suite.Require().Equal("PROCESSED", status.Status)
+	suite.Require().Equal(accrual.StateClosed, breaker.State())
+}
+
+func (suite *ClientTestSuite) TestCircuitBreakerIgnoresNotRegistered() {
+	breaker := accrual.NewCircuitBreaker(suite.client, server.NewLogger(), 1, time.Minute)
+	_, err := breaker.GetOrderStatus(context.Background(), "12345678903")
+	suite.Require().ErrorIs(err, accrual.ErrOrderNotRegistered)
+	suite.Require().Equal(accrual.StateClosed, breaker.State())
+}
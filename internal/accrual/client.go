@@ -0,0 +1,100 @@
+package accrual
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"go.uber.org/zap"
+
+	"github.com/pisarevaa/gophermart/internal/storage"
+	"github.com/pisarevaa/gophermart/internal/utils"
+)
+
+const defaultRetryAfter = 60 * time.Second
+
+// ErrOrderNotRegistered возвращается, если система расчета ответила 204 и еще не знает о заказе.
+var ErrOrderNotRegistered = errors.New("order is not registered in accrual system")
+
+// TooManyRequestsError возвращается при ответе 429, RetryAfter - пауза перед следующим запросом.
+type TooManyRequestsError struct {
+	RetryAfter time.Duration
+}
+
+func (e *TooManyRequestsError) Error() string {
+	return fmt.Sprintf("too many requests to accrual system, retry after %v", e.RetryAfter)
+}
+
+type Client interface {
+	GetOrderStatus(ctx context.Context, number string) (status storage.OrderStatus, err error)
+	State() (state string)
+}
+
+type HTTPClient struct {
+	Address string
+	Client  *resty.Client
+	Logger  *zap.SugaredLogger
+
+	limiter   *utils.RateLimiter
+	rateLimit int
+}
+
+func NewHTTPClient(address string, client *resty.Client, logger *zap.SugaredLogger) *HTTPClient {
+	return &HTTPClient{
+		Address: address,
+		Client:  client,
+		Logger:  logger,
+	}
+}
+
+// SetRateLimit ограничивает количество запросов в минуту. При исчерпании лимита запрос
+// не отправляется, а возвращается TooManyRequestsError, как при ответе 429.
+func (a *HTTPClient) SetRateLimit(limit int) {
+	a.limiter = utils.NewRateLimiter(time.Minute)
+	a.rateLimit = limit
+}
+
+func (a *HTTPClient) GetOrderStatus(ctx context.Context, number string) (storage.OrderStatus, error) {
+	var orderStatus storage.OrderStatus
+	if a.limiter != nil && !a.limiter.Allow(a.Address, a.rateLimit) {
+		return orderStatus, &TooManyRequestsError{RetryAfter: a.limiter.RetryAfter(a.Address)}
+	}
+	requestURL := fmt.Sprintf("%v/api/orders/%v", a.Address, number)
+	resp, err := a.Client.R().
+		SetContext(ctx).
+		SetResult(&orderStatus).
+		SetHeader("Content-Type", "application/json").
+		Get(requestURL)
+	if err != nil {
+		a.Logger.Info("Request to ", requestURL, " with Error: ", err)
+		return orderStatus, err
+	}
+	a.Logger.Info("Request to ", requestURL, " with status code:  ", resp.StatusCode())
+	switch resp.StatusCode() {
+	case http.StatusOK:
+		return orderStatus, nil
+	case http.StatusNoContent:
+		return orderStatus, ErrOrderNotRegistered
+	case http.StatusTooManyRequests:
+		return orderStatus, &TooManyRequestsError{RetryAfter: parseRetryAfter(resp.Header().Get("Retry-After"))}
+	default:
+		return orderStatus, fmt.Errorf("unexpected status code %d from accrual system", resp.StatusCode())
+	}
+}
+
+// State всегда возвращает StateClosed: клиент без CircuitBreaker не ограничивает запросы.
+func (a *HTTPClient) State() string {
+	return StateClosed
+}
+
+func parseRetryAfter(header string) time.Duration {
+	seconds, err := strconv.Atoi(header)
+	if err != nil || seconds <= 0 {
+		return defaultRetryAfter
+	}
+	return time.Duration(seconds) * time.Second
+}
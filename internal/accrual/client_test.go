@@ -0,0 +1,94 @@
+package accrual_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/suite"
+
+	server "github.com/pisarevaa/gophermart/internal"
+	"github.com/pisarevaa/gophermart/internal/accrual"
+	"github.com/pisarevaa/gophermart/internal/fakeaccrual"
+)
+
+type ClientTestSuite struct {
+	suite.Suite
+	fake   *fakeaccrual.Server
+	ts     *httptest.Server
+	client *accrual.HTTPClient
+}
+
+func (suite *ClientTestSuite) SetupTest() {
+	suite.fake = fakeaccrual.NewServer()
+	suite.ts = httptest.NewServer(suite.fake.Handler())
+	suite.client = accrual.NewHTTPClient(suite.ts.URL, resty.New(), server.NewLogger())
+}
+
+func (suite *ClientTestSuite) TearDownTest() {
+	suite.ts.Close()
+}
+
+func TestClientSuite(t *testing.T) {
+	suite.Run(t, new(ClientTestSuite))
+}
+
+func (suite *ClientTestSuite) TestGetOrderStatus() {
+	ctx := context.Background()
+
+	_, err := suite.client.GetOrderStatus(ctx, "12345678903")
+	suite.Require().ErrorIs(err, accrual.ErrOrderNotRegistered)
+
+	suite.fake.SetOrder("12345678903", "PROCESSED", 729.98)
+	status, err := suite.client.GetOrderStatus(ctx, "12345678903")
+	suite.Require().NoError(err)
+	suite.Require().Equal("12345678903", status.Number)
+	suite.Require().Equal("PROCESSED", status.Status)
+	suite.Require().InDelta(729.98, status.Accrual, 0.001)
+
+	suite.fake.SetOrder("12345678903", "INVALID", 0)
+	status, err = suite.client.GetOrderStatus(ctx, "12345678903")
+	suite.Require().NoError(err)
+	suite.Require().Equal("INVALID", status.Status)
+	suite.Require().Zero(status.Accrual)
+}
+
+func (suite *ClientTestSuite) TestTooManyRequests() {
+	suite.fake.SetOrder("12345678903", "PROCESSED", 100)
+	suite.fake.SetTooManyRequests(1, 30*time.Second)
+
+	_, err := suite.client.GetOrderStatus(context.Background(), "12345678903")
+	var tooManyRequests *accrual.TooManyRequestsError
+	suite.Require().ErrorAs(err, &tooManyRequests)
+	suite.Require().Equal(30*time.Second, tooManyRequests.RetryAfter)
+
+	status, err := suite.client.GetOrderStatus(context.Background(), "12345678903")
+	suite.Require().NoError(err)
+	suite.Require().Equal("PROCESSED", status.Status)
+}
+
+func (suite *ClientTestSuite) TestRegisteredOrderIsProcessing() {
+	suite.fake.SetProcessingTime(time.Hour)
+	resp, err := resty.New().R().
+		SetBody(fakeaccrual.Reward{Match: "Bork", Reward: 10, RewardType: fakeaccrual.RewardPercent}).
+		Post(suite.ts.URL + "/api/goods")
+	suite.Require().NoError(err)
+	suite.Require().Equal(200, resp.StatusCode())
+	resp, err = resty.New().R().
+		SetBody(fakeaccrual.RegisterOrder{Order: "12345678903", Goods: []fakeaccrual.Good{{Description: "Bork", Price: 500}}}).
+		Post(suite.ts.URL + "/api/orders")
+	suite.Require().NoError(err)
+	suite.Require().Equal(202, resp.StatusCode())
+
+	status, err := suite.client.GetOrderStatus(context.Background(), "12345678903")
+	suite.Require().NoError(err)
+	suite.Require().Equal("PROCESSING", status.Status)
+
+	suite.fake.SetProcessingTime(0)
+	status, err = suite.client.GetOrderStatus(context.Background(), "12345678903")
+	suite.Require().NoError(err)
+	suite.Require().Equal("PROCESSED", status.Status)
+	suite.Require().InDelta(50, status.Accrual, 0.001)
+}
@@ -0,0 +1,112 @@
+package accrual
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/pisarevaa/gophermart/internal/configs"
+	"github.com/pisarevaa/gophermart/internal/utils"
+)
+
+const (
+	DefaultProvider = "default"
+	// PushProvider отмечает заказы, результат по которым прислан в /api/internal/accrual.
+	PushProvider = "push"
+)
+
+type Provider struct {
+	Name     string
+	Prefixes []string
+	Logins   []string
+	Client   Client
+}
+
+// Router выбирает систему расчета начислений для заказа. Правило по пользователю
+// важнее правила по номеру, из нескольких префиксов выбирается самый длинный.
+// Заказы, не подходящие ни под одно правило, направляются в систему по умолчанию.
+type Router struct {
+	Default   Provider
+	Providers []Provider
+}
+
+func NewRouter(defaultProvider Provider, providers ...Provider) *Router {
+	return &Router{
+		Default:   defaultProvider,
+		Providers: providers,
+	}
+}
+
+// NewRouterFromConfig создает клиентов для системы по умолчанию и дополнительных систем из конфигурации.
+// Каждый клиент получает собственные учетные данные, лимит запросов и CircuitBreaker.
+func NewRouterFromConfig(cfg configs.Config, logger *zap.SugaredLogger) *Router {
+	newClient := func(provider configs.AccrualProvider) Client {
+		client := utils.NewClient()
+		if provider.Token != "" {
+			client.SetAuthToken(provider.Token)
+		}
+		if provider.Username != "" {
+			client.SetBasicAuth(provider.Username, provider.Password)
+		}
+		httpClient := NewHTTPClient(provider.Address, client, logger)
+		httpClient.SetRateLimit(int(provider.RateLimit))
+		return NewCircuitBreaker(
+			httpClient, logger, int(cfg.BreakerThreshold), time.Duration(cfg.BreakerTimeoutSec)*time.Second,
+		)
+	}
+	router := NewRouter(Provider{
+		Name:   DefaultProvider,
+		Client: newClient(configs.AccrualProvider{Address: cfg.AccrualSystemAddress}),
+	})
+	for _, provider := range cfg.AccrualProviders {
+		router.Providers = append(router.Providers, Provider{
+			Name:     provider.Name,
+			Prefixes: provider.Prefixes,
+			Logins:   provider.Logins,
+			Client:   newClient(provider),
+		})
+	}
+	return router
+}
+
+func (r *Router) Route(number string, login string) Provider {
+	for _, provider := range r.Providers {
+		if slices.Contains(provider.Logins, login) {
+			return provider
+		}
+	}
+	matched, matchedLength := r.Default, 0
+	for _, provider := range r.Providers {
+		for _, prefix := range provider.Prefixes {
+			if strings.HasPrefix(number, prefix) && len(prefix) > matchedLength {
+				matched, matchedLength = provider, len(prefix)
+			}
+		}
+	}
+	return matched
+}
+
+// States возвращает состояние CircuitBreaker каждой системы.
+func (r *Router) States() map[string]string {
+	states := map[string]string{r.Default.Name: r.Default.Client.State()}
+	for _, provider := range r.Providers {
+		states[provider.Name] = provider.Client.State()
+	}
+	return states
+}
+
+// State возвращает StateOpen, только если недоступны все системы расчета начислений.
+func (r *Router) State() string {
+	state := StateOpen
+	for _, providerState := range r.States() {
+		if providerState == StateClosed {
+			return StateClosed
+		}
+		if providerState == StateHalfOpen {
+			state = StateHalfOpen
+		}
+	}
+	return state
+}
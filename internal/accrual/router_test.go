@@ -0,0 +1,134 @@
+package accrual_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+
+	server "github.com/pisarevaa/gophermart/internal"
+	"github.com/pisarevaa/gophermart/internal/accrual"
+	"github.com/pisarevaa/gophermart/internal/configs"
+	"github.com/pisarevaa/gophermart/internal/events"
+	"github.com/pisarevaa/gophermart/internal/fakeaccrual"
+	"github.com/pisarevaa/gophermart/internal/storage"
+	"github.com/pisarevaa/gophermart/internal/tasks"
+)
+
+func (suite *ClientTestSuite) TestRoute() {
+	router := accrual.NewRouter(
+		accrual.Provider{Name: accrual.DefaultProvider},
+		accrual.Provider{Name: "short", Prefixes: []string{"4"}},
+		accrual.Provider{Name: "long", Prefixes: []string{"42"}},
+		accrual.Provider{Name: "vip", Logins: []string{"vip"}},
+	)
+	suite.Require().Equal(accrual.DefaultProvider, router.Route("12345678903", "test").Name)
+	suite.Require().Equal("short", router.Route("4111111111111111", "test").Name)
+	suite.Require().Equal("long", router.Route("4242424242424242", "test").Name)
+	suite.Require().Equal("vip", router.Route("4242424242424242", "vip").Name)
+}
+
+func (suite *ClientTestSuite) TestUpdateOrderStatusesByProvider() {
+	partner := fakeaccrual.NewServer()
+	partnerServer := httptest.NewServer(partner.Handler())
+	defer partnerServer.Close()
+
+	logger := server.NewLogger()
+	router := accrual.NewRouter(
+		accrual.Provider{Name: accrual.DefaultProvider, Client: suite.client},
+		accrual.Provider{
+			Name:     "partner",
+			Prefixes: []string{"4"},
+			Client:   accrual.NewHTTPClient(partnerServer.URL, resty.New(), logger),
+		},
+	)
+	suite.fake.SetOrder("4561261212345467", "PROCESSED", 1)
+	partner.SetOrder("4561261212345467", "PROCESSED", 200)
+
+	ctx := context.Background()
+	m := storage.NewMemory()
+	m.Users["test"] = storage.User{Login: "test", Role: storage.RoleUser}
+	suite.Require().NoError(m.StoreOrder(ctx, "4561261212345467", "test"))
+
+	task := tasks.NewTask(configs.Config{}, logger, m, router, events.NewBroker())
+	suite.Require().NoError(task.UpdateOrderStatuses(ctx))
+
+	order := m.Orders["4561261212345467"]
+	suite.Require().Equal("PROCESSED", order.Status)
+	suite.Require().InDelta(200, order.Accrual, 0.001)
+	suite.Require().NotNil(order.AccrualProvider)
+	suite.Require().Equal("partner", *order.AccrualProvider)
+}
+
+func (suite *ClientTestSuite) TestUpdateOrderStatusesSkipsThrottledProvider() {
+	partner := fakeaccrual.NewServer()
+	partnerServer := httptest.NewServer(partner.Handler())
+	defer partnerServer.Close()
+
+	logger := server.NewLogger()
+	router := accrual.NewRouter(
+		accrual.Provider{Name: accrual.DefaultProvider, Client: suite.client},
+		accrual.Provider{
+			Name:     "partner",
+			Prefixes: []string{"4"},
+			Client:   accrual.NewHTTPClient(partnerServer.URL, resty.New(), logger),
+		},
+	)
+	partner.SetOrder("4561261212345467", "PROCESSED", 200)
+	partner.SetTooManyRequests(1, time.Minute)
+	suite.fake.SetOrder("79927398713", "PROCESSED", 100)
+
+	ctx := context.Background()
+	m := storage.NewMemory()
+	m.Users["test"] = storage.User{Login: "test", Role: storage.RoleUser}
+	suite.Require().NoError(m.StoreOrder(ctx, "4561261212345467", "test"))
+	suite.Require().NoError(m.StoreOrder(ctx, "79927398713", "test"))
+	throttled := m.Orders["4561261212345467"]
+	throttled.UploadedAt = throttled.UploadedAt.Add(-time.Hour)
+	m.Orders["4561261212345467"] = throttled
+
+	task := tasks.NewTask(configs.Config{}, logger, m, router, events.NewBroker())
+	// Система партнера просит подождать, ее заказ откладывается и не мешает опросу остальных
+	suite.Require().Error(task.UpdateOrderStatuses(ctx))
+	suite.Require().NoError(task.UpdateOrderStatuses(ctx))
+	suite.Require().NoError(task.UpdateOrderStatuses(ctx))
+
+	suite.Require().Equal("PROCESSED", m.Orders["79927398713"].Status)
+	suite.Require().Equal("NEW", m.Orders["4561261212345467"].Status)
+}
+
+func (suite *ClientTestSuite) TestUpdateOrderStatusesSkipsInvalidStatus() {
+	logger := server.NewLogger()
+	router := accrual.NewRouter(accrual.Provider{Name: accrual.DefaultProvider, Client: suite.client})
+
+	ctx := context.Background()
+	m := storage.NewMemory()
+	m.Users["test"] = storage.User{Login: "test", Role: storage.RoleUser}
+	suite.Require().NoError(m.StoreOrder(ctx, "79927398713", "test"))
+	suite.Require().NoError(m.StoreOrder(ctx, "4561261212345467", "test"))
+
+	task := tasks.NewTask(configs.Config{}, logger, m, router, events.NewBroker())
+	// Неизвестный статус и отрицательное начисление не сохраняются и не меняют баланс
+	suite.fake.SetOrder("79927398713", "UNKNOWN", 0)
+	suite.fake.SetOrder("4561261212345467", "PROCESSED", -100)
+	suite.Require().NoError(task.UpdateOrderStatuses(ctx))
+	suite.Require().NoError(task.UpdateOrderStatuses(ctx))
+
+	for _, number := range []string{"79927398713", "4561261212345467"} {
+		order, err := m.GetOrder(ctx, number)
+		suite.Require().NoError(err)
+		suite.Require().Equal("NEW", order.Status)
+	}
+	user, err := m.GetUser(ctx, "test")
+	suite.Require().NoError(err)
+	suite.Require().InDelta(float32(0), user.Balance, 0.001)
+
+	// После исправления ответа заказ обрабатывается как обычно
+	suite.fake.SetOrder("4561261212345467", "PROCESSED", 100)
+	suite.Require().NoError(task.UpdateOrderStatuses(ctx))
+	suite.Require().NoError(task.UpdateOrderStatuses(ctx))
+	user, err = m.GetUser(ctx, "test")
+	suite.Require().NoError(err)
+	suite.Require().InDelta(float32(100), user.Balance, 0.001)
+}
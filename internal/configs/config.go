@@ -1,12 +1,104 @@
 package configs
 
 import (
+	"encoding/json"
 	"flag"
 	"log"
+	"os"
+	"slices"
+	"strings"
 
 	"github.com/caarlos0/env/v6"
 )
 
+// AccrualProvider - дополнительная система расчета начислений. Заказ направляется в нее,
+// если его номер начинается с одного из Prefixes или он загружен пользователем из Logins.
+type AccrualProvider struct {
+	Name      string   `json:"name"`
+	Address   string   `json:"address"`
+	Prefixes  []string `json:"prefixes"`
+	Logins    []string `json:"logins"`
+	RateLimit int64    `json:"rateLimit"`
+	Token     string   `json:"token"`
+	Username  string   `json:"username"`
+	Password  string   `json:"password"`
+}
+
+// LoyaltyTier - уровень лояльности. Пользователь получает уровень с наибольшим Threshold,
+// не превышающим сумму его начислений за последние 12 месяцев. Начисления умножаются на Multiplier.
+type LoyaltyTier struct {
+	Name       string  `json:"name"`
+	Threshold  float32 `json:"threshold"`
+	Multiplier float32 `json:"multiplier"`
+}
+
+// DefaultLoyaltyTiers используются, если уровни не заданы в LOYALTY_TIERS.
+var DefaultLoyaltyTiers = []LoyaltyTier{
+	{Name: "bronze", Threshold: 0, Multiplier: 1},
+	{Name: "silver", Threshold: 1000, Multiplier: 1.1},
+	{Name: "gold", Threshold: 5000, Multiplier: 1.25},
+}
+
+// Правила антифрода, доступные в FRAUD_RULES.
+const (
+	FraudUploadVelocity       = "upload_velocity"
+	FraudInvalidRatio         = "invalid_ratio"
+	FraudNewAccountWithdrawal = "new_account_withdrawal"
+)
+
+// FraudRule - правило антифрода и баллы риска, которые оно добавляет при срабатывании.
+type FraudRule struct {
+	Name  string `json:"name"`
+	Score int64  `json:"score"`
+	// Limit - число загрузок заказов за WindowSec для upload_velocity
+	Limit int64 `json:"limit"`
+	// Ratio - доля INVALID среди не менее MinOrders рассчитанных заказов для invalid_ratio
+	Ratio     float64 `json:"ratio"`
+	MinOrders int64   `json:"minOrders"`
+	// WindowSec - окно для upload_velocity и минимальный возраст аккаунта для new_account_withdrawal
+	WindowSec int64 `json:"windowSec"`
+}
+
+// FraudConfig - правила антифрода и пороги суммы баллов риска, с которых действие
+// отправляется на проверку (ReviewScore) или блокируется (BlockScore).
+type FraudConfig struct {
+	ReviewScore int64       `json:"reviewScore"`
+	BlockScore  int64       `json:"blockScore"`
+	Rules       []FraudRule `json:"rules"`
+}
+
+// DefaultFraudConfig используется, если правила не заданы в FRAUD_RULES.
+var DefaultFraudConfig = FraudConfig{
+	ReviewScore: 50,
+	BlockScore:  100,
+	Rules: []FraudRule{
+		{Name: FraudUploadVelocity, Score: 60, Limit: 20, WindowSec: 3600},
+		{Name: FraudInvalidRatio, Score: 50, Ratio: 0.5, MinOrders: 5},
+		{Name: FraudNewAccountWithdrawal, Score: 50, WindowSec: 86400},
+	},
+}
+
+// OrderNumberRule - формат номеров заказов партнера. Номер относится к партнеру, если начинается
+// с одного из Prefixes, правило без Prefixes применяется к остальным номерам.
+type OrderNumberRule struct {
+	Partner   string   `json:"partner"`
+	Prefixes  []string `json:"prefixes"`
+	MinLength int      `json:"minLength"`
+	MaxLength int      `json:"maxLength"`
+	// Charset - допустимые символы номера, по умолчанию только цифры
+	Charset string `json:"charset"`
+	// SkipLuhn отключает проверку контрольной цифры для номеров партнера
+	SkipLuhn bool `json:"skipLuhn"`
+}
+
+// MaxOrderNumberLength - длина колонки номера заказа в БД.
+const MaxOrderNumberLength = 50
+
+// DefaultOrderNumberRules используются, если правила не заданы в ORDER_NUMBER_RULES.
+var DefaultOrderNumberRules = []OrderNumberRule{
+	{Partner: "default", MinLength: 2, MaxLength: MaxOrderNumberLength},
+}
+
 type Config struct {
 	Host                 string `env:"RUN_ADDRESS"`
 	DatabaseURI          string `env:"DATABASE_URI"`
@@ -15,6 +107,34 @@ type Config struct {
 	SecretKey            string `env:"SECRET_KEY"`
 	TokenExpSec          int64  `env:"TOKEN_EXP"`
 	TaskInterval         int64  `env:"TASK_INTERVAL"`
+	APIKeyRateLimit      int64  `env:"API_KEY_RATE_LIMIT"`
+	AccrualPushSecret    string `env:"ACCRUAL_PUSH_SECRET"`
+	BreakerThreshold     int64  `env:"ACCRUAL_BREAKER_THRESHOLD"`
+	BreakerTimeoutSec    int64  `env:"ACCRUAL_BREAKER_TIMEOUT"`
+	AccrualProvidersJSON string `env:"ACCRUAL_PROVIDERS"`
+	AccrualProviders     []AccrualProvider
+	PointsExpiryMonths   int64   `env:"POINTS_EXPIRY_MONTHS"`
+	HoldTTLSec           int64   `env:"HOLD_TTL"`
+	TransferMaxSum       float64 `env:"TRANSFER_MAX_SUM"`
+	TransferDailyLimit   float64 `env:"TRANSFER_DAILY_LIMIT"`
+	LoyaltyTiersJSON     string  `env:"LOYALTY_TIERS"`
+	LoyaltyTiers         []LoyaltyTier
+	ReferrerBonus        float64 `env:"REFERRER_BONUS"`
+	RefereeBonus         float64 `env:"REFEREE_BONUS"`
+	ReferralMaxCount     int64   `env:"REFERRAL_MAX_COUNT"`
+	WithdrawMinSum       float64 `env:"WITHDRAW_MIN_SUM"`
+	WithdrawMaxSum       float64 `env:"WITHDRAW_MAX_SUM"`
+	WithdrawDailyLimit   float64 `env:"WITHDRAW_DAILY_LIMIT"`
+	WithdrawMonthlyLimit float64 `env:"WITHDRAW_MONTHLY_LIMIT"`
+	WithdrawHourlyCount  int64   `env:"WITHDRAW_HOURLY_COUNT"`
+	FraudRulesJSON       string  `env:"FRAUD_RULES"`
+	Fraud                FraudConfig
+	OrderNumberRulesJSON string `env:"ORDER_NUMBER_RULES"`
+	OrderNumberRules     []OrderNumberRule
+	WebhookAllowPrivate  bool   `env:"WEBHOOK_ALLOW_PRIVATE"`
+	TrustedProxiesList   string `env:"TRUSTED_PROXIES"`
+	// TrustedProxies - адреса и подсети прокси, которым доверяется заголовок X-Forwarded-For
+	TrustedProxies []string
 }
 
 func NewConfig() Config {
@@ -32,6 +152,43 @@ func NewConfig() Config {
 	flag.StringVar(&config.SecretKey, "k", "7fd315fd5f381bb9035d003dbd904102", "secret key to hash password")
 	flag.Int64Var(&config.TokenExpSec, "t", 7200, "time in sec to expire token")
 	flag.Int64Var(&config.TaskInterval, "i", 1, "time in sec to update order statuses")
+	flag.Int64Var(&config.APIKeyRateLimit, "l", 60, "default API key rate limit per minute")
+	flag.StringVar(&config.AccrualPushSecret, "p", "", "shared secret to sign accrual push requests, empty disables push")
+	flag.Int64Var(&config.BreakerThreshold, "b", 5, "consecutive accrual system failures to open circuit breaker")
+	flag.Int64Var(&config.BreakerTimeoutSec, "o", 30, "time in sec before circuit breaker probes accrual system")
+	flag.StringVar(&config.AccrualProvidersJSON, "m", "", "JSON list of additional accrual systems with routing rules")
+	flag.Int64Var(&config.PointsExpiryMonths, "e", 0, "months after which accrued points expire, 0 disables expiration")
+	flag.Int64Var(&config.HoldTTLSec, "u", 900, "time in sec before not captured hold is released")
+	flag.Float64Var(&config.TransferMaxSum, "s", 0, "max points in one transfer between users, 0 disables limit")
+	flag.Float64Var(&config.TransferDailyLimit, "y", 0, "max points transferred by user per day, 0 disables limit")
+	flag.StringVar(&config.LoyaltyTiersJSON, "n", "", "JSON list of loyalty tiers with thresholds and multipliers")
+	flag.Float64Var(&config.ReferrerBonus, "f", 100, "points credited to referrer after referee's first processed order")
+	flag.Float64Var(&config.RefereeBonus, "j", 50, "points credited to referee after the first processed order")
+	flag.Int64Var(&config.ReferralMaxCount, "x", 50, "max referrals per user, 0 disables limit")
+	flag.Float64Var(&config.WithdrawMinSum, "c", 0, "min points in one withdrawal, 0 disables limit")
+	flag.Float64Var(&config.WithdrawMaxSum, "w", 0, "max points in one withdrawal, 0 disables limit")
+	flag.Float64Var(&config.WithdrawDailyLimit, "q", 0, "max points withdrawn by user per day, 0 disables limit")
+	flag.Float64Var(&config.WithdrawMonthlyLimit, "v", 0, "max points withdrawn by user per month, 0 disables limit")
+	flag.Int64Var(&config.WithdrawHourlyCount, "H", 0, "max withdrawals by user per hour, 0 disables limit")
+	flag.StringVar(&config.FraudRulesJSON, "z", "", "JSON with fraud rules and review and block scores")
+	flag.StringVar(
+		&config.TrustedProxiesList,
+		"T",
+		"",
+		"comma separated IPs and CIDRs of proxies trusted to set X-Forwarded-For, empty trusts none",
+	)
+	flag.BoolVar(
+		&config.WebhookAllowPrivate,
+		"W",
+		false,
+		"allow webhooks to loopback and private network addresses, for local development only",
+	)
+	flag.StringVar(
+		&config.OrderNumberRulesJSON,
+		"O",
+		"",
+		"JSON list of order number length, prefix and charset rules per partner",
+	)
 	flag.Parse()
 	if len(flag.Args()) > 0 {
 		log.Fatal("used not declared arguments")
@@ -64,5 +221,143 @@ func NewConfig() Config {
 	if envConfig.TaskInterval != 0 {
 		config.TaskInterval = envConfig.TaskInterval
 	}
+	if envConfig.APIKeyRateLimit != 0 {
+		config.APIKeyRateLimit = envConfig.APIKeyRateLimit
+	}
+	if envConfig.AccrualPushSecret != "" {
+		config.AccrualPushSecret = envConfig.AccrualPushSecret
+	}
+	if envConfig.BreakerThreshold != 0 {
+		config.BreakerThreshold = envConfig.BreakerThreshold
+	}
+	if envConfig.BreakerTimeoutSec != 0 {
+		config.BreakerTimeoutSec = envConfig.BreakerTimeoutSec
+	}
+	if envConfig.AccrualProvidersJSON != "" {
+		config.AccrualProvidersJSON = envConfig.AccrualProvidersJSON
+	}
+	if envConfig.PointsExpiryMonths != 0 {
+		config.PointsExpiryMonths = envConfig.PointsExpiryMonths
+	}
+	if envConfig.HoldTTLSec != 0 {
+		config.HoldTTLSec = envConfig.HoldTTLSec
+	}
+	if envConfig.TransferMaxSum != 0 {
+		config.TransferMaxSum = envConfig.TransferMaxSum
+	}
+	if envConfig.TransferDailyLimit != 0 {
+		config.TransferDailyLimit = envConfig.TransferDailyLimit
+	}
+	if envConfig.LoyaltyTiersJSON != "" {
+		config.LoyaltyTiersJSON = envConfig.LoyaltyTiersJSON
+	}
+	// У реферальных настроек ненулевые значения по умолчанию, а 0 отключает бонус или лимит,
+	// поэтому переменная окружения применяется, если она задана, даже со значением 0
+	if _, ok := os.LookupEnv("REFERRER_BONUS"); ok {
+		config.ReferrerBonus = envConfig.ReferrerBonus
+	}
+	if _, ok := os.LookupEnv("REFEREE_BONUS"); ok {
+		config.RefereeBonus = envConfig.RefereeBonus
+	}
+	if _, ok := os.LookupEnv("REFERRAL_MAX_COUNT"); ok {
+		config.ReferralMaxCount = envConfig.ReferralMaxCount
+	}
+	if envConfig.WithdrawMinSum != 0 {
+		config.WithdrawMinSum = envConfig.WithdrawMinSum
+	}
+	if envConfig.WithdrawMaxSum != 0 {
+		config.WithdrawMaxSum = envConfig.WithdrawMaxSum
+	}
+	if envConfig.WithdrawDailyLimit != 0 {
+		config.WithdrawDailyLimit = envConfig.WithdrawDailyLimit
+	}
+	if envConfig.WithdrawMonthlyLimit != 0 {
+		config.WithdrawMonthlyLimit = envConfig.WithdrawMonthlyLimit
+	}
+	if envConfig.WithdrawHourlyCount != 0 {
+		config.WithdrawHourlyCount = envConfig.WithdrawHourlyCount
+	}
+	if envConfig.FraudRulesJSON != "" {
+		config.FraudRulesJSON = envConfig.FraudRulesJSON
+	}
+	if envConfig.OrderNumberRulesJSON != "" {
+		config.OrderNumberRulesJSON = envConfig.OrderNumberRulesJSON
+	}
+	if envConfig.WebhookAllowPrivate {
+		config.WebhookAllowPrivate = true
+	}
+	if envConfig.TrustedProxiesList != "" {
+		config.TrustedProxiesList = envConfig.TrustedProxiesList
+	}
+	for _, proxy := range strings.Split(config.TrustedProxiesList, ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			config.TrustedProxies = append(config.TrustedProxies, proxy)
+		}
+	}
+	if config.AccrualProvidersJSON != "" {
+		err = json.Unmarshal([]byte(config.AccrualProvidersJSON), &config.AccrualProviders)
+		if err != nil {
+			log.Fatal("unable to parse accrual providers: ", err)
+		}
+		for _, provider := range config.AccrualProviders {
+			if provider.Name == "" || provider.Address == "" {
+				log.Fatal("accrual provider must have name and address")
+			}
+		}
+	}
+	config.LoyaltyTiers = DefaultLoyaltyTiers
+	if config.LoyaltyTiersJSON != "" {
+		err = json.Unmarshal([]byte(config.LoyaltyTiersJSON), &config.LoyaltyTiers)
+		if err != nil {
+			log.Fatal("unable to parse loyalty tiers: ", err)
+		}
+		if len(config.LoyaltyTiers) == 0 {
+			log.Fatal("at least one loyalty tier is required")
+		}
+		for _, tier := range config.LoyaltyTiers {
+			if tier.Name == "" || tier.Multiplier <= 0 {
+				log.Fatal("loyalty tier must have name and positive multiplier")
+			}
+		}
+	}
+	config.Fraud = DefaultFraudConfig
+	if config.FraudRulesJSON != "" {
+		config.Fraud = FraudConfig{}
+		err = json.Unmarshal([]byte(config.FraudRulesJSON), &config.Fraud)
+		if err != nil {
+			log.Fatal("unable to parse fraud rules: ", err)
+		}
+		if config.Fraud.ReviewScore <= 0 || config.Fraud.BlockScore < config.Fraud.ReviewScore {
+			log.Fatal("fraud review score must be positive and not greater than block score")
+		}
+		for _, rule := range config.Fraud.Rules {
+			if !slices.Contains([]string{FraudUploadVelocity, FraudInvalidRatio, FraudNewAccountWithdrawal}, rule.Name) {
+				log.Fatal("unknown fraud rule ", rule.Name)
+			}
+			if rule.Score <= 0 {
+				log.Fatal("fraud rule must have positive score")
+			}
+		}
+	}
+	config.OrderNumberRules = DefaultOrderNumberRules
+	if config.OrderNumberRulesJSON != "" {
+		config.OrderNumberRules = nil
+		err = json.Unmarshal([]byte(config.OrderNumberRulesJSON), &config.OrderNumberRules)
+		if err != nil {
+			log.Fatal("unable to parse order number rules: ", err)
+		}
+		if len(config.OrderNumberRules) == 0 {
+			log.Fatal("at least one order number rule is required")
+		}
+		for _, rule := range config.OrderNumberRules {
+			if rule.Partner == "" || rule.MinLength < 0 || rule.MinLength > rule.MaxLength ||
+				rule.MaxLength > MaxOrderNumberLength {
+				log.Fatal("order number rule must have partner and length between 0 and ", MaxOrderNumberLength)
+			}
+			if !rule.SkipLuhn && strings.Trim(rule.Charset, "0123456789") != "" {
+				log.Fatal("order number rule with not digit charset must skip luhn check")
+			}
+		}
+	}
 	return config
 }
@@ -0,0 +1,176 @@
+package events
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	OrderStatusChanged = "order.status"
+	BalanceChanged     = "balance"
+)
+
+const (
+	historySize    = 100
+	subscriberSize = 16
+	// DefaultIdleTTL - время, после которого история пользователя без новых событий и подписчиков удаляется
+	DefaultIdleTTL = 30 * time.Minute
+)
+
+type Event struct {
+	ID    uint64
+	Epoch string
+	Login string
+	Type  string
+	Data  any
+}
+
+// StreamID возвращает идентификатор события для потока вида <epoch>-<id>. Эпоха меняется
+// при каждом запуске процесса, поэтому идентификатор из прошлого запуска не совпадет с новым.
+func (e Event) StreamID() string {
+	return e.Epoch + "-" + strconv.FormatUint(e.ID, 10)
+}
+
+type OrderEvent struct {
+	Number  string  `json:"number"  binding:"required"`
+	Status  string  `json:"status"  binding:"required"`
+	Accrual float32 `json:"accrual" binding:"required"`
+}
+
+type BalanceEvent struct {
+	Current float32 `json:"current" binding:"required"`
+}
+
+// Broker рассылает события пользователям, подписанным в рамках одного процесса.
+// Для каждого пользователя хранятся последние события, чтобы клиент мог
+// продолжить поток с Last-Event-ID после переподключения.
+type Broker struct {
+	// IdleTTL - время хранения истории пользователя, у которого нет новых событий и подписчиков
+	IdleTTL time.Duration
+	// Now возвращает текущее время, в тестах подменяется для проверки удаления истории
+	Now func() time.Time
+
+	mu          sync.Mutex
+	epoch       string
+	lastID      uint64
+	history     map[string]*loginHistory
+	subscribers map[string]map[chan Event]struct{}
+	lastSweep   time.Time
+}
+
+type loginHistory struct {
+	events      []Event
+	publishedAt time.Time
+}
+
+func NewBroker() *Broker {
+	now := time.Now()
+	return &Broker{
+		IdleTTL:     DefaultIdleTTL,
+		Now:         time.Now,
+		epoch:       strconv.FormatInt(now.UnixNano(), 36),
+		history:     make(map[string]*loginHistory),
+		subscribers: make(map[string]map[chan Event]struct{}),
+		lastSweep:   now,
+	}
+}
+
+// Publish сохраняет событие и отправляет его подписчикам пользователя.
+// Медленный подписчик не блокирует публикацию: событие для него пропускается.
+func (b *Broker) Publish(login string, eventType string, data any) Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.Now()
+	b.lastID++
+	event := Event{
+		ID:    b.lastID,
+		Epoch: b.epoch,
+		Login: login,
+		Type:  eventType,
+		Data:  data,
+	}
+	history := b.history[login]
+	if history == nil {
+		history = &loginHistory{}
+		b.history[login] = history
+	}
+	history.events = append(history.events, event)
+	if len(history.events) > historySize {
+		history.events = history.events[len(history.events)-historySize:]
+	}
+	history.publishedAt = now
+	b.evictIdle(now)
+
+	for ch := range b.subscribers[login] {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+	return event
+}
+
+// evictIdle удаляет историю пользователей без подписчиков, у которых не было событий дольше IdleTTL.
+// Проверка выполняется не чаще раза в IdleTTL.
+func (b *Broker) evictIdle(now time.Time) {
+	if now.Sub(b.lastSweep) < b.IdleTTL {
+		return
+	}
+	b.lastSweep = now
+	for login, history := range b.history {
+		if now.Sub(history.publishedAt) >= b.IdleTTL && len(b.subscribers[login]) == 0 {
+			delete(b.history, login)
+		}
+	}
+}
+
+// Subscribe возвращает канал новых событий пользователя и события, пропущенные после lastEventID.
+// Идентификатор из прошлого запуска процесса или нераспознанный идентификатор означает, что клиент
+// пропустил все события текущего запуска, поэтому ему отдается вся сохраненная история.
+// Функцию отписки необходимо вызвать по завершении чтения.
+func (b *Broker) Subscribe(login string, lastEventID string) (<-chan Event, []Event, func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var replay []Event
+	if history := b.history[login]; lastEventID != "" && history != nil {
+		lastID, ok := b.parseStreamID(lastEventID)
+		for _, event := range history.events {
+			if !ok || event.ID > lastID {
+				replay = append(replay, event)
+			}
+		}
+	}
+
+	ch := make(chan Event, subscriberSize)
+	if b.subscribers[login] == nil {
+		b.subscribers[login] = make(map[chan Event]struct{})
+	}
+	b.subscribers[login][ch] = struct{}{}
+
+	unsubscribe := func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.subscribers[login], ch)
+		if len(b.subscribers[login]) == 0 {
+			delete(b.subscribers, login)
+		}
+	}
+	return ch, replay, unsubscribe
+}
+
+// parseStreamID возвращает номер события из идентификатора, выданного текущим процессом.
+func (b *Broker) parseStreamID(streamID string) (uint64, bool) {
+	epoch, id, found := strings.Cut(streamID, "-")
+	if !found || epoch != b.epoch {
+		return 0, false
+	}
+	lastID, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return lastID, true
+}
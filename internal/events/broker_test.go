@@ -0,0 +1,61 @@
+package events_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/pisarevaa/gophermart/internal/events"
+)
+
+const login = "test"
+
+type BrokerTestSuite struct {
+	suite.Suite
+}
+
+func TestBrokerSuite(t *testing.T) {
+	suite.Run(t, new(BrokerTestSuite))
+}
+
+func (suite *BrokerTestSuite) TestStaleIDAndEviction() {
+	broker := events.NewBroker()
+	now := time.Now()
+	broker.Now = func() time.Time { return now }
+	first := broker.Publish(login, events.OrderStatusChanged, events.OrderEvent{Number: "1", Status: "PROCESSING"})
+	broker.Publish(login, events.OrderStatusChanged, events.OrderEvent{Number: "1", Status: "PROCESSED"})
+
+	_, replay, unsubscribe := broker.Subscribe(login, first.StreamID())
+	unsubscribe()
+	suite.Require().Len(replay, 1)
+
+	// Идентификатор, выданный до перезапуска, не сравнивается с новыми номерами событий
+	restarted := events.NewBroker()
+	restarted.Publish(login, events.OrderStatusChanged, events.OrderEvent{Number: "1", Status: "PROCESSED"})
+	_, replay, unsubscribe = restarted.Subscribe(login, first.StreamID())
+	unsubscribe()
+	suite.Require().Len(replay, 1)
+	_, replay, unsubscribe = restarted.Subscribe(login, "100")
+	unsubscribe()
+	suite.Require().Len(replay, 1)
+
+	// История с подписчиком сохраняется, даже если новых событий не было
+	_, _, unsubscribe = broker.Subscribe(login, "")
+	now = now.Add(events.DefaultIdleTTL)
+	broker.Publish("other", events.BalanceChanged, events.BalanceEvent{Current: 1})
+	unsubscribe()
+	_, replay, unsubscribe = broker.Subscribe(login, first.StreamID())
+	unsubscribe()
+	suite.Require().Len(replay, 1)
+
+	// История пользователя без подписчиков и новых событий удаляется
+	now = now.Add(events.DefaultIdleTTL)
+	broker.Publish("other", events.BalanceChanged, events.BalanceEvent{Current: 2})
+	_, replay, unsubscribe = broker.Subscribe(login, first.StreamID())
+	unsubscribe()
+	suite.Require().Empty(replay)
+	_, replay, unsubscribe = broker.Subscribe("other", first.StreamID())
+	unsubscribe()
+	suite.Require().Len(replay, 2)
+}
@@ -0,0 +1,235 @@
+package fakeaccrual
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	RewardPercent = "%"
+	RewardPoints  = "pt"
+)
+
+type Good struct {
+	Description string  `json:"description" binding:"required"`
+	Price       float32 `json:"price"       binding:"required"`
+}
+
+type RegisterOrder struct {
+	Order string `json:"order" binding:"required"`
+	Goods []Good `json:"goods" binding:"required"`
+}
+
+type Reward struct {
+	Match      string  `json:"match"       binding:"required"`
+	Reward     float32 `json:"reward"      binding:"required"`
+	RewardType string  `json:"reward_type" binding:"required"`
+}
+
+type OrderStatus struct {
+	Order   string   `json:"order"             binding:"required"`
+	Status  string   `json:"status"            binding:"required"`
+	Accrual *float32 `json:"accrual,omitempty"`
+}
+
+type order struct {
+	status       string
+	accrual      float32
+	registeredAt time.Time
+}
+
+// Server - встраиваемая замена системы расчета начислений для тестов и локального запуска.
+// Поддерживает тот же API, что и настоящая система, а также позволяет задать статусы заказов,
+// задержку ответа, ответы 429 и 500 и время расчета зарегистрированных заказов.
+type Server struct {
+	mu             sync.Mutex
+	orders         map[string]order
+	rewards        []Reward
+	delay          time.Duration
+	processingTime time.Duration
+	throttled      int
+	retryAfter     time.Duration
+	failures       int
+}
+
+func NewServer() *Server {
+	return &Server{
+		orders:     make(map[string]order),
+		retryAfter: time.Minute,
+	}
+}
+
+// SetOrder задает ответ по заказу. Заказы, о которых сервер не знает, возвращают 204.
+func (s *Server) SetOrder(number string, status string, accrual float32) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.orders[number] = order{status: status, accrual: accrual}
+}
+
+// DeleteOrder возвращает заказ в состояние "не зарегистрирован".
+func (s *Server) DeleteOrder(number string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.orders, number)
+}
+
+// SetDelay задает задержку перед каждым ответом.
+func (s *Server) SetDelay(delay time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.delay = delay
+}
+
+// SetProcessingTime задает время, в течение которого заказ, зарегистрированный через
+// POST /api/orders, находится в статусе PROCESSING.
+func (s *Server) SetProcessingTime(processingTime time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.processingTime = processingTime
+}
+
+// SetTooManyRequests заставляет сервер ответить 429 на следующие count запросов.
+func (s *Server) SetTooManyRequests(count int, retryAfter time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.throttled = count
+	s.retryAfter = retryAfter
+}
+
+// SetInternalErrors заставляет сервер ответить 500 на следующие count запросов.
+func (s *Server) SetInternalErrors(count int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = count
+}
+
+func (s *Server) Handler() http.Handler {
+	r := gin.New()
+	r.Use(gin.Recovery(), s.simulate())
+	r.GET("/api/orders/:number", s.getOrder)
+	r.POST("/api/orders", s.registerOrder)
+	r.POST("/api/goods", s.registerReward)
+	return r
+}
+
+func (s *Server) simulate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		s.mu.Lock()
+		delay := s.delay
+		throttled := s.throttled > 0
+		if throttled {
+			s.throttled--
+		}
+		retryAfter := s.retryAfter
+		failed := s.failures > 0
+		if failed {
+			s.failures--
+		}
+		s.mu.Unlock()
+
+		if delay > 0 {
+			select {
+			case <-time.After(delay):
+			case <-c.Request.Context().Done():
+				c.Abort()
+				return
+			}
+		}
+		if failed {
+			c.AbortWithStatus(http.StatusInternalServerError)
+			return
+		}
+		if throttled {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			c.AbortWithStatus(http.StatusTooManyRequests)
+			return
+		}
+		c.Next()
+	}
+}
+
+func (s *Server) getOrder(c *gin.Context) {
+	number := c.Param("number")
+	s.mu.Lock()
+	o, ok := s.orders[number]
+	processingTime := s.processingTime
+	s.mu.Unlock()
+	if !ok {
+		c.Status(http.StatusNoContent)
+		return
+	}
+	status := o.status
+	if !o.registeredAt.IsZero() && time.Since(o.registeredAt) < processingTime {
+		status = "PROCESSING"
+	}
+	response := OrderStatus{
+		Order:  number,
+		Status: status,
+	}
+	if status == "PROCESSED" {
+		accrual := o.accrual
+		response.Accrual = &accrual
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+func (s *Server) registerOrder(c *gin.Context) {
+	var request RegisterOrder
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.orders[request.Order]; ok {
+		c.Status(http.StatusConflict)
+		return
+	}
+	var accrual float32
+	for _, good := range request.Goods {
+		for _, reward := range s.rewards {
+			if !strings.Contains(good.Description, reward.Match) {
+				continue
+			}
+			if reward.RewardType == RewardPercent {
+				accrual += good.Price * reward.Reward / 100
+			} else {
+				accrual += reward.Reward
+			}
+		}
+	}
+	s.orders[request.Order] = order{
+		status:       "PROCESSED",
+		accrual:      accrual,
+		registeredAt: time.Now(),
+	}
+	c.Status(http.StatusAccepted)
+}
+
+func (s *Server) registerReward(c *gin.Context) {
+	var reward Reward
+	if err := c.ShouldBindJSON(&reward); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if reward.RewardType != RewardPercent && reward.RewardType != RewardPoints {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unknown reward type"})
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, existing := range s.rewards {
+		if existing.Match == reward.Match {
+			c.Status(http.StatusConflict)
+			return
+		}
+	}
+	s.rewards = append(s.rewards, reward)
+	c.Status(http.StatusOK)
+}
@@ -0,0 +1,109 @@
+package fraud
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pisarevaa/gophermart/internal/configs"
+	"github.com/pisarevaa/gophermart/internal/storage"
+)
+
+// Действия пользователя, которые оцениваются правилами.
+const (
+	ActionOrderUpload = "order_upload"
+	ActionWithdrawal  = "withdrawal"
+)
+
+const (
+	DecisionAllow  = "allow"
+	DecisionReview = "review"
+	DecisionBlock  = "block"
+)
+
+// Source - данные, по которым правила оценивают действие пользователя.
+type Source interface {
+	GetUser(ctx context.Context, login string) (user storage.User, err error)
+	GetUploadedOrdersCount(ctx context.Context, login string, since time.Time) (count int64, err error)
+	GetOrdersCountByStatus(ctx context.Context, login string) (counts map[string]int64, err error)
+}
+
+// Event - оцениваемое действие пользователя.
+type Event struct {
+	Action      string
+	Login       string
+	OrderNumber string
+	Sum         float32
+	At          time.Time
+	// Pending - заказы, принятые раньше в том же пакете и еще не сохраненные
+	Pending int64
+}
+
+// Rule - правило антифрода. Возвращает баллы риска и причину или 0, если правило не сработало.
+type Rule interface {
+	Evaluate(ctx context.Context, source Source, event Event) (score int64, reason string, err error)
+}
+
+// Assessment - итог оценки действия: сумма баллов сработавших правил, решение и причины.
+type Assessment struct {
+	Score    int64
+	Decision string
+	Reasons  []string
+}
+
+// Engine складывает баллы риска сработавших правил и принимает решение по порогам.
+type Engine struct {
+	rules       []Rule
+	reviewScore int64
+	blockScore  int64
+}
+
+func NewEngine(reviewScore int64, blockScore int64, rules ...Rule) *Engine {
+	return &Engine{
+		rules:       rules,
+		reviewScore: reviewScore,
+		blockScore:  blockScore,
+	}
+}
+
+// NewEngineFromConfig создает движок со встроенными правилами из конфигурации.
+// Неизвестное правило - ошибка конфигурации, а не повод молча отключить проверку.
+func NewEngineFromConfig(config configs.FraudConfig) (*Engine, error) {
+	rules := make([]Rule, 0, len(config.Rules))
+	for _, rule := range config.Rules {
+		window := time.Duration(rule.WindowSec) * time.Second
+		switch rule.Name {
+		case configs.FraudUploadVelocity:
+			rules = append(rules, UploadVelocity{Limit: rule.Limit, Window: window, Score: rule.Score})
+		case configs.FraudInvalidRatio:
+			rules = append(rules, InvalidRatio{Ratio: rule.Ratio, MinOrders: rule.MinOrders, Score: rule.Score})
+		case configs.FraudNewAccountWithdrawal:
+			rules = append(rules, NewAccountWithdrawal{MinAge: window, Score: rule.Score})
+		default:
+			return nil, fmt.Errorf("unknown fraud rule %q", rule.Name)
+		}
+	}
+	return NewEngine(config.ReviewScore, config.BlockScore, rules...), nil
+}
+
+// Evaluate оценивает действие всеми правилами.
+func (e *Engine) Evaluate(ctx context.Context, source Source, event Event) (Assessment, error) {
+	assessment := Assessment{Decision: DecisionAllow}
+	for _, rule := range e.rules {
+		score, reason, err := rule.Evaluate(ctx, source, event)
+		if err != nil {
+			return assessment, err
+		}
+		if score > 0 {
+			assessment.Score += score
+			assessment.Reasons = append(assessment.Reasons, reason)
+		}
+	}
+	switch {
+	case e.blockScore > 0 && assessment.Score >= e.blockScore:
+		assessment.Decision = DecisionBlock
+	case e.reviewScore > 0 && assessment.Score >= e.reviewScore:
+		assessment.Decision = DecisionReview
+	}
+	return assessment, nil
+}
@@ -0,0 +1,97 @@
+package fraud_test
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/pisarevaa/gophermart/internal/configs"
+	"github.com/pisarevaa/gophermart/internal/fraud"
+	"github.com/pisarevaa/gophermart/internal/storage"
+)
+
+type EngineTestSuite struct {
+	suite.Suite
+	engine *fraud.Engine
+	source *storage.MemoryStorage
+	now    time.Time
+}
+
+func (suite *EngineTestSuite) SetupTest() {
+	engine, err := fraud.NewEngineFromConfig(configs.DefaultFraudConfig)
+	suite.Require().NoError(err)
+	suite.engine = engine
+	suite.source = storage.NewMemory()
+	suite.now = time.Now()
+	suite.source.Users["test"] = storage.User{Login: "test", CreatedAt: suite.now.AddDate(0, 0, -30)}
+}
+
+func TestEngineSuite(t *testing.T) {
+	suite.Run(t, new(EngineTestSuite))
+}
+
+func (suite *EngineTestSuite) addOrders(count int, status string) {
+	for range count {
+		number := strconv.Itoa(len(suite.source.Orders))
+		suite.source.Orders[number] = storage.Order{
+			Number:     number,
+			Status:     status,
+			Login:      "test",
+			UploadedAt: suite.now.AddDate(0, 0, -1),
+		}
+	}
+}
+
+func (suite *EngineTestSuite) TestAllow() {
+	suite.addOrders(3, "PROCESSED")
+	assessment, err := suite.engine.Evaluate(context.Background(), suite.source, fraud.Event{
+		Action: fraud.ActionWithdrawal,
+		Login:  "test",
+		At:     suite.now,
+	})
+	suite.Require().NoError(err)
+	suite.Require().Equal(fraud.DecisionAllow, assessment.Decision)
+	suite.Require().Empty(assessment.Reasons)
+}
+
+func (suite *EngineTestSuite) TestReview() {
+	suite.addOrders(3, "INVALID")
+	suite.addOrders(2, "PROCESSED")
+	assessment, err := suite.engine.Evaluate(context.Background(), suite.source, fraud.Event{
+		Action: fraud.ActionOrderUpload,
+		Login:  "test",
+		At:     suite.now,
+	})
+	suite.Require().NoError(err)
+	suite.Require().Equal(fraud.DecisionReview, assessment.Decision)
+	suite.Require().Equal(int64(50), assessment.Score)
+	suite.Require().Equal([]string{"3 of 5 orders are invalid"}, assessment.Reasons)
+}
+
+func (suite *EngineTestSuite) TestBlock() {
+	suite.addOrders(4, "INVALID")
+	suite.addOrders(1, "PROCESSED")
+	user := suite.source.Users["test"]
+	user.CreatedAt = suite.now.Add(-time.Hour)
+	suite.source.Users["test"] = user
+	assessment, err := suite.engine.Evaluate(context.Background(), suite.source, fraud.Event{
+		Action: fraud.ActionWithdrawal,
+		Login:  "test",
+		At:     suite.now,
+	})
+	suite.Require().NoError(err)
+	suite.Require().Equal(fraud.DecisionBlock, assessment.Decision)
+	suite.Require().Equal(int64(100), assessment.Score)
+	suite.Require().Len(assessment.Reasons, 2)
+}
+
+func (suite *EngineTestSuite) TestUnknownRule() {
+	config := configs.DefaultFraudConfig
+	config.Rules = append(slices.Clone(config.Rules), configs.FraudRule{Name: "unknown", Score: 100})
+	_, err := fraud.NewEngineFromConfig(config)
+	suite.Require().Error(err)
+}
@@ -0,0 +1,75 @@
+package fraud
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// UploadVelocity срабатывает, если с загружаемым заказом пользователь загрузил больше Limit заказов за Window.
+type UploadVelocity struct {
+	Limit  int64
+	Window time.Duration
+	Score  int64
+}
+
+func (r UploadVelocity) Evaluate(ctx context.Context, source Source, event Event) (int64, string, error) {
+	if event.Action != ActionOrderUpload || r.Limit <= 0 {
+		return 0, "", nil
+	}
+	count, err := source.GetUploadedOrdersCount(ctx, event.Login, event.At.Add(-r.Window))
+	if err != nil {
+		return 0, "", err
+	}
+	count += event.Pending + 1
+	if count <= r.Limit {
+		return 0, "", nil
+	}
+	return r.Score, fmt.Sprintf("%d orders uploaded in %s", count, r.Window), nil
+}
+
+// InvalidRatio срабатывает, если среди не менее MinOrders рассчитанных заказов пользователя
+// доля INVALID не меньше Ratio.
+type InvalidRatio struct {
+	Ratio     float64
+	MinOrders int64
+	Score     int64
+}
+
+func (r InvalidRatio) Evaluate(ctx context.Context, source Source, event Event) (int64, string, error) {
+	counts, err := source.GetOrdersCountByStatus(ctx, event.Login)
+	if err != nil {
+		return 0, "", err
+	}
+	invalid := counts["INVALID"]
+	finalized := invalid + counts["PROCESSED"]
+	if finalized == 0 || finalized < r.MinOrders {
+		return 0, "", nil
+	}
+	ratio := float64(invalid) / float64(finalized)
+	if ratio < r.Ratio {
+		return 0, "", nil
+	}
+	return r.Score, fmt.Sprintf("%d of %d orders are invalid", invalid, finalized), nil
+}
+
+// NewAccountWithdrawal срабатывает на списание с аккаунта, зарегистрированного менее MinAge назад.
+type NewAccountWithdrawal struct {
+	MinAge time.Duration
+	Score  int64
+}
+
+func (r NewAccountWithdrawal) Evaluate(ctx context.Context, source Source, event Event) (int64, string, error) {
+	if event.Action != ActionWithdrawal {
+		return 0, "", nil
+	}
+	user, err := source.GetUser(ctx, event.Login)
+	if err != nil {
+		return 0, "", err
+	}
+	age := event.At.Sub(user.CreatedAt)
+	if age >= r.MinAge {
+		return 0, "", nil
+	}
+	return r.Score, fmt.Sprintf("account is registered %s ago", age.Truncate(time.Minute)), nil
+}
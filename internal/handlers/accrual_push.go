@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/pisarevaa/gophermart/internal/accrual"
+	"github.com/pisarevaa/gophermart/internal/events"
+	"github.com/pisarevaa/gophermart/internal/storage"
+	"github.com/pisarevaa/gophermart/internal/tasks"
+	"github.com/pisarevaa/gophermart/internal/utils"
+)
+
+type PushAccrual struct {
+	Order   string  `json:"order"   binding:"required"`
+	Status  string  `json:"status"  binding:"required"`
+	Accrual float32 `json:"accrual"`
+}
+
+// PushAccrual godoc
+//
+//	@Summary		Push order's accrual result
+//	@Description	Called by the accrual system. Body is signed with HMAC-SHA256 of "<timestamp>.<body>" using the shared secret.
+//	@Description	INVALID or a lower accrual for a processed order reverses the difference from user's balance.
+//	@Schemes
+//	@Tags		Internal
+//	@Accept		json
+//	@Produce	json
+//	@Param		request				body	PushAccrual	true	"Body"
+//	@Param		X-Accrual-Timestamp	header	int			true	"Unix timestamp"
+//	@Param		X-Accrual-Signature	header	string		true	"sha256=<hex>"
+//	@Success	200	{object}	storage.Success	"Order is finalized, accrual is reversed or result is already applied"
+//	@Success	202	{object}	storage.Success	"Intermediate status is stored"
+//	@Failure	401	{object}	utils.Problem	"Signature is invalid"
+//	@Failure	404	{object}	utils.Problem	"Order is not found"
+//	@Failure	409	{object}	utils.Problem	"Order is already finalized with another result"
+//	@Failure	422	{object}	utils.Problem	"Unprocessable Entity"
+//	@Failure	500	{object}	utils.Problem	"Error"
+//	@Router		/api/internal/accrual [post]
+func (s *Service) PushAccrual(c *gin.Context) {
+	var push PushAccrual
+	if err := c.ShouldBindJSON(&push); err != nil {
+		utils.Abort(c, utils.NewProblem(http.StatusUnprocessableEntity, err.Error()))
+		return
+	}
+	status := storage.OrderStatus{
+		Number:   push.Order,
+		Status:   push.Status,
+		Accrual:  push.Accrual,
+		Provider: accrual.PushProvider,
+	}
+	if err := tasks.ValidateOrderStatus(status); err != nil {
+		utils.Abort(c, utils.NewProblem(http.StatusUnprocessableEntity, err.Error()))
+		return
+	}
+
+	tx, err := s.Repo.BeginTransaction(c)
+	if err != nil {
+		utils.Abort(c, err)
+		return
+	}
+	defer tx.Rollback(c) //nolint:errcheck // ignore check
+
+	order, err := tx.GetOrderForUpdate(c, status.Number)
+	if err != nil {
+		utils.Abort(c, utils.NewProblem(http.StatusNotFound, "order is not found"))
+		return
+	}
+	// Система расчета может повторить отправку, повтор того же результата не является ошибкой
+	if tasks.IsFinalStatus(order.Status) {
+		if order.Status == status.Status && order.Accrual == status.Accrual {
+			c.JSON(http.StatusOK, storage.Success{Success: true})
+			return
+		}
+		// INVALID или меньшая сумма по уже начисленному заказу (например, возврат товара)
+		// списывает разницу с баланса пользователя
+		if tasks.IsReversal(order, status) {
+			s.reverseOrderAccrual(c, tx, order, status, "system", "reported by accrual system")
+			return
+		}
+		utils.Abort(c, utils.NewProblem(http.StatusConflict, "order is already finalized"))
+		return
+	}
+
+	if !tasks.IsFinalStatus(status.Status) {
+		err = tx.StorePushedOrderStatus(c, status)
+		if err != nil {
+			utils.Abort(c, err)
+			return
+		}
+		err = tx.Commit(c)
+		if err != nil {
+			utils.Abort(c, err)
+			return
+		}
+		if order.Status != status.Status {
+			s.Broker.Publish(order.Login, events.OrderStatusChanged, events.OrderEvent{
+				Number: status.Number,
+				Status: status.Status,
+			})
+		}
+		c.JSON(http.StatusAccepted, storage.Success{Success: true})
+		return
+	}
+
+	policy := tasks.NewAccrualPolicy(s.Config, time.Now())
+	balanceAfter, err := tasks.ApplyOrderStatus(c, tx, order.Login, status, policy, c.GetString("RequestID"))
+	if err != nil {
+		utils.Abort(c, err)
+		return
+	}
+	err = tx.Commit(c)
+	if err != nil {
+		utils.Abort(c, err)
+		return
+	}
+	tasks.PublishOrderStatus(s.Broker, order.Login, status, balanceAfter)
+
+	s.Logger.Info("order ", status.Number, " is finalized by push with status ", status.Status)
+
+	c.JSON(http.StatusOK, storage.Success{Success: true})
+}
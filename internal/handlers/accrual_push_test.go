@@ -0,0 +1,74 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strconv"
+	"time"
+
+	server "github.com/pisarevaa/gophermart/internal"
+	"github.com/pisarevaa/gophermart/internal/events"
+	"github.com/pisarevaa/gophermart/internal/handlers"
+	"github.com/pisarevaa/gophermart/internal/storage"
+	"github.com/pisarevaa/gophermart/internal/utils"
+)
+
+func (suite *ServerTestSuite) TestPushAccrualInMemory() {
+	const secret = "push-secret"
+	cfg := suite.cfg
+	cfg.AccrualPushSecret = secret
+
+	m := storage.NewMemory()
+	m.Users[login] = storage.User{
+		Login: login,
+		Role:  storage.RoleUser,
+	}
+	ctx := context.Background()
+	suite.Require().NoError(m.StoreOrder(ctx, "12345678903", login))
+
+	ts := httptest.NewServer(server.NewRouter(cfg, suite.logger, m, events.NewBroker(), nil))
+	defer ts.Close()
+
+	push := func(request handlers.PushAccrual, signSecret string) int {
+		body, err := json.Marshal(request)
+		suite.Require().NoError(err)
+		timestamp := time.Now().Unix()
+		resp, err := suite.client.R().
+			SetBody(body).
+			SetHeader("Content-Type", "application/json").
+			SetHeader(utils.AccrualTimestampHeader, strconv.FormatInt(timestamp, 10)).
+			SetHeader(utils.AccrualSignatureHeader, utils.SignWebhookPayload(signSecret, timestamp, body)).
+			Post(ts.URL + "/api/internal/accrual")
+		suite.Require().NoError(err)
+		return resp.StatusCode()
+	}
+
+	suite.Require().Equal(401, push(handlers.PushAccrual{Order: "12345678903", Status: "PROCESSED", Accrual: 100}, "wrong"))
+	suite.Require().Equal(422, push(handlers.PushAccrual{Order: "12345678903", Status: "DONE"}, secret))
+	suite.Require().Equal(404, push(handlers.PushAccrual{Order: "79927398713", Status: "PROCESSED"}, secret))
+
+	suite.Require().Equal(202, push(handlers.PushAccrual{Order: "12345678903", Status: "PROCESSING"}, secret))
+	suite.Require().Equal("PROCESSING", m.Orders["12345678903"].Status)
+	count, err := m.GetOrdersCountToUpdate(ctx)
+	suite.Require().NoError(err)
+	suite.Require().Equal(int64(0), count)
+
+	suite.Require().Equal(200, push(handlers.PushAccrual{Order: "12345678903", Status: "PROCESSED", Accrual: 100}, secret))
+	suite.Require().Equal("PROCESSED", m.Orders["12345678903"].Status)
+	suite.Require().Equal(float32(100), m.Users[login].Balance)
+	suite.Require().Len(m.Movements, 1)
+
+	suite.Require().Equal(200, push(handlers.PushAccrual{Order: "12345678903", Status: "PROCESSED", Accrual: 100}, secret))
+	suite.Require().Equal(409, push(handlers.PushAccrual{Order: "12345678903", Status: "PROCESSED", Accrual: 150}, secret))
+	suite.Require().Equal(float32(100), m.Users[login].Balance)
+
+	suite.Require().Equal(200, push(handlers.PushAccrual{Order: "12345678903", Status: "PROCESSED", Accrual: 40}, secret))
+	suite.Require().Equal(float32(40), m.Users[login].Balance)
+	suite.Require().Equal(200, push(handlers.PushAccrual{Order: "12345678903", Status: "INVALID"}, secret))
+	suite.Require().Equal("INVALID", m.Orders["12345678903"].Status)
+	suite.Require().Equal(float32(0), m.Users[login].Balance)
+	suite.Require().Len(m.Movements, 3)
+	suite.Require().Equal(storage.MovementReversal, m.Movements[2].Kind)
+	suite.Require().Nil(m.Users[login].FlaggedAt)
+}
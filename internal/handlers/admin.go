@@ -0,0 +1,295 @@
+package handlers
+
+import (
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/pisarevaa/gophermart/internal/events"
+	"github.com/pisarevaa/gophermart/internal/storage"
+	"github.com/pisarevaa/gophermart/internal/utils"
+)
+
+type AdminUserInfo struct {
+	Login      string     `json:"login"      binding:"required"`
+	Role       string     `json:"role"       binding:"required"`
+	Balance    float32    `json:"balance"    binding:"required"`
+	Withdrawn  float32    `json:"withdrawn"  binding:"required"`
+	FlaggedAt  *time.Time `json:"flaggedAt"`
+	FlagReason string     `json:"flagReason"`
+}
+
+type AdminOrderResponse struct {
+	OrderReponse
+	AccrualProvider *string `json:"accrualProvider"`
+}
+
+type AdjustBalance struct {
+	Amount float32 `json:"amount" binding:"required"`
+	Reason string  `json:"reason" binding:"required"`
+}
+
+type SetRole struct {
+	Role string `json:"role" binding:"required"`
+}
+
+// AdminAudit сохраняет каждое обращение к административному API вместе с логином оператора.
+func (s *Service) AdminAudit() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		action := storage.AdminAction{
+			Operator:   c.GetString("Login"),
+			Method:     c.Request.Method,
+			Path:       c.FullPath(),
+			Target:     c.Param("login") + c.Param("number") + c.Param("id"),
+			StatusCode: c.Writer.Status(),
+		}
+		err := s.Repo.StoreAdminAction(c, action)
+		if err != nil {
+			s.Logger.Error("error to store admin action: ", err)
+		}
+	}
+}
+
+// AdminGetUser godoc
+//
+//	@Summary	Get user info
+//	@Schemes
+//	@Tags		Admin
+//	@Produce	json
+//	@Param		login			path	string	true	"User login"
+//	@Param		Authorization	header	string	true	"Bearer"
+//	@Security	ApiKeyAuth
+//	@Success	200	{object}	AdminUserInfo	"Response"
+//	@Failure	401	{object}	utils.Problem	"Unauthorized"
+//	@Failure	403	{object}	utils.Problem	"Forbidden"
+//	@Failure	404	{object}	utils.Problem	"User is not found"
+//	@Router		/api/admin/users/{login} [get]
+func (s *Service) AdminGetUser(c *gin.Context) {
+	user, err := s.Repo.GetUser(c, c.Param("login"))
+	if err != nil {
+		utils.Abort(c, utils.NewProblem(http.StatusNotFound, "user is not found"))
+		return
+	}
+	c.JSON(http.StatusOK, newAdminUserInfo(user))
+}
+
+func newAdminUserInfo(user storage.User) AdminUserInfo {
+	return AdminUserInfo{
+		Login:      user.Login,
+		Role:       user.Role,
+		Balance:    user.Balance,
+		Withdrawn:  user.Withdrawn,
+		FlaggedAt:  user.FlaggedAt,
+		FlagReason: user.FlagReason,
+	}
+}
+
+// AdminGetUserOrders godoc
+//
+//	@Summary	Get user's orders
+//	@Schemes
+//	@Tags		Admin
+//	@Produce	json
+//	@Param		login			path	string	true	"User login"
+//	@Param		Authorization	header	string	true	"Bearer"
+//	@Security	ApiKeyAuth
+//	@Success	200	{object}	[]AdminOrderResponse	"Response"
+//	@Success	204	{object}	storage.Success			"No orders"
+//	@Failure	401	{object}	utils.Problem			"Unauthorized"
+//	@Failure	403	{object}	utils.Problem			"Forbidden"
+//	@Failure	500	{object}	utils.Problem			"Error"
+//	@Router		/api/admin/users/{login}/orders [get]
+func (s *Service) AdminGetUserOrders(c *gin.Context) {
+	orders, err := s.Repo.GetOrders(c, c.Param("login"), false)
+	if err != nil {
+		utils.Abort(c, err)
+		return
+	}
+	if len(orders) == 0 {
+		c.Status(http.StatusNoContent)
+		return
+	}
+	ordersResponse := make([]AdminOrderResponse, 0, len(orders))
+	for i, order := range newOrdersResponse(orders) {
+		ordersResponse = append(ordersResponse, AdminOrderResponse{
+			OrderReponse:    order,
+			AccrualProvider: orders[i].AccrualProvider,
+		})
+	}
+	c.JSON(http.StatusOK, ordersResponse)
+}
+
+// AdminGetUserWithdrawals godoc
+//
+//	@Summary	Get user's withdrawals
+//	@Schemes
+//	@Tags		Admin
+//	@Produce	json
+//	@Param		login			path	string	true	"User login"
+//	@Param		Authorization	header	string	true	"Bearer"
+//	@Security	ApiKeyAuth
+//	@Success	200	{object}	[]WithdrawalsReponse	"Response"
+//	@Success	204	{object}	storage.Success			"No withdrawals"
+//	@Failure	401	{object}	utils.Problem			"Unauthorized"
+//	@Failure	403	{object}	utils.Problem			"Forbidden"
+//	@Failure	500	{object}	utils.Problem			"Error"
+//	@Router		/api/admin/users/{login}/withdrawals [get]
+func (s *Service) AdminGetUserWithdrawals(c *gin.Context) {
+	orders, err := s.Repo.GetOrders(c, c.Param("login"), true)
+	if err != nil {
+		utils.Abort(c, err)
+		return
+	}
+	if len(orders) == 0 {
+		c.Status(http.StatusNoContent)
+		return
+	}
+	c.JSON(http.StatusOK, newWithdrawalsResponse(orders))
+}
+
+// AdminAdjustBalance godoc
+//
+//	@Summary	Adjust user's balance
+//	@Schemes
+//	@Tags		Admin
+//	@Accept		json
+//	@Produce	json
+//	@Param		login			path	string			true	"User login"
+//	@Param		request			body	AdjustBalance	true	"Body"
+//	@Param		Authorization	header	string			true	"Bearer"
+//	@Security	ApiKeyAuth
+//	@Success	200	{object}	AdminUserInfo	"Response"
+//	@Failure	401	{object}	utils.Problem	"Unauthorized"
+//	@Failure	403	{object}	utils.Problem	"Forbidden"
+//	@Failure	404	{object}	utils.Problem	"User is not found"
+//	@Failure	422	{object}	utils.Problem	"Unprocessable Entity"
+//	@Failure	500	{object}	utils.Problem	"Error"
+//	@Router		/api/admin/users/{login}/balance [post]
+func (s *Service) AdminAdjustBalance(c *gin.Context) {
+	login := c.Param("login")
+	var adjust AdjustBalance
+	if err := c.ShouldBindJSON(&adjust); err != nil {
+		utils.Abort(c, utils.NewProblem(http.StatusUnprocessableEntity, err.Error()))
+		return
+	}
+
+	tx, err := s.Repo.BeginTransaction(c)
+	if err != nil {
+		utils.Abort(c, err)
+		return
+	}
+	defer tx.Rollback(c) //nolint:errcheck // ignore check
+
+	user, err := tx.GetUserWithLock(c, login)
+	if err != nil {
+		utils.Abort(c, utils.NewProblem(http.StatusNotFound, "user is not found"))
+		return
+	}
+
+	if user.Balance+adjust.Amount < 0 {
+		utils.Abort(c, utils.NewProblem(http.StatusUnprocessableEntity, "balance can't be negative"))
+		return
+	}
+
+	err = tx.AccrualUserBalance(c, adjust.Amount, login)
+	if err != nil {
+		utils.Abort(c, err)
+		return
+	}
+
+	// Ручное начисление не сгорает, а списание расходует самые старые партии баллов
+	if adjust.Amount < 0 {
+		_, err = tx.ConsumePointLots(c, login, -adjust.Amount)
+		if err != nil {
+			utils.Abort(c, err)
+			return
+		}
+	}
+
+	err = tx.StoreBalanceMovement(c, storage.BalanceMovement{
+		Login:    login,
+		Kind:     storage.MovementAdjustment,
+		Amount:   adjust.Amount,
+		Reason:   adjust.Reason,
+		Operator: c.GetString("Login"),
+	})
+	if err != nil {
+		utils.Abort(c, err)
+		return
+	}
+
+	err = tx.StoreAuditEvent(c, withAmounts(
+		newAuditEvent(c, c.GetString("Login"), storage.AuditBalanceAdjusted, login),
+		user.Balance,
+		user.Balance+adjust.Amount,
+	))
+	if err != nil {
+		utils.Abort(c, err)
+		return
+	}
+
+	err = tx.Commit(c)
+	if err != nil {
+		utils.Abort(c, err)
+		return
+	}
+
+	s.Broker.Publish(login, events.BalanceChanged, events.BalanceEvent{
+		Current: user.Balance + adjust.Amount,
+	})
+
+	s.Logger.Info(
+		"balance of ", login, " is adjusted by ", adjust.Amount,
+		" operator ", c.GetString("Login"), " reason ", adjust.Reason,
+	)
+
+	c.JSON(http.StatusOK, AdminUserInfo{
+		Login:     user.Login,
+		Role:      user.Role,
+		Balance:   user.Balance + adjust.Amount,
+		Withdrawn: user.Withdrawn,
+	})
+}
+
+// AdminSetRole godoc
+//
+//	@Summary	Set user's role
+//	@Schemes
+//	@Tags		Admin
+//	@Accept		json
+//	@Produce	json
+//	@Param		login			path	string	true	"User login"
+//	@Param		request			body	SetRole	true	"Body"
+//	@Param		Authorization	header	string	true	"Bearer"
+//	@Security	ApiKeyAuth
+//	@Success	200	{object}	storage.Success	"Response"
+//	@Failure	401	{object}	utils.Problem	"Unauthorized"
+//	@Failure	403	{object}	utils.Problem	"Forbidden"
+//	@Failure	404	{object}	utils.Problem	"User is not found"
+//	@Failure	422	{object}	utils.Problem	"Unknown role"
+//	@Router		/api/admin/users/{login}/role [put]
+func (s *Service) AdminSetRole(c *gin.Context) {
+	var role SetRole
+	if err := c.ShouldBindJSON(&role); err != nil {
+		utils.Abort(c, utils.NewProblem(http.StatusUnprocessableEntity, err.Error()))
+		return
+	}
+	if !slices.Contains([]string{storage.RoleUser, storage.RoleSupport, storage.RoleAdmin}, role.Role) {
+		utils.Abort(c, utils.NewProblem(http.StatusUnprocessableEntity, "unknown role"))
+		return
+	}
+
+	err := s.Repo.SetUserRole(c, c.Param("login"), role.Role)
+	if err != nil {
+		utils.Abort(c, utils.NewProblem(http.StatusNotFound, "user is not found"))
+		return
+	}
+	s.storeAuditEvent(c, newAuditEvent(c, c.GetString("Login"), storage.AuditUserRoleChanged, c.Param("login")))
+
+	c.JSON(http.StatusOK, storage.Success{
+		Success: true,
+	})
+}
@@ -0,0 +1,229 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/pisarevaa/gophermart/internal/events"
+	"github.com/pisarevaa/gophermart/internal/storage"
+	"github.com/pisarevaa/gophermart/internal/utils"
+)
+
+var errNegativeBalance = errors.New("user's balance can't be negative")
+
+type RepollOrder struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+type OverrideOrderStatus struct {
+	Status  string  `json:"status"  binding:"required"`
+	Accrual float32 `json:"accrual"`
+	Reason  string  `json:"reason"  binding:"required"`
+}
+
+// AdminRepollOrder godoc
+//
+//	@Summary	Force re-poll of an order from the accrual system
+//	@Schemes
+//	@Tags		Admin
+//	@Accept		json
+//	@Produce	json
+//	@Param		number			path	string		true	"Order number"
+//	@Param		request			body	RepollOrder	true	"Body"
+//	@Param		Authorization	header	string		true	"Bearer"
+//	@Security	ApiKeyAuth
+//	@Success	200	{object}	OrderReponse	"Response"
+//	@Failure	401	{object}	utils.Problem	"Unauthorized"
+//	@Failure	403	{object}	utils.Problem	"Forbidden"
+//	@Failure	404	{object}	utils.Problem	"Order is not found"
+//	@Failure	422	{object}	utils.Problem	"Unprocessable Entity"
+//	@Failure	500	{object}	utils.Problem	"Error"
+//	@Router		/api/admin/orders/{number}/repoll [post]
+func (s *Service) AdminRepollOrder(c *gin.Context) {
+	var repoll RepollOrder
+	if err := c.ShouldBindJSON(&repoll); err != nil {
+		utils.Abort(c, utils.NewProblem(http.StatusUnprocessableEntity, err.Error()))
+		return
+	}
+	// Заказ возвращается в статус NEW, ранее начисленные баллы списываются,
+	// и фоновая задача заново запросит его в системе расчета начислений.
+	s.changeOrderStatus(c, storage.OrderChange{
+		OrderNumber: c.Param("number"),
+		Operator:    c.GetString("Login"),
+		NewStatus:   "NEW",
+		NewAccrual:  0,
+		Reason:      repoll.Reason,
+	}, storage.MovementReaccrual)
+}
+
+// AdminOverrideOrderStatus godoc
+//
+//	@Summary	Override order's status
+//	@Schemes
+//	@Tags		Admin
+//	@Accept		json
+//	@Produce	json
+//	@Param		number			path	string				true	"Order number"
+//	@Param		request			body	OverrideOrderStatus	true	"Body"
+//	@Param		Authorization	header	string				true	"Bearer"
+//	@Security	ApiKeyAuth
+//	@Success	200	{object}	OrderReponse	"Response"
+//	@Failure	401	{object}	utils.Problem	"Unauthorized"
+//	@Failure	403	{object}	utils.Problem	"Forbidden"
+//	@Failure	404	{object}	utils.Problem	"Order is not found"
+//	@Failure	422	{object}	utils.Problem	"Unprocessable Entity"
+//	@Failure	500	{object}	utils.Problem	"Error"
+//	@Router		/api/admin/orders/{number}/status [put]
+func (s *Service) AdminOverrideOrderStatus(c *gin.Context) {
+	var override OverrideOrderStatus
+	if err := c.ShouldBindJSON(&override); err != nil {
+		utils.Abort(c, utils.NewProblem(http.StatusUnprocessableEntity, err.Error()))
+		return
+	}
+	if !slices.Contains(orderStatuses, override.Status) {
+		utils.Abort(c, utils.NewProblem(http.StatusUnprocessableEntity, "unknown order status"))
+		return
+	}
+	if override.Accrual < 0 {
+		utils.Abort(c, utils.NewProblem(http.StatusUnprocessableEntity, "accrual can't be negative"))
+		return
+	}
+	s.changeOrderStatus(c, storage.OrderChange{
+		OrderNumber: c.Param("number"),
+		Operator:    c.GetString("Login"),
+		NewStatus:   override.Status,
+		NewAccrual:  override.Accrual,
+		Reason:      override.Reason,
+	}, storage.MovementStatusOverride)
+}
+
+func (s *Service) changeOrderStatus(c *gin.Context, change storage.OrderChange, kind string) {
+	order, err := s.Repo.GetOrder(c, change.OrderNumber)
+	if err != nil {
+		utils.Abort(c, utils.NewProblem(http.StatusNotFound, "order is not found"))
+		return
+	}
+
+	tx, err := s.Repo.BeginTransaction(c)
+	if err != nil {
+		utils.Abort(c, err)
+		return
+	}
+	defer tx.Rollback(c) //nolint:errcheck // ignore check
+
+	event := newAuditEvent(c, change.Operator, storage.AuditOrderStatusChanged, change.OrderNumber)
+	order, err = applyOrderChange(c, tx, order.Login, change, kind, event)
+	if errors.Is(err, errNegativeBalance) {
+		utils.Abort(c, utils.NewProblem(http.StatusUnprocessableEntity, err.Error()))
+		return
+	}
+	if err != nil {
+		utils.Abort(c, err)
+		return
+	}
+
+	err = tx.Commit(c)
+	if err != nil {
+		utils.Abort(c, err)
+		return
+	}
+
+	s.Broker.Publish(order.Login, events.OrderStatusChanged, events.OrderEvent{
+		Number:  order.Number,
+		Status:  order.Status,
+		Accrual: order.Accrual,
+	})
+
+	s.Logger.Info(
+		"order ", order.Number, " status is changed to ", change.NewStatus,
+		" operator ", change.Operator, " reason ", change.Reason,
+	)
+
+	c.JSON(http.StatusOK, newOrdersResponse([]storage.Order{order})[0])
+}
+
+// applyOrderChange меняет статус заказа и корректирует баланс пользователя на разницу
+// между новым и ранее зачисленным начислением. Заказ блокируется раньше пользователя,
+// в том же порядке, что и при загрузке заказов и обновлении их статусов.
+func applyOrderChange(
+	ctx context.Context,
+	tx storage.Transaction,
+	login string,
+	change storage.OrderChange,
+	kind string,
+	event storage.AuditEvent,
+) (storage.Order, error) {
+	order, err := tx.GetOrderForUpdate(ctx, change.OrderNumber)
+	if err != nil {
+		return order, err
+	}
+	user, err := tx.GetUserWithLock(ctx, login)
+	if err != nil {
+		return order, err
+	}
+	change.OldStatus = order.Status
+	change.OldAccrual = order.Accrual
+
+	// Бонусы уровня лояльности и промо-акций по заказу списываются: оператор задает итоговое начисление явно
+	delta := creditedAccrual(change.NewStatus, change.NewAccrual) - creditedAccrual(order.Status, order.Accrual) -
+		order.TierBonus - order.CampaignBonus
+	if user.Balance+delta < 0 {
+		return order, errNegativeBalance
+	}
+
+	err = tx.UpdateOrderStatus(ctx, storage.OrderStatus{
+		Number:  order.Number,
+		Status:  change.NewStatus,
+		Accrual: change.NewAccrual,
+	})
+	if err != nil {
+		return order, err
+	}
+	if delta != 0 {
+		err = tx.AccrualUserBalance(ctx, delta, login)
+		if err != nil {
+			return order, err
+		}
+		if delta < 0 {
+			_, err = tx.ConsumePointLots(ctx, login, -delta)
+			if err != nil {
+				return order, err
+			}
+		}
+		err = tx.StoreBalanceMovement(ctx, storage.BalanceMovement{
+			Login:       login,
+			Kind:        kind,
+			Amount:      delta,
+			OrderNumber: order.Number,
+			Reason:      change.Reason,
+			Operator:    change.Operator,
+		})
+		if err != nil {
+			return order, err
+		}
+	}
+	err = tx.StoreOrderChange(ctx, change)
+	if err != nil {
+		return order, err
+	}
+	err = tx.StoreAuditEvent(ctx, withAmounts(event, user.Balance, user.Balance+delta))
+	if err != nil {
+		return order, err
+	}
+
+	order.Status = change.NewStatus
+	order.Accrual = change.NewAccrual
+	return order, nil
+}
+
+// creditedAccrual возвращает сумму, зачисленную на баланс по заказу в данном статусе.
+func creditedAccrual(status string, accrual float32) float32 {
+	if status == "PROCESSED" {
+		return accrual
+	}
+	return 0
+}
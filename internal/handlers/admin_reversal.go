@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/pisarevaa/gophermart/internal/storage"
+	"github.com/pisarevaa/gophermart/internal/tasks"
+	"github.com/pisarevaa/gophermart/internal/utils"
+)
+
+type ReverseOrder struct {
+	Status  string  `json:"status"  binding:"required"`
+	Accrual float32 `json:"accrual"`
+	Reason  string  `json:"reason"  binding:"required"`
+}
+
+// AdminReverseOrder godoc
+//
+//	@Summary		Reverse order's accrual
+//	@Description	Deducts points for a processed order (e.g. returned goods). Status INVALID reverses the whole accrual,
+//	@Description	PROCESSED with a lower accrual reverses the difference. User's balance may become negative,
+//	@Description	in this case user is flagged for review.
+//	@Schemes
+//	@Tags		Admin
+//	@Accept		json
+//	@Produce	json
+//	@Param		number			path	string			true	"Order number"
+//	@Param		request			body	ReverseOrder	true	"Body"
+//	@Param		Authorization	header	string			true	"Bearer"
+//	@Security	ApiKeyAuth
+//	@Success	200	{object}	storage.Success	"Accrual is reversed"
+//	@Failure	401	{object}	utils.Problem	"Unauthorized"
+//	@Failure	403	{object}	utils.Problem	"Forbidden"
+//	@Failure	404	{object}	utils.Problem	"Order is not found"
+//	@Failure	409	{object}	utils.Problem	"Order's accrual can't be reversed"
+//	@Failure	422	{object}	utils.Problem	"Unprocessable Entity"
+//	@Failure	500	{object}	utils.Problem	"Error"
+//	@Router		/api/admin/orders/{number}/reversal [post]
+func (s *Service) AdminReverseOrder(c *gin.Context) {
+	var reverse ReverseOrder
+	if err := c.ShouldBindJSON(&reverse); err != nil {
+		utils.Abort(c, utils.NewProblem(http.StatusUnprocessableEntity, err.Error()))
+		return
+	}
+	status := storage.OrderStatus{
+		Number:  c.Param("number"),
+		Status:  reverse.Status,
+		Accrual: reverse.Accrual,
+	}
+	if !tasks.IsFinalStatus(status.Status) {
+		utils.Abort(c, utils.NewProblem(http.StatusUnprocessableEntity, "status must be PROCESSED or INVALID"))
+		return
+	}
+	if err := tasks.ValidateOrderStatus(status); err != nil {
+		utils.Abort(c, utils.NewProblem(http.StatusUnprocessableEntity, err.Error()))
+		return
+	}
+
+	tx, err := s.Repo.BeginTransaction(c)
+	if err != nil {
+		utils.Abort(c, err)
+		return
+	}
+	defer tx.Rollback(c) //nolint:errcheck // ignore check
+
+	order, err := tx.GetOrderForUpdate(c, status.Number)
+	if err != nil {
+		utils.Abort(c, utils.NewProblem(http.StatusNotFound, "order is not found"))
+		return
+	}
+	s.reverseOrderAccrual(c, tx, order, status, c.GetString("Login"), reverse.Reason)
+}
+
+// reverseOrderAccrual списывает баллы по заблокированному в tx заказу, фиксирует транзакцию
+// и отправляет ответ.
+func (s *Service) reverseOrderAccrual(
+	c *gin.Context,
+	tx storage.Transaction,
+	order storage.Order,
+	status storage.OrderStatus,
+	operator string,
+	reason string,
+) {
+	balanceAfter, err := tasks.ReverseOrderAccrual(c, tx, order, status, operator, reason, c.GetString("RequestID"))
+	if errors.Is(err, tasks.ErrNotReversible) {
+		utils.Abort(c, utils.NewProblem(http.StatusConflict, err.Error()))
+		return
+	}
+	if err != nil {
+		utils.Abort(c, err)
+		return
+	}
+	err = tx.Commit(c)
+	if err != nil {
+		utils.Abort(c, err)
+		return
+	}
+	tasks.PublishOrderStatus(s.Broker, order.Login, status, balanceAfter)
+
+	s.Logger.Info(
+		"accrual of order ", order.Number, " is reversed to ", status.Status, " ", status.Accrual,
+		" operator ", operator, " reason ", reason,
+	)
+	if balanceAfter < 0 {
+		s.Logger.Warn("user ", order.Login, " is flagged for review, balance ", balanceAfter)
+	}
+
+	c.JSON(http.StatusOK, storage.Success{Success: true})
+}
+
+// AdminGetFlaggedUsers godoc
+//
+//	@Summary	Get users flagged for review
+//	@Schemes
+//	@Tags		Admin
+//	@Produce	json
+//	@Param		Authorization	header	string	true	"Bearer"
+//	@Security	ApiKeyAuth
+//	@Success	200	{object}	[]AdminUserInfo	"Response"
+//	@Success	204	{object}	storage.Success	"No flagged users"
+//	@Failure	401	{object}	utils.Problem	"Unauthorized"
+//	@Failure	403	{object}	utils.Problem	"Forbidden"
+//	@Failure	500	{object}	utils.Problem	"Error"
+//	@Router		/api/admin/flagged-users [get]
+func (s *Service) AdminGetFlaggedUsers(c *gin.Context) {
+	users, err := s.Repo.GetFlaggedUsers(c)
+	if err != nil {
+		utils.Abort(c, err)
+		return
+	}
+	if len(users) == 0 {
+		c.Status(http.StatusNoContent)
+		return
+	}
+	usersResponse := make([]AdminUserInfo, 0, len(users))
+	for _, user := range users {
+		usersResponse = append(usersResponse, newAdminUserInfo(user))
+	}
+	c.JSON(http.StatusOK, usersResponse)
+}
+
+// AdminClearUserFlag godoc
+//
+//	@Summary	Mark flagged user as reviewed
+//	@Schemes
+//	@Tags		Admin
+//	@Produce	json
+//	@Param		login			path	string	true	"User login"
+//	@Param		Authorization	header	string	true	"Bearer"
+//	@Security	ApiKeyAuth
+//	@Success	200	{object}	storage.Success	"Flag is cleared"
+//	@Failure	401	{object}	utils.Problem	"Unauthorized"
+//	@Failure	403	{object}	utils.Problem	"Forbidden"
+//	@Failure	404	{object}	utils.Problem	"Flagged user is not found"
+//	@Router		/api/admin/users/{login}/flag [delete]
+func (s *Service) AdminClearUserFlag(c *gin.Context) {
+	login := c.Param("login")
+	err := s.Repo.ClearUserFlag(c, login)
+	if err != nil {
+		utils.Abort(c, utils.NewProblem(http.StatusNotFound, "flagged user is not found"))
+		return
+	}
+	s.storeAuditEvent(c, newAuditEvent(c, c.GetString("Login"), storage.AuditUserReviewed, login))
+	s.Logger.Info("user ", login, " is reviewed by ", c.GetString("Login"))
+	c.JSON(http.StatusOK, storage.Success{Success: true})
+}
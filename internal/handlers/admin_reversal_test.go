@@ -0,0 +1,93 @@
+package handlers_test
+
+import (
+	"net/http/httptest"
+	"time"
+
+	server "github.com/pisarevaa/gophermart/internal"
+	"github.com/pisarevaa/gophermart/internal/events"
+	"github.com/pisarevaa/gophermart/internal/handlers"
+	"github.com/pisarevaa/gophermart/internal/storage"
+	"github.com/pisarevaa/gophermart/internal/utils"
+)
+
+func (suite *ServerTestSuite) TestAdminReverseOrderInMemory() {
+	m := storage.NewMemory()
+
+	m.Users[login] = storage.User{
+		Login:   login,
+		Balance: float32(30),
+		Role:    storage.RoleUser,
+	}
+	now := time.Now()
+	m.Orders["123"] = storage.Order{
+		Number:      "123",
+		Status:      "PROCESSED",
+		Accrual:     float32(100),
+		Login:       login,
+		UploadedAt:  now,
+		ProcessedAt: &now,
+	}
+	m.Orders["456"] = storage.Order{
+		Number:     "456",
+		Status:     "NEW",
+		Login:      login,
+		UploadedAt: now,
+	}
+
+	m.Users["support"] = storage.User{Login: "support", Role: storage.RoleSupport}
+	supportToken, err := utils.GenerateJWTString(suite.cfg.TokenExpSec, suite.cfg.SecretKey, "support", storage.RoleSupport)
+	suite.Require().NoError(err)
+
+	ts := httptest.NewServer(server.NewRouter(suite.cfg, suite.logger, m, events.NewBroker(), nil))
+	defer ts.Close()
+
+	reverse := func(number string, request handlers.ReverseOrder) int {
+		resp, err := suite.client.R().
+			SetBody(request).
+			SetHeader("Content-Type", "application/json").
+			SetHeader("Authorization", "Bearer "+supportToken).
+			Post(ts.URL + "/api/admin/orders/" + number + "/reversal")
+		suite.Require().NoError(err)
+		return resp.StatusCode()
+	}
+
+	suite.Require().Equal(422, reverse("123", handlers.ReverseOrder{Status: "NEW", Reason: "returned goods"}))
+	suite.Require().Equal(404, reverse("789", handlers.ReverseOrder{Status: "INVALID", Reason: "returned goods"}))
+	suite.Require().Equal(409, reverse("456", handlers.ReverseOrder{Status: "INVALID", Reason: "returned goods"}))
+	suite.Require().Equal(409, reverse("123", handlers.ReverseOrder{Status: "PROCESSED", Accrual: 120, Reason: "returned goods"}))
+
+	suite.Require().Equal(200, reverse("123", handlers.ReverseOrder{Status: "INVALID", Reason: "returned goods"}))
+	suite.Require().Equal("INVALID", m.Orders["123"].Status)
+	suite.Require().Equal(float32(-70), m.Users[login].Balance)
+	suite.Require().Len(m.Movements, 1)
+	suite.Require().Equal(storage.MovementReversal, m.Movements[0].Kind)
+	suite.Require().Equal(float32(-100), m.Movements[0].Amount)
+	suite.Require().Equal("support", m.Movements[0].Operator)
+	suite.Require().Len(m.OrderChanges, 1)
+	suite.Require().NotNil(m.Users[login].FlaggedAt)
+
+	var flagged []handlers.AdminUserInfo
+	resp, err := suite.client.R().
+		SetResult(&flagged).
+		SetHeader("Authorization", "Bearer "+supportToken).
+		Get(ts.URL + "/api/admin/flagged-users")
+	suite.Require().NoError(err)
+	suite.Require().Equal(200, resp.StatusCode())
+	suite.Require().Len(flagged, 1)
+	suite.Require().Equal(login, flagged[0].Login)
+	suite.Require().NotEmpty(flagged[0].FlagReason)
+
+	resp, err = suite.client.R().
+		SetHeader("Authorization", "Bearer "+supportToken).
+		Delete(ts.URL + "/api/admin/users/" + login + "/flag")
+	suite.Require().NoError(err)
+	suite.Require().Equal(200, resp.StatusCode())
+	suite.Require().Nil(m.Users[login].FlaggedAt)
+
+	resp, err = suite.client.R().
+		SetHeader("Authorization", "Bearer "+supportToken).
+		Get(ts.URL + "/api/admin/flagged-users")
+	suite.Require().NoError(err)
+	suite.Require().Equal(204, resp.StatusCode())
+}
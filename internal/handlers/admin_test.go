@@ -0,0 +1,180 @@
+package handlers_test
+
+import (
+	"net/http/httptest"
+	"time"
+
+	server "github.com/pisarevaa/gophermart/internal"
+	"github.com/pisarevaa/gophermart/internal/events"
+	"github.com/pisarevaa/gophermart/internal/handlers"
+	"github.com/pisarevaa/gophermart/internal/storage"
+	"github.com/pisarevaa/gophermart/internal/utils"
+)
+
+func (suite *ServerTestSuite) TestAdminForbiddenForUserInMemory() {
+	m := storage.NewMemory()
+	m.Users[login] = storage.User{Login: login, Role: storage.RoleUser}
+
+	ts := httptest.NewServer(server.NewRouter(suite.cfg, suite.logger, m, events.NewBroker(), nil))
+	defer ts.Close()
+
+	resp, err := suite.client.R().
+		SetHeader("Authorization", "Bearer "+suite.token).
+		Get(ts.URL + "/api/admin/users/" + login)
+	suite.Require().NoError(err)
+	suite.Require().Equal(403, resp.StatusCode())
+
+	// Роль читается из БД, токен, выданный до понижения роли, больше не дает доступа
+	adminToken, err := utils.GenerateJWTString(suite.cfg.TokenExpSec, suite.cfg.SecretKey, login, storage.RoleAdmin)
+	suite.Require().NoError(err)
+	resp, err = suite.client.R().
+		SetHeader("Authorization", "Bearer "+adminToken).
+		Get(ts.URL + "/api/admin/users/" + login)
+	suite.Require().NoError(err)
+	suite.Require().Equal(403, resp.StatusCode())
+}
+
+func (suite *ServerTestSuite) TestAdminAdjustBalanceInMemory() {
+	m := storage.NewMemory()
+
+	m.Users[login] = storage.User{
+		Login:   login,
+		Balance: float32(500),
+		Role:    storage.RoleUser,
+	}
+
+	m.Users["support"] = storage.User{Login: "support", Role: storage.RoleSupport}
+	supportToken, err := utils.GenerateJWTString(suite.cfg.TokenExpSec, suite.cfg.SecretKey, "support", storage.RoleSupport)
+	suite.Require().NoError(err)
+
+	ts := httptest.NewServer(server.NewRouter(suite.cfg, suite.logger, m, events.NewBroker(), nil))
+	defer ts.Close()
+
+	var userInfo handlers.AdminUserInfo
+	resp, err := suite.client.R().
+		SetResult(&userInfo).
+		SetBody(handlers.AdjustBalance{Amount: -200, Reason: "duplicate accrual"}).
+		SetHeader("Content-Type", "application/json").
+		SetHeader("Authorization", "Bearer "+supportToken).
+		Post(ts.URL + "/api/admin/users/" + login + "/balance")
+	suite.Require().NoError(err)
+	suite.Require().Equal(200, resp.StatusCode())
+	suite.Require().Equal(float32(300), userInfo.Balance)
+	suite.Require().Equal(float32(300), m.Users[login].Balance)
+	suite.Require().Len(m.Movements, 1)
+	suite.Require().Equal("support", m.Movements[0].Operator)
+
+	resp, err = suite.client.R().
+		SetBody(handlers.SetRole{Role: storage.RoleAdmin}).
+		SetHeader("Content-Type", "application/json").
+		SetHeader("Authorization", "Bearer "+supportToken).
+		Put(ts.URL + "/api/admin/users/" + login + "/role")
+	suite.Require().NoError(err)
+	suite.Require().Equal(403, resp.StatusCode())
+
+	suite.Require().Len(m.AdminActions, 2)
+	suite.Require().Equal("support", m.AdminActions[0].Operator)
+	suite.Require().Equal(login, m.AdminActions[0].Target)
+	suite.Require().Equal(403, m.AdminActions[1].StatusCode)
+}
+
+func (suite *ServerTestSuite) TestAdminRepollOrderInMemory() {
+	m := storage.NewMemory()
+
+	m.Users[login] = storage.User{
+		Login:   login,
+		Balance: float32(500),
+		Role:    storage.RoleUser,
+	}
+	now := time.Now()
+	m.Orders["123"] = storage.Order{
+		Number:      "123",
+		Status:      "PROCESSED",
+		Accrual:     float32(100),
+		Login:       login,
+		UploadedAt:  now,
+		ProcessedAt: &now,
+	}
+
+	m.Users["support"] = storage.User{Login: "support", Role: storage.RoleSupport}
+	supportToken, err := utils.GenerateJWTString(suite.cfg.TokenExpSec, suite.cfg.SecretKey, "support", storage.RoleSupport)
+	suite.Require().NoError(err)
+
+	ts := httptest.NewServer(server.NewRouter(suite.cfg, suite.logger, m, events.NewBroker(), nil))
+	defer ts.Close()
+
+	resp, err := suite.client.R().
+		SetBody(handlers.RepollOrder{Reason: "customer complaint"}).
+		SetHeader("Content-Type", "application/json").
+		SetHeader("Authorization", "Bearer "+supportToken).
+		Post(ts.URL + "/api/admin/orders/123/repoll")
+	suite.Require().NoError(err)
+	suite.Require().Equal(200, resp.StatusCode())
+	suite.Require().Equal("NEW", m.Orders["123"].Status)
+	suite.Require().Equal(float32(400), m.Users[login].Balance)
+	suite.Require().Len(m.OrderChanges, 1)
+	suite.Require().Equal("PROCESSED", m.OrderChanges[0].OldStatus)
+
+	resp, err = suite.client.R().
+		SetBody(handlers.OverrideOrderStatus{Status: "PROCESSED", Accrual: 50, Reason: "manual check"}).
+		SetHeader("Content-Type", "application/json").
+		SetHeader("Authorization", "Bearer "+supportToken).
+		Put(ts.URL + "/api/admin/orders/123/status")
+	suite.Require().NoError(err)
+	suite.Require().Equal(200, resp.StatusCode())
+	suite.Require().Equal(float32(450), m.Users[login].Balance)
+	suite.Require().Len(m.Movements, 2)
+}
+
+func (suite *ServerTestSuite) TestAuditEventsInMemory() {
+	m := storage.NewMemory()
+
+	ts := httptest.NewServer(server.NewRouter(suite.cfg, suite.logger, m, events.NewBroker(), nil))
+	defer ts.Close()
+
+	user := storage.RegisterUser{
+		Login:    login,
+		Password: "123",
+	}
+	resp, err := suite.client.R().
+		SetBody(user).
+		SetHeader("Content-Type", "application/json").
+		SetHeader("X-Request-ID", "register-request").
+		Post(ts.URL + "/api/user/register")
+	suite.Require().NoError(err)
+	suite.Require().Equal(200, resp.StatusCode())
+
+	user.Password = "wrong"
+	resp, err = suite.client.R().
+		SetBody(user).
+		SetHeader("Content-Type", "application/json").
+		Post(ts.URL + "/api/user/login")
+	suite.Require().NoError(err)
+	suite.Require().Equal(401, resp.StatusCode())
+
+	m.Users["admin"] = storage.User{Login: "admin", Role: storage.RoleAdmin}
+	adminToken, err := utils.GenerateJWTString(suite.cfg.TokenExpSec, suite.cfg.SecretKey, "admin", storage.RoleAdmin)
+	suite.Require().NoError(err)
+
+	var events []handlers.AuditEventResponse
+	resp, err = suite.client.R().
+		SetResult(&events).
+		SetQueryParam("actor", login).
+		SetQueryParam("action", storage.AuditUserRegistered).
+		SetHeader("Authorization", "Bearer "+adminToken).
+		Get(ts.URL + "/api/admin/audit")
+	suite.Require().NoError(err)
+	suite.Require().Equal(200, resp.StatusCode())
+	suite.Require().Len(events, 1)
+	suite.Require().Equal("register-request", events[0].RequestID)
+
+	resp, err = suite.client.R().
+		SetResult(&events).
+		SetQueryParam("actor", login).
+		SetHeader("Authorization", "Bearer "+adminToken).
+		Get(ts.URL + "/api/admin/audit")
+	suite.Require().NoError(err)
+	suite.Require().Equal(200, resp.StatusCode())
+	suite.Require().Len(events, 2)
+	suite.Require().Equal(storage.AuditUserLoginFailed, events[0].Action)
+}
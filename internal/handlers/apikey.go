@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/pisarevaa/gophermart/internal/storage"
+	"github.com/pisarevaa/gophermart/internal/utils"
+)
+
+type CreateAPIKey struct {
+	Name      string   `json:"name"      binding:"required"`
+	Scopes    []string `json:"scopes"    binding:"required"`
+	RateLimit int      `json:"rateLimit"`
+}
+
+type APIKeyResponse struct {
+	ID         int64      `json:"id"         binding:"required"`
+	Name       string     `json:"name"       binding:"required"`
+	Prefix     string     `json:"prefix"     binding:"required"`
+	Scopes     []string   `json:"scopes"     binding:"required"`
+	RateLimit  int        `json:"rateLimit"  binding:"required"`
+	CreatedAt  time.Time  `json:"createdAt"  binding:"required"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+	RevokedAt  *time.Time `json:"revokedAt"`
+}
+
+type CreatedAPIKey struct {
+	APIKeyResponse
+	Key string `json:"key" binding:"required"`
+}
+
+func newAPIKeyResponse(key storage.APIKey) APIKeyResponse {
+	return APIKeyResponse{
+		ID:         key.ID,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     key.Scopes,
+		RateLimit:  key.RateLimit,
+		CreatedAt:  key.CreatedAt,
+		LastUsedAt: key.LastUsedAt,
+		RevokedAt:  key.RevokedAt,
+	}
+}
+
+// CreateAPIKey godoc
+//
+//	@Summary	Create API key
+//	@Schemes
+//	@Tags		API keys
+//	@Accept		json
+//	@Produce	json
+//	@Param		request			body	CreateAPIKey	true	"Body"
+//	@Param		Authorization	header	string			true	"Bearer"
+//	@Security	ApiKeyAuth
+//	@Success	201	{object}	CreatedAPIKey	"Response, key is shown only once"
+//	@Failure	401	{object}	utils.Problem	"Unauthorized"
+//	@Failure	422	{object}	utils.Problem	"Unknown scope or rate limit above default"
+//	@Failure	500	{object}	utils.Problem	"Error"
+//	@Router		/api/user/api-keys [post]
+func (s *Service) CreateAPIKey(c *gin.Context) {
+	login := c.GetString("Login")
+	var request CreateAPIKey
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.Abort(c, utils.NewProblem(http.StatusUnprocessableEntity, err.Error()))
+		return
+	}
+	if len(request.Scopes) == 0 {
+		utils.Abort(c, utils.NewProblem(http.StatusUnprocessableEntity, "scopes are empty"))
+		return
+	}
+	for _, scope := range request.Scopes {
+		if !slices.Contains(utils.APIKeyScopes(), scope) {
+			utils.Abort(c, utils.NewProblem(http.StatusUnprocessableEntity, "unknown scope "+scope))
+			return
+		}
+	}
+	if request.RateLimit < 0 {
+		utils.Abort(c, utils.NewProblem(http.StatusUnprocessableEntity, "rate limit can't be negative"))
+		return
+	}
+	// Пользователь может только ужесточить лимит ключа относительно лимита по умолчанию
+	if s.Config.APIKeyRateLimit > 0 && int64(request.RateLimit) > s.Config.APIKeyRateLimit {
+		utils.Abort(c, utils.NewProblem(
+			http.StatusUnprocessableEntity,
+			fmt.Sprintf("rate limit can't exceed %d requests per minute", s.Config.APIKeyRateLimit),
+		))
+		return
+	}
+
+	apiKey, prefix, err := utils.GenerateAPIKey()
+	if err != nil {
+		utils.Abort(c, err)
+		return
+	}
+	keyHash, err := utils.GetPasswordHash(apiKey, s.Config.SecretKey)
+	if err != nil {
+		utils.Abort(c, err)
+		return
+	}
+
+	key := storage.APIKey{
+		Login:     login,
+		Name:      request.Name,
+		Prefix:    prefix,
+		Hash:      keyHash,
+		Scopes:    request.Scopes,
+		RateLimit: request.RateLimit,
+		CreatedAt: time.Now(),
+	}
+	tx, err := s.Repo.BeginTransaction(c)
+	if err != nil {
+		utils.Abort(c, err)
+		return
+	}
+	defer tx.Rollback(c) //nolint:errcheck // ignore check
+
+	key.ID, err = tx.StoreAPIKey(c, key)
+	if err != nil {
+		utils.Abort(c, err)
+		return
+	}
+	err = tx.StoreAuditEvent(c, newAuditEvent(c, login, storage.AuditAPIKeyCreated, strconv.FormatInt(key.ID, 10)))
+	if err != nil {
+		utils.Abort(c, err)
+		return
+	}
+	err = tx.Commit(c)
+	if err != nil {
+		utils.Abort(c, err)
+		return
+	}
+
+	s.Logger.Info("api key ", prefix, " is created for login ", login)
+
+	c.JSON(http.StatusCreated, CreatedAPIKey{
+		APIKeyResponse: newAPIKeyResponse(key),
+		Key:            apiKey,
+	})
+}
+
+// GetAPIKeys godoc
+//
+//	@Summary	Get user's API keys
+//	@Schemes
+//	@Tags		API keys
+//	@Produce	json
+//	@Param		Authorization	header	string	true	"Bearer"
+//	@Security	ApiKeyAuth
+//	@Success	200	{object}	[]APIKeyResponse	"Response"
+//	@Failure	401	{object}	utils.Problem		"Unauthorized"
+//	@Failure	500	{object}	utils.Problem		"Error"
+//	@Router		/api/user/api-keys [get]
+func (s *Service) GetAPIKeys(c *gin.Context) {
+	keys, err := s.Repo.GetAPIKeys(c, c.GetString("Login"))
+	if err != nil {
+		utils.Abort(c, err)
+		return
+	}
+	keysResponse := []APIKeyResponse{}
+	for _, key := range keys {
+		keysResponse = append(keysResponse, newAPIKeyResponse(key))
+	}
+	c.JSON(http.StatusOK, keysResponse)
+}
+
+// RevokeAPIKey godoc
+//
+//	@Summary	Revoke API key
+//	@Schemes
+//	@Tags		API keys
+//	@Produce	json
+//	@Param		id				path	int		true	"API key ID"
+//	@Param		Authorization	header	string	true	"Bearer"
+//	@Security	ApiKeyAuth
+//	@Success	200	{object}	storage.Success	"Response"
+//	@Failure	401	{object}	utils.Problem	"Unauthorized"
+//	@Failure	404	{object}	utils.Problem	"API key is not found"
+//	@Failure	500	{object}	utils.Problem	"Error"
+//	@Router		/api/user/api-keys/{id} [delete]
+func (s *Service) RevokeAPIKey(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.Abort(c, utils.NewProblem(http.StatusNotFound, "API key is not found"))
+		return
+	}
+	login := c.GetString("Login")
+	tx, err := s.Repo.BeginTransaction(c)
+	if err != nil {
+		utils.Abort(c, err)
+		return
+	}
+	defer tx.Rollback(c) //nolint:errcheck // ignore check
+
+	err = tx.RevokeAPIKey(c, id, login)
+	if err != nil {
+		utils.Abort(c, utils.NewProblem(http.StatusNotFound, "API key is not found"))
+		return
+	}
+	err = tx.StoreAuditEvent(c, newAuditEvent(c, login, storage.AuditAPIKeyRevoked, c.Param("id")))
+	if err != nil {
+		utils.Abort(c, err)
+		return
+	}
+	err = tx.Commit(c)
+	if err != nil {
+		utils.Abort(c, err)
+		return
+	}
+
+	s.Logger.Info("api key ", id, " is revoked by login ", login)
+	c.JSON(http.StatusOK, storage.Success{
+		Success: true,
+	})
+}
@@ -0,0 +1,123 @@
+package handlers_test
+
+import (
+	"net/http/httptest"
+	"strconv"
+
+	"github.com/ShiraazMoollatjie/goluhn"
+
+	server "github.com/pisarevaa/gophermart/internal"
+	"github.com/pisarevaa/gophermart/internal/events"
+	"github.com/pisarevaa/gophermart/internal/handlers"
+	"github.com/pisarevaa/gophermart/internal/storage"
+	"github.com/pisarevaa/gophermart/internal/utils"
+)
+
+func (suite *ServerTestSuite) TestAPIKeyInMemory() {
+	m := storage.NewMemory()
+
+	m.Users[login] = storage.User{
+		Login: login,
+		Role:  storage.RoleUser,
+	}
+
+	ts := httptest.NewServer(server.NewRouter(suite.cfg, suite.logger, m, events.NewBroker(), nil))
+	defer ts.Close()
+
+	var createdKey handlers.CreatedAPIKey
+	resp, err := suite.client.R().
+		SetResult(&createdKey).
+		SetBody(handlers.CreateAPIKey{Name: "pos", Scopes: []string{utils.ScopeOrdersWrite}}).
+		SetHeader("Content-Type", "application/json").
+		SetHeader("Authorization", "Bearer "+suite.token).
+		Post(ts.URL + "/api/user/api-keys")
+	suite.Require().NoError(err)
+	suite.Require().Equal(201, resp.StatusCode())
+	suite.Require().NotEmpty(createdKey.Key)
+
+	resp, err = suite.client.R().
+		SetBody(goluhn.Generate(9)).
+		SetHeader("Content-Type", "text/plain").
+		SetHeader(utils.APIKeyHeader, createdKey.Key).
+		Post(ts.URL + "/api/user/orders")
+	suite.Require().NoError(err)
+	suite.Require().Equal(202, resp.StatusCode())
+	suite.Require().Len(m.Orders, 1)
+	suite.Require().NotNil(m.APIKeys[0].LastUsedAt)
+
+	resp, err = suite.client.R().
+		SetHeader(utils.APIKeyHeader, createdKey.Key).
+		Get(ts.URL + "/api/user/balance")
+	suite.Require().NoError(err)
+	suite.Require().Equal(403, resp.StatusCode())
+
+	resp, err = suite.client.R().
+		SetHeader(utils.APIKeyHeader, createdKey.Key).
+		Get(ts.URL + "/api/user/api-keys")
+	suite.Require().NoError(err)
+	suite.Require().Equal(403, resp.StatusCode())
+
+	resp, err = suite.client.R().
+		SetHeader("Authorization", "Bearer "+suite.token).
+		Delete(ts.URL + "/api/user/api-keys/" + strconv.FormatInt(createdKey.ID, 10))
+	suite.Require().NoError(err)
+	suite.Require().Equal(200, resp.StatusCode())
+
+	resp, err = suite.client.R().
+		SetBody(goluhn.Generate(9)).
+		SetHeader("Content-Type", "text/plain").
+		SetHeader(utils.APIKeyHeader, createdKey.Key).
+		Post(ts.URL + "/api/user/orders")
+	suite.Require().NoError(err)
+	suite.Require().Equal(401, resp.StatusCode())
+
+	var actions []string
+	for _, event := range m.AuditEvents {
+		if event.Target == strconv.FormatInt(createdKey.ID, 10) {
+			actions = append(actions, event.Action)
+		}
+	}
+	suite.Require().Equal([]string{storage.AuditAPIKeyCreated, storage.AuditAPIKeyRevoked}, actions)
+
+	// Лимит ключа не может превышать лимит по умолчанию
+	resp, err = suite.client.R().
+		SetBody(handlers.CreateAPIKey{
+			Name:      "pos",
+			Scopes:    []string{utils.ScopeOrdersWrite},
+			RateLimit: int(suite.cfg.APIKeyRateLimit) + 1,
+		}).
+		SetHeader("Content-Type", "application/json").
+		SetHeader("Authorization", "Bearer "+suite.token).
+		Post(ts.URL + "/api/user/api-keys")
+	suite.Require().NoError(err)
+	suite.Require().Equal(422, resp.StatusCode())
+}
+
+func (suite *ServerTestSuite) TestAPIKeyRateLimitInMemory() {
+	m := storage.NewMemory()
+
+	ts := httptest.NewServer(server.NewRouter(suite.cfg, suite.logger, m, events.NewBroker(), nil))
+	defer ts.Close()
+
+	var createdKey handlers.CreatedAPIKey
+	resp, err := suite.client.R().
+		SetResult(&createdKey).
+		SetBody(handlers.CreateAPIKey{Name: "pos", Scopes: []string{utils.ScopeOrdersRead}, RateLimit: 1}).
+		SetHeader("Content-Type", "application/json").
+		SetHeader("Authorization", "Bearer "+suite.token).
+		Post(ts.URL + "/api/user/api-keys")
+	suite.Require().NoError(err)
+	suite.Require().Equal(201, resp.StatusCode())
+
+	resp, err = suite.client.R().
+		SetHeader(utils.APIKeyHeader, createdKey.Key).
+		Get(ts.URL + "/api/user/orders")
+	suite.Require().NoError(err)
+	suite.Require().Equal(204, resp.StatusCode())
+
+	resp, err = suite.client.R().
+		SetHeader(utils.APIKeyHeader, createdKey.Key).
+		Get(ts.URL + "/api/user/orders")
+	suite.Require().NoError(err)
+	suite.Require().Equal(429, resp.StatusCode())
+}
@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/pisarevaa/gophermart/internal/storage"
+	"github.com/pisarevaa/gophermart/internal/utils"
+)
+
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
+type AuditEventResponse struct {
+	ID           int64     `json:"id"           binding:"required"`
+	Actor        string    `json:"actor"        binding:"required"`
+	Action       string    `json:"action"       binding:"required"`
+	Target       string    `json:"target"       binding:"required"`
+	AmountBefore *float32  `json:"amountBefore"`
+	AmountAfter  *float32  `json:"amountAfter"`
+	RequestID    string    `json:"requestId"    binding:"required"`
+	CreatedAt    time.Time `json:"createdAt"    binding:"required"`
+}
+
+// newAuditEvent заполняет событие аудита данными текущего запроса.
+func newAuditEvent(c *gin.Context, actor string, action string, target string) storage.AuditEvent {
+	return storage.AuditEvent{
+		Actor:     actor,
+		Action:    action,
+		Target:    target,
+		RequestID: c.GetString("RequestID"),
+	}
+}
+
+// storeAuditEvent сохраняет событие, не связанное с изменением данных (например, вход в систему).
+// Ошибка записи не должна ломать сам запрос, поэтому она только логируется.
+func (s *Service) storeAuditEvent(c *gin.Context, event storage.AuditEvent) {
+	err := s.Repo.StoreAuditEvent(c, event)
+	if err != nil {
+		s.Logger.Error("error to store audit event: ", err)
+	}
+}
+
+func withAmounts(event storage.AuditEvent, before float32, after float32) storage.AuditEvent {
+	event.AmountBefore = &before
+	event.AmountAfter = &after
+	return event
+}
+
+// GetAuditEvents godoc
+//
+//	@Summary	Get audit events
+//	@Schemes
+//	@Tags		Admin
+//	@Produce	json
+//	@Param		actor			query	string	false	"Actor login"
+//	@Param		action			query	string	false	"Action"
+//	@Param		target			query	string	false	"Target"
+//	@Param		from			query	string	false	"From datetime (RFC3339)"
+//	@Param		to				query	string	false	"To datetime (RFC3339)"
+//	@Param		limit			query	int		false	"Limit"
+//	@Param		offset			query	int		false	"Offset"
+//	@Param		Authorization	header	string	true	"Bearer"
+//	@Security	ApiKeyAuth
+//	@Success	200	{object}	[]AuditEventResponse	"Response"
+//	@Failure	400	{object}	utils.Problem			"Incorrect filters"
+//	@Failure	401	{object}	utils.Problem			"Unauthorized"
+//	@Failure	403	{object}	utils.Problem			"Forbidden"
+//	@Failure	500	{object}	utils.Problem			"Error"
+//	@Router		/api/admin/audit [get]
+func (s *Service) GetAuditEvents(c *gin.Context) {
+	filter := storage.AuditFilter{
+		Actor:  c.Query("actor"),
+		Action: c.Query("action"),
+		Target: c.Query("target"),
+		Limit:  defaultAuditLimit,
+	}
+	var err error
+	if filter.From, err = parseTimeQuery(c, "from"); err != nil {
+		utils.Abort(c, utils.NewProblem(http.StatusBadRequest, err.Error()))
+		return
+	}
+	if filter.To, err = parseTimeQuery(c, "to"); err != nil {
+		utils.Abort(c, utils.NewProblem(http.StatusBadRequest, err.Error()))
+		return
+	}
+	if limit := c.Query("limit"); limit != "" {
+		filter.Limit, err = strconv.Atoi(limit)
+		if err != nil || filter.Limit <= 0 || filter.Limit > maxAuditLimit {
+			utils.Abort(c, utils.NewProblem(http.StatusBadRequest, "limit must be between 1 and 1000"))
+			return
+		}
+	}
+	if offset := c.Query("offset"); offset != "" {
+		filter.Offset, err = strconv.Atoi(offset)
+		if err != nil || filter.Offset < 0 {
+			utils.Abort(c, utils.NewProblem(http.StatusBadRequest, "offset must be positive"))
+			return
+		}
+	}
+
+	events, err := s.Repo.GetAuditEvents(c, filter)
+	if err != nil {
+		utils.Abort(c, err)
+		return
+	}
+
+	eventsResponse := []AuditEventResponse{}
+	for _, event := range events {
+		eventsResponse = append(eventsResponse, AuditEventResponse(event))
+	}
+	c.JSON(http.StatusOK, eventsResponse)
+}
+
+func parseTimeQuery(c *gin.Context, key string) (*time.Time, error) {
+	value := c.Query(key)
+	if value == "" {
+		return nil, nil //nolint:nilnil // no filter
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
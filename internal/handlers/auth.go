@@ -16,44 +16,93 @@ type SuccessLogin struct {
 
 // RegisterUser godoc
 //
-//	@Summary	Regiser user
+//	@Summary		Regiser user
+//	@Description	Optional referralCode links the user to the inviting user, both receive bonus points
+//	@Description	after the first processed order of the new user.
 //	@Schemes
 //	@Tags		Auth
 //	@Accept		json
 //	@Produce	json
 //	@Param		request	body		storage.RegisterUser	true	"Body"
 //	@Success	200		{object}	storage.Success			"Response"
-//	@Failure	409		{object}	storage.Error			"Login is already used"
-//	@Failure	500		{object}	storage.Error			"Error"
+//	@Failure	409		{object}	utils.Problem			"Login is already used"
+//	@Failure	422		{object}	utils.Problem			"Referral code is not found"
+//	@Failure	500		{object}	utils.Problem			"Error"
 //	@Router		/api/user/register [post]
 func (s *Service) RegisterUser(c *gin.Context) {
 	var user storage.RegisterUser
 	if err := c.ShouldBindJSON(&user); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		utils.Abort(c, utils.NewProblem(http.StatusBadRequest, err.Error()))
 		return
 	}
 
 	_, err := s.Repo.GetUser(c, user.Login)
 	if err == nil {
-		c.JSON(http.StatusConflict, gin.H{"error": "login is already used"})
+		utils.Abort(c, utils.NewProblem(http.StatusConflict, "login is already used"))
+		return
+	}
+
+	var referrer *storage.User
+	if user.ReferralCode != "" {
+		inviter, err := s.Repo.GetUserByReferralCode(c, utils.NormalizeReferralCode(user.ReferralCode))
+		if err != nil {
+			utils.Abort(c, utils.NewProblem(http.StatusUnprocessableEntity, "referral code is not found"))
+			return
+		}
+		referrer = &inviter
+	}
+	referralCode, err := utils.GenerateReferralCode()
+	if err != nil {
+		utils.Abort(c, err)
 		return
 	}
 
 	passwordHash, err := utils.GetPasswordHash(user.Password, s.Config.SecretKey)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		utils.Abort(c, err)
+		return
+	}
+
+	tx, err := s.Repo.BeginTransaction(c)
+	if err != nil {
+		utils.Abort(c, err)
+		return
+	}
+	defer tx.Rollback(c) //nolint:errcheck // ignore check
+
+	err = tx.StoreUser(c, user.Login, passwordHash)
+	if err != nil {
+		utils.Abort(c, err)
+		return
+	}
+	err = tx.SetUserReferral(c, user.Login, referralCode, c.ClientIP())
+	if err != nil {
+		utils.Abort(c, err)
 		return
 	}
+	if referrer != nil {
+		err = s.storeReferral(c, tx, *referrer, user.Login)
+		if err != nil {
+			utils.Abort(c, err)
+			return
+		}
+	}
 
-	err = s.Repo.StoreUser(c, user.Login, passwordHash)
+	err = tx.StoreAuditEvent(c, newAuditEvent(c, user.Login, storage.AuditUserRegistered, user.Login))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		utils.Abort(c, err)
 		return
 	}
 
-	token, err := utils.GenerateJWTString(s.Config.TokenExpSec, s.Config.SecretKey, user.Login)
+	err = tx.Commit(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		utils.Abort(c, err)
+		return
+	}
+
+	token, err := utils.GenerateJWTString(s.Config.TokenExpSec, s.Config.SecretKey, user.Login, storage.RoleUser)
+	if err != nil {
+		utils.Abort(c, err)
 		return
 	}
 
@@ -74,39 +123,44 @@ func (s *Service) RegisterUser(c *gin.Context) {
 //	@Produce	json
 //	@Param		request	body		storage.RegisterUser	true	"Body"
 //	@Success	200		{object}	SuccessLogin			"Response"
-//	@Failure	401		{object}	storage.Error			"Login is not found or password is wrong"
-//	@Failure	400		{object}	storage.Error			"Incorrect request data"
-//	@Failure	500		{object}	storage.Error			"Error"
+//	@Failure	401		{object}	utils.Problem			"Login is not found or password is wrong"
+//	@Failure	400		{object}	utils.Problem			"Incorrect request data"
+//	@Failure	500		{object}	utils.Problem			"Error"
 //	@Router		/api/user/login [post]
 func (s *Service) LoginUser(c *gin.Context) {
 	var user storage.RegisterUser
 	if err := c.ShouldBindJSON(&user); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		utils.Abort(c, utils.NewProblem(http.StatusBadRequest, err.Error()))
 		return
 	}
 
 	userInDB, err := s.Repo.GetUser(c, user.Login)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "login is not found"})
+		s.storeAuditEvent(c, newAuditEvent(c, user.Login, storage.AuditUserLoginFailed, user.Login))
+		utils.Abort(c, utils.NewProblem(http.StatusUnauthorized, "login is not found"))
 		return
 	}
 
 	isCorrect, err := utils.CheckPasswordHash(user.Password, userInDB.Password, s.Config.SecretKey)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		s.storeAuditEvent(c, newAuditEvent(c, user.Login, storage.AuditUserLoginFailed, user.Login))
+		utils.Abort(c, utils.NewProblem(http.StatusUnauthorized, "password is wrong"))
 		return
 	}
 	if !isCorrect {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "password is wrong"})
+		s.storeAuditEvent(c, newAuditEvent(c, user.Login, storage.AuditUserLoginFailed, user.Login))
+		utils.Abort(c, utils.NewProblem(http.StatusUnauthorized, "password is wrong"))
 		return
 	}
 
-	token, err := utils.GenerateJWTString(s.Config.TokenExpSec, s.Config.SecretKey, userInDB.Login)
+	token, err := utils.GenerateJWTString(s.Config.TokenExpSec, s.Config.SecretKey, userInDB.Login, userInDB.Role)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		utils.Abort(c, err)
 		return
 	}
 
+	s.storeAuditEvent(c, newAuditEvent(c, userInDB.Login, storage.AuditUserLogin, userInDB.Login))
+
 	c.Header("Authorization", token)
 	c.SetCookie("token", token, int(s.Config.TokenExpSec), "/", "localhost", false, true)
 
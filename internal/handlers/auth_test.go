@@ -12,6 +12,7 @@ import (
 
 	server "github.com/pisarevaa/gophermart/internal"
 	"github.com/pisarevaa/gophermart/internal/configs"
+	"github.com/pisarevaa/gophermart/internal/events"
 	mock "github.com/pisarevaa/gophermart/internal/mocks"
 	"github.com/pisarevaa/gophermart/internal/storage"
 	"github.com/pisarevaa/gophermart/internal/utils"
@@ -31,7 +32,7 @@ func (suite *ServerTestSuite) SetupSuite() {
 	suite.cfg = configs.NewConfig()
 	suite.logger = server.NewLogger()
 	suite.client = resty.New()
-	token, err := utils.GenerateJWTString(suite.cfg.TokenExpSec, suite.cfg.SecretKey, login)
+	token, err := utils.GenerateJWTString(suite.cfg.TokenExpSec, suite.cfg.SecretKey, login, storage.RoleUser)
 	suite.Require().NoError(err)
 	suite.token = token
 }
@@ -45,16 +46,37 @@ func (suite *ServerTestSuite) TestRegisterUserMockDB() {
 	defer ctrl.Finish()
 
 	m := mock.NewMockStorage(ctrl)
+	tx := mock.NewMockTransaction(ctrl)
 
 	m.EXPECT().
 		GetUser(gomock.Any(), gomock.Any()).
 		Return(storage.User{}, errors.New("user exists"))
 
 	m.EXPECT().
+		BeginTransaction(gomock.Any()).
+		Return(tx, nil)
+
+	tx.EXPECT().
 		StoreUser(gomock.Any(), gomock.Any(), gomock.Any()).
 		Return(nil)
 
-	ts := httptest.NewServer(server.NewRouter(suite.cfg, suite.logger, m))
+	tx.EXPECT().
+		SetUserReferral(gomock.Any(), "test", gomock.Any(), gomock.Any()).
+		Return(nil)
+
+	tx.EXPECT().
+		StoreAuditEvent(gomock.Any(), gomock.Any()).
+		Return(nil)
+
+	tx.EXPECT().
+		Commit(gomock.Any()).
+		Return(nil)
+
+	tx.EXPECT().
+		Rollback(gomock.Any()).
+		Return(nil)
+
+	ts := httptest.NewServer(server.NewRouter(suite.cfg, suite.logger, m, events.NewBroker(), nil))
 	defer ts.Close()
 
 	user := storage.RegisterUser{
@@ -87,7 +109,11 @@ func (suite *ServerTestSuite) TestLoginMockDB() {
 		GetUser(gomock.Any(), gomock.Any()).
 		Return(dbUser, nil)
 
-	ts := httptest.NewServer(server.NewRouter(suite.cfg, suite.logger, m))
+	m.EXPECT().
+		StoreAuditEvent(gomock.Any(), gomock.Any()).
+		Return(nil)
+
+	ts := httptest.NewServer(server.NewRouter(suite.cfg, suite.logger, m, events.NewBroker(), nil))
 	defer ts.Close()
 
 	user := storage.RegisterUser{
@@ -106,7 +132,7 @@ func (suite *ServerTestSuite) TestLoginMockDB() {
 func (suite *ServerTestSuite) TestRegisterUserAndLoginInMemory() {
 	m := storage.NewMemory()
 
-	ts := httptest.NewServer(server.NewRouter(suite.cfg, suite.logger, m))
+	ts := httptest.NewServer(server.NewRouter(suite.cfg, suite.logger, m, events.NewBroker(), nil))
 	defer ts.Close()
 
 	user := storage.RegisterUser{
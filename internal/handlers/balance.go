@@ -4,6 +4,9 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/pisarevaa/gophermart/internal/events"
+	"github.com/pisarevaa/gophermart/internal/fraud"
+	"github.com/pisarevaa/gophermart/internal/storage"
 	"github.com/pisarevaa/gophermart/internal/utils"
 )
 
@@ -19,38 +22,86 @@ type WithdrawalsReponse struct {
 }
 
 type UserBalanceInfo struct {
-	Current   float32 `json:"current"   binding:"required"`
-	Withdrawn float32 `json:"withdrawn" binding:"required"`
+	Current   float32          `json:"current"            binding:"required"`
+	Withdrawn float32          `json:"withdrawn"          binding:"required"`
+	Held      float32          `json:"held,omitempty"`
+	Expiring  []ExpiringPoints `json:"expiring,omitempty"`
+	// Pending - предварительные начисления по заказам, которые еще рассчитываются
+	Pending         *float32                 `json:"pending,omitempty"`
+	PendingByStatus []storage.PendingBalance `json:"pending_by_status,omitempty"`
 }
 
+type ExpiringPoints struct {
+	Amount    float32                 `json:"amount"     binding:"required"`
+	ExpiresAt utils.FormattedDatetime `json:"expires_at" binding:"required" swaggertype:"string" example:"2024-06-12T08:00:04+03:00"`
+}
+
+// expiringLotsLimit - количество ближайших сгораний, показываемых пользователю.
+const expiringLotsLimit = 5
+
 // GetBalance godoc
 //
-//	@Summary	Get user's balance
+//	@Summary		Get user's balance
+//	@Description	Held is the part of current balance reserved by active holds and not available for withdrawal.
+//	@Description	Expiring lists the nearest expirations of accrued points.
+//	@Description	With pending=true the response includes provisional accrual of orders in NEW, REGISTERED
+//	@Description	and PROCESSING statuses reported by the accrual system, in total and by status.
 //	@Schemes
 //	@Tags		Balance
 //	@Produce	json
+//	@Param		pending			query	bool	false	"Include pending balance"
 //	@Param		Authorization	header	string	true	"Bearer"
 //	@Security	ApiKeyAuth
 //	@Success	200	{object}	UserBalanceInfo	"Response"
-//	@Failure	401	{object}	storage.Error	"Unauthorized"
-//	@Failure	500	{object}	storage.Error	"Error"
+//	@Failure	401	{object}	utils.Problem	"Unauthorized"
+//	@Failure	500	{object}	utils.Problem	"Error"
 //	@Router		/api/user/balance [get]
 func (s *Service) GetBalance(c *gin.Context) {
 	login := c.GetString("Login")
 	user, err := s.Repo.GetUser(c, login)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "user is not found"})
+		utils.Abort(c, utils.NewProblem(http.StatusUnauthorized, "user is not found"))
+		return
+	}
+	lots, err := s.Repo.GetExpiringPointLots(c, login, expiringLotsLimit)
+	if err != nil {
+		utils.Abort(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, UserBalanceInfo{
+	balance := UserBalanceInfo{
 		Current:   user.Balance,
 		Withdrawn: user.Withdrawn,
-	})
+		Held:      user.Held,
+	}
+	for _, lot := range lots {
+		balance.Expiring = append(balance.Expiring, ExpiringPoints{
+			Amount:    lot.Remaining,
+			ExpiresAt: utils.FormattedDatetime(*lot.ExpiresAt),
+		})
+	}
+	if c.Query("pending") == "true" {
+		balance.PendingByStatus, err = s.Repo.GetPendingBalance(c, login)
+		if err != nil {
+			utils.Abort(c, err)
+			return
+		}
+		var pending float32
+		for _, p := range balance.PendingByStatus {
+			pending += p.Accrual
+		}
+		balance.Pending = &pending
+	}
+	c.JSON(http.StatusOK, balance)
 }
 
 // WithdrawUserBalance godoc
 //
-//	@Summary	Withdraw user's balance
+//	@Summary		Withdraw user's balance
+//	@Description	Withdrawal is limited by configured min and max sum, daily and monthly sums and number of withdrawals per hour.
+//	@Description	Violated limit is returned in code: withdrawal_sum_not_positive, withdrawal_below_min,
+//	@Description	withdrawal_above_max (422),
+//	@Description	withdrawal_daily_limit, withdrawal_monthly_limit (403) or withdrawal_hourly_count (429).
+//	@Description	Order number is checked by the same partner rules as uploaded orders.
 //	@Schemes
 //	@Tags		Balance
 //	@Accept		json
@@ -59,24 +110,42 @@ func (s *Service) GetBalance(c *gin.Context) {
 //	@Param		Authorization	header	string		true	"Bearer"
 //	@Security	ApiKeyAuth
 //	@Success	200	{object}	storage.Success	"Response"
-//	@Failure	401	{object}	storage.Error	"Unauthorized"
-//	@Failure	402	{object}	storage.Error	"not enough balance"
-//	@Failure	422	{object}	storage.Error	"Unprocessable Entity"
-//	@Failure	500	{object}	storage.Error	"Error"
+//	@Failure	400	{object}	utils.Problem	"Empty order number"
+//	@Failure	401	{object}	utils.Problem	"Unauthorized"
+//	@Failure	402	{object}	utils.Problem	"not enough balance"
+//	@Failure	403	{object}	utils.Problem	"Daily or monthly limit is exceeded or blocked by fraud rules"
+//	@Failure	422	{object}	utils.Problem	"Unprocessable Entity, sum is out of limits or order number is incorrect"
+//	@Failure	429	{object}	utils.Problem	"Too many withdrawals per hour"
+//	@Failure	500	{object}	utils.Problem	"Error"
 //	@Router		/api/user/balance/withdraw [post]
 func (s *Service) WithdrawBalance(c *gin.Context) {
 	login := c.GetString("Login")
 	var withdraw Withdraw
 	if err := c.ShouldBindJSON(&withdraw); err != nil {
 		s.Logger.Info(err.Error())
-		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		utils.Abort(c, utils.NewProblem(http.StatusUnprocessableEntity, err.Error()))
+		return
+	}
+	// Отрицательное списание пополнило бы баланс и уменьшило сумму списаний в лимитах
+	if withdraw.Sum <= 0 {
+		utils.Abort(c, utils.NewProblem(http.StatusUnprocessableEntity, "sum must be positive").WithCode(WithdrawalNotPositive))
+		return
+	}
+	number, ok := s.validateOrderNumber(c, withdraw.Order)
+	if !ok {
+		return
+	}
+	withdraw.Order = number
+
+	event := fraud.Event{Action: fraud.ActionWithdrawal, OrderNumber: withdraw.Order, Sum: withdraw.Sum}
+	if !s.checkFraud(c, event) {
 		return
 	}
 
 	tx, err := s.Repo.BeginTransaction(c)
 	if err != nil {
 		s.Logger.Info(err.Error())
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		utils.Abort(c, err)
 		return
 	}
 	defer tx.Rollback(c) //nolint:errcheck // ignore check
@@ -84,55 +153,104 @@ func (s *Service) WithdrawBalance(c *gin.Context) {
 	user, err := tx.GetUserWithLock(c, login)
 	if err != nil {
 		s.Logger.Info("user is not found")
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "user is not found"})
+		utils.Abort(c, utils.NewProblem(http.StatusUnauthorized, "user is not found"))
 		return
 	}
 
-	if user.Balance-withdraw.Sum < 0 {
+	violation, err := s.checkWithdrawalLimits(c, tx, login, withdraw.Sum)
+	if err != nil {
+		s.Logger.Info(err.Error())
+		utils.Abort(c, err)
+		return
+	}
+	if violation != nil {
+		s.Logger.Info("withdrawal limit is violated: ", violation.Code)
+		utils.Abort(c, violation)
+		return
+	}
+
+	// Баллы, зарезервированные холдами, недоступны для списания
+	if user.Balance-user.Held-withdraw.Sum < 0 {
 		s.Logger.Info("not enough balance")
-		c.JSON(http.StatusPaymentRequired, gin.H{"error": "not enough balance"})
+		utils.Abort(c, utils.NewProblem(http.StatusPaymentRequired, "not enough balance"))
 		return
 	}
 
 	order, err := tx.GetOrderWithLock(c, withdraw.Order, login)
 	if err != nil {
 		s.Logger.Info("order is not found")
-		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "order is not found"})
+		utils.Abort(c, utils.NewProblem(http.StatusUnprocessableEntity, "order is not found"))
 		return
 	}
 
 	if order.Login != login {
 		s.Logger.Info("order is user's order")
-		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "order is user's order"})
-		return
-	}
-
-	err = tx.WithdrawUserBalance(c, login, withdraw.Sum)
-	if err != nil {
-		s.Logger.Info(err.Error())
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		utils.Abort(c, utils.NewProblem(http.StatusUnprocessableEntity, "order is user's order"))
 		return
 	}
 
-	err = tx.WithdrawOrderBalance(c, withdraw.Order, withdraw.Sum)
+	err = withdrawPoints(c, tx, user, withdraw.Order, withdraw.Sum, "")
 	if err != nil {
 		s.Logger.Info(err.Error())
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		utils.Abort(c, err)
 		return
 	}
 
 	err = tx.Commit(c)
 	if err != nil {
 		s.Logger.Info(err.Error())
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		utils.Abort(c, err)
 		return
 	}
 
+	s.Broker.Publish(login, events.BalanceChanged, events.BalanceEvent{
+		Current: user.Balance - withdraw.Sum,
+	})
+
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 	})
 }
 
+// withdrawPoints списывает баллы пользователя, заблокированного в транзакции tx, в счет заказа
+// и записывает движение по балансу и событие аудита.
+func withdrawPoints(
+	c *gin.Context,
+	tx storage.Transaction,
+	user storage.User,
+	number string,
+	sum float32,
+	reason string,
+) error {
+	err := tx.WithdrawUserBalance(c, user.Login, sum)
+	if err != nil {
+		return err
+	}
+	err = tx.WithdrawOrderBalance(c, number, sum)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ConsumePointLots(c, user.Login, sum)
+	if err != nil {
+		return err
+	}
+	err = tx.StoreBalanceMovement(c, storage.BalanceMovement{
+		Login:       user.Login,
+		Kind:        storage.MovementWithdrawal,
+		Amount:      -sum,
+		OrderNumber: number,
+		Reason:      reason,
+	})
+	if err != nil {
+		return err
+	}
+	return tx.StoreAuditEvent(c, withAmounts(
+		newAuditEvent(c, user.Login, storage.AuditBalanceWithdrawn, number),
+		user.Balance,
+		user.Balance-sum,
+	))
+}
+
 // GetWithdrawls godoc
 //
 //	@Summary	Get user's withdrawls
@@ -143,15 +261,15 @@ func (s *Service) WithdrawBalance(c *gin.Context) {
 //	@Security	ApiKeyAuth
 //	@Success	200	{object}	[]WithdrawalsReponse	"Response"
 //	@Success	204	{object}	[]WithdrawalsReponse	"No orders"
-//	@Failure	401	{object}	storage.Error			"Unauthorized"
-//	@Failure	500	{object}	storage.Error			"Error"
+//	@Failure	401	{object}	utils.Problem			"Unauthorized"
+//	@Failure	500	{object}	utils.Problem			"Error"
 //	@Router		/api/user/withdrawals [get]
 func (s *Service) Withdrawls(c *gin.Context) {
 	login := c.GetString("Login")
 
 	orders, err := s.Repo.GetOrders(c, login, true)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		utils.Abort(c, err)
 		return
 	}
 
@@ -159,6 +277,10 @@ func (s *Service) Withdrawls(c *gin.Context) {
 		c.JSON(http.StatusNoContent, []WithdrawalsReponse{})
 	}
 
+	c.JSON(http.StatusOK, newWithdrawalsResponse(orders))
+}
+
+func newWithdrawalsResponse(orders []storage.Order) []WithdrawalsReponse {
 	var withdrawalsResponse []WithdrawalsReponse
 	for _, order := range orders {
 		withdrawalsResponse = append(
@@ -170,6 +292,5 @@ func (s *Service) Withdrawls(c *gin.Context) {
 			},
 		)
 	}
-
-	c.JSON(http.StatusOK, withdrawalsResponse)
+	return withdrawalsResponse
 }
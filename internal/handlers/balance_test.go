@@ -1,12 +1,14 @@
 package handlers_test
 
 import (
+	"context"
 	"net/http/httptest"
 	"time"
 
 	"github.com/golang/mock/gomock"
 
 	server "github.com/pisarevaa/gophermart/internal"
+	"github.com/pisarevaa/gophermart/internal/events"
 	"github.com/pisarevaa/gophermart/internal/handlers"
 	mock "github.com/pisarevaa/gophermart/internal/mocks"
 	"github.com/pisarevaa/gophermart/internal/storage"
@@ -35,7 +37,11 @@ func (suite *ServerTestSuite) TestGetBalanceMockDB() {
 		GetUser(gomock.Any(), gomock.Any()).
 		Return(user, nil)
 
-	ts := httptest.NewServer(server.NewRouter(suite.cfg, suite.logger, m))
+	m.EXPECT().
+		GetExpiringPointLots(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return([]storage.PointLot{}, nil)
+
+	ts := httptest.NewServer(server.NewRouter(suite.cfg, suite.logger, m, events.NewBroker(), nil))
 	defer ts.Close()
 
 	var userBalanceResponse handlers.UserBalanceInfo
@@ -58,7 +64,7 @@ func (suite *ServerTestSuite) TestWithdrawBalanceMockDB() {
 	tx := mock.NewMockTransaction(ctrl)
 
 	withdraw := handlers.Withdraw{
-		Order: "123",
+		Order: "12345678903",
 		Sum:   float32(200),
 	}
 
@@ -69,13 +75,21 @@ func (suite *ServerTestSuite) TestWithdrawBalanceMockDB() {
 	}
 
 	order := storage.Order{
-		Number:     "123",
+		Number:     "12345678903",
 		Status:     "PROCESSED",
 		Accrual:    float32(100),
 		Login:      "test",
 		UploadedAt: time.Now(),
 	}
 
+	m.EXPECT().
+		GetOrdersCountByStatus(gomock.Any(), gomock.Any()).
+		Return(map[string]int64{}, nil)
+
+	m.EXPECT().
+		GetUser(gomock.Any(), gomock.Any()).
+		Return(user, nil)
+
 	m.EXPECT().
 		BeginTransaction(gomock.Any()).
 		Return(tx, nil)
@@ -99,11 +113,23 @@ func (suite *ServerTestSuite) TestWithdrawBalanceMockDB() {
 		WithdrawOrderBalance(gomock.Any(), gomock.Any(), gomock.Any()).
 		Return(nil)
 
+	tx.EXPECT().
+		ConsumePointLots(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil, nil)
+
+	tx.EXPECT().
+		StoreBalanceMovement(gomock.Any(), gomock.Any()).
+		Return(nil)
+
+	tx.EXPECT().
+		StoreAuditEvent(gomock.Any(), gomock.Any()).
+		Return(nil)
+
 	tx.EXPECT().
 		Commit(gomock.Any()).
 		Return(nil)
 
-	ts := httptest.NewServer(server.NewRouter(suite.cfg, suite.logger, m))
+	ts := httptest.NewServer(server.NewRouter(suite.cfg, suite.logger, m, events.NewBroker(), nil))
 	defer ts.Close()
 
 	resp, err := suite.client.R().
@@ -134,7 +160,7 @@ func (suite *ServerTestSuite) TestWithdrawlsMockDB() {
 		GetOrders(gomock.Any(), gomock.Any(), gomock.Any()).
 		Return(orders, nil)
 
-	ts := httptest.NewServer(server.NewRouter(suite.cfg, suite.logger, m))
+	ts := httptest.NewServer(server.NewRouter(suite.cfg, suite.logger, m, events.NewBroker(), nil))
 	defer ts.Close()
 
 	var withdrawalsResponse []WithdrawalsReponse
@@ -158,7 +184,7 @@ func (suite *ServerTestSuite) TestGetBalanceInMemory() {
 		Withdrawn: float32(300),
 	}
 
-	ts := httptest.NewServer(server.NewRouter(suite.cfg, suite.logger, m))
+	ts := httptest.NewServer(server.NewRouter(suite.cfg, suite.logger, m, events.NewBroker(), nil))
 	defer ts.Close()
 
 	var userBalanceResponse handlers.UserBalanceInfo
@@ -187,7 +213,7 @@ func (suite *ServerTestSuite) TestWithdrawInMemory() {
 		ProcessedAt: &now,
 	}
 
-	ts := httptest.NewServer(server.NewRouter(suite.cfg, suite.logger, m))
+	ts := httptest.NewServer(server.NewRouter(suite.cfg, suite.logger, m, events.NewBroker(), nil))
 	defer ts.Close()
 
 	var withdrawalsResponse []WithdrawalsReponse
@@ -211,8 +237,8 @@ func (suite *ServerTestSuite) TestWithdrawBalanceInMemory() {
 	}
 
 	now := time.Now()
-	m.Orders["123"] = storage.Order{
-		Number:      "123",
+	m.Orders["12345678903"] = storage.Order{
+		Number:      "12345678903",
 		Status:      "PROCESSED",
 		Accrual:     float32(100),
 		Login:       "test",
@@ -221,11 +247,11 @@ func (suite *ServerTestSuite) TestWithdrawBalanceInMemory() {
 	}
 
 	withdraw := handlers.Withdraw{
-		Order: "123",
+		Order: "12345678903",
 		Sum:   float32(200),
 	}
 
-	ts := httptest.NewServer(server.NewRouter(suite.cfg, suite.logger, m))
+	ts := httptest.NewServer(server.NewRouter(suite.cfg, suite.logger, m, events.NewBroker(), nil))
 	defer ts.Close()
 
 	resp, err := suite.client.R().
@@ -236,3 +262,51 @@ func (suite *ServerTestSuite) TestWithdrawBalanceInMemory() {
 	suite.Require().NoError(err)
 	suite.Require().Equal(200, resp.StatusCode())
 }
+
+func (suite *ServerTestSuite) TestGetPendingBalanceInMemory() {
+	m := storage.NewMemory()
+	ctx := context.Background()
+
+	m.Users[login] = storage.User{
+		Login:   login,
+		Balance: float32(500),
+		Role:    storage.RoleUser,
+	}
+	suite.Require().NoError(m.StoreOrder(ctx, "12345678903", login))
+	suite.Require().NoError(m.StoreOrder(ctx, "79927398713", login))
+	suite.Require().NoError(m.StoreProvisionalOrderStatus(ctx, storage.OrderStatus{
+		Number:  "79927398713",
+		Status:  "PROCESSING",
+		Accrual: 30,
+	}))
+
+	ts := httptest.NewServer(server.NewRouter(suite.cfg, suite.logger, m, events.NewBroker(), nil))
+	defer ts.Close()
+
+	var balance handlers.UserBalanceInfo
+	resp, err := suite.client.R().
+		SetResult(&balance).
+		SetHeader("Authorization", "Bearer "+suite.token).
+		Get(ts.URL + "/api/user/balance")
+	suite.Require().NoError(err)
+	suite.Require().Equal(200, resp.StatusCode())
+	suite.Require().Nil(balance.Pending)
+
+	resp, err = suite.client.R().
+		SetResult(&balance).
+		SetHeader("Authorization", "Bearer "+suite.token).
+		Get(ts.URL + "/api/user/balance?pending=true")
+	suite.Require().NoError(err)
+	suite.Require().Equal(200, resp.StatusCode())
+	suite.Require().NotNil(balance.Pending)
+	suite.Require().Equal(float32(30), *balance.Pending)
+	suite.Require().Equal([]storage.PendingBalance{
+		{Status: "NEW", Count: 1},
+		{Status: "PROCESSING", Count: 1, Accrual: 30},
+	}, balance.PendingByStatus)
+
+	// Заказ, который опрашивается фоновой задачей, не считается присланным системой расчета
+	count, err := m.GetOrdersCountToUpdate(ctx)
+	suite.Require().NoError(err)
+	suite.Require().Equal(int64(2), count)
+}
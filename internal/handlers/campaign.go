@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/pisarevaa/gophermart/internal/loyalty"
+	"github.com/pisarevaa/gophermart/internal/storage"
+	"github.com/pisarevaa/gophermart/internal/tasks"
+	"github.com/pisarevaa/gophermart/internal/utils"
+)
+
+type CampaignRequest struct {
+	Name           string    `json:"name"           binding:"required"`
+	StartsAt       time.Time `json:"startsAt"       binding:"required"`
+	EndsAt         time.Time `json:"endsAt"         binding:"required"`
+	FirstOrderOnly bool      `json:"firstOrderOnly"`
+	MinAccrual     float32   `json:"minAccrual"`
+	Tiers          []string  `json:"tiers"`
+	BonusType      string    `json:"bonusType"      binding:"required"`
+	BonusValue     float32   `json:"bonusValue"     binding:"required"`
+}
+
+// bindCampaign разбирает и проверяет запрос на создание или изменение промо-акции.
+func (s *Service) bindCampaign(c *gin.Context) (storage.Campaign, bool) {
+	var request CampaignRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.Abort(c, utils.NewProblem(http.StatusUnprocessableEntity, err.Error()))
+		return storage.Campaign{}, false
+	}
+	campaign := storage.Campaign{
+		Name:           request.Name,
+		StartsAt:       request.StartsAt,
+		EndsAt:         request.EndsAt,
+		FirstOrderOnly: request.FirstOrderOnly,
+		MinAccrual:     request.MinAccrual,
+		Tiers:          []string{},
+		BonusType:      request.BonusType,
+		BonusValue:     request.BonusValue,
+	}
+	if err := tasks.ValidateCampaign(campaign); err != nil {
+		utils.Abort(c, utils.NewProblem(http.StatusUnprocessableEntity, err.Error()))
+		return storage.Campaign{}, false
+	}
+	tiers := loyalty.NewTiers(s.Config.LoyaltyTiers)
+	for _, tier := range request.Tiers {
+		if tiers.ByName(tier).Name != tier {
+			utils.Abort(c, utils.NewProblem(http.StatusUnprocessableEntity, fmt.Sprintf("unknown loyalty tier %q", tier)))
+			return storage.Campaign{}, false
+		}
+		if !slices.Contains(campaign.Tiers, tier) {
+			campaign.Tiers = append(campaign.Tiers, tier)
+		}
+	}
+	return campaign, true
+}
+
+// AdminCreateCampaign godoc
+//
+//	@Summary		Create promotional campaign
+//	@Description	Campaign credits bonus points for orders finalized between startsAt and endsAt.
+//	@Description	Bonus type multiplier credits accrual * (bonusValue - 1), fixed credits bonusValue points.
+//	@Description	Eligibility is limited by firstOrderOnly, minAccrual and loyalty tiers (empty - all tiers).
+//	@Schemes
+//	@Tags		Admin
+//	@Accept		json
+//	@Produce	json
+//	@Param		request			body	CampaignRequest	true	"Body"
+//	@Param		Authorization	header	string			true	"Bearer"
+//	@Security	ApiKeyAuth
+//	@Success	201	{object}	storage.Campaign	"Campaign is created"
+//	@Failure	401	{object}	utils.Problem		"Unauthorized"
+//	@Failure	403	{object}	utils.Problem		"Forbidden"
+//	@Failure	422	{object}	utils.Problem		"Unprocessable Entity"
+//	@Failure	500	{object}	utils.Problem		"Error"
+//	@Router		/api/admin/campaigns [post]
+func (s *Service) AdminCreateCampaign(c *gin.Context) {
+	campaign, ok := s.bindCampaign(c)
+	if !ok {
+		return
+	}
+	var err error
+	campaign.ID, err = s.Repo.StoreCampaign(c, campaign)
+	if err != nil {
+		utils.Abort(c, err)
+		return
+	}
+	campaign, err = s.Repo.GetCampaign(c, campaign.ID)
+	if err != nil {
+		utils.Abort(c, err)
+		return
+	}
+
+	s.Logger.Info("campaign ", campaign.ID, " is created by ", c.GetString("Login"))
+
+	c.JSON(http.StatusCreated, campaign)
+}
+
+// AdminGetCampaigns godoc
+//
+//	@Summary	Get promotional campaigns
+//	@Schemes
+//	@Tags		Admin
+//	@Produce	json
+//	@Param		Authorization	header	string	true	"Bearer"
+//	@Security	ApiKeyAuth
+//	@Success	200	{object}	[]storage.Campaign	"Response"
+//	@Failure	401	{object}	utils.Problem		"Unauthorized"
+//	@Failure	403	{object}	utils.Problem		"Forbidden"
+//	@Failure	500	{object}	utils.Problem		"Error"
+//	@Router		/api/admin/campaigns [get]
+func (s *Service) AdminGetCampaigns(c *gin.Context) {
+	campaigns, err := s.Repo.GetCampaigns(c)
+	if err != nil {
+		utils.Abort(c, err)
+		return
+	}
+	if campaigns == nil {
+		campaigns = []storage.Campaign{}
+	}
+	c.JSON(http.StatusOK, campaigns)
+}
+
+// AdminUpdateCampaign godoc
+//
+//	@Summary		Update promotional campaign
+//	@Description	Changes apply to orders finalized after the update, already credited bonuses are kept.
+//	@Schemes
+//	@Tags		Admin
+//	@Accept		json
+//	@Produce	json
+//	@Param		id				path	int				true	"Campaign ID"
+//	@Param		request			body	CampaignRequest	true	"Body"
+//	@Param		Authorization	header	string			true	"Bearer"
+//	@Security	ApiKeyAuth
+//	@Success	200	{object}	storage.Campaign	"Campaign is updated"
+//	@Failure	401	{object}	utils.Problem		"Unauthorized"
+//	@Failure	403	{object}	utils.Problem		"Forbidden"
+//	@Failure	404	{object}	utils.Problem		"Campaign is not found"
+//	@Failure	422	{object}	utils.Problem		"Unprocessable Entity"
+//	@Failure	500	{object}	utils.Problem		"Error"
+//	@Router		/api/admin/campaigns/{id} [put]
+func (s *Service) AdminUpdateCampaign(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.Abort(c, utils.NewProblem(http.StatusNotFound, "campaign is not found"))
+		return
+	}
+	campaign, ok := s.bindCampaign(c)
+	if !ok {
+		return
+	}
+	campaign.ID = id
+	err = s.Repo.UpdateCampaign(c, campaign)
+	if err != nil {
+		utils.Abort(c, utils.NewProblem(http.StatusNotFound, "campaign is not found"))
+		return
+	}
+	campaign, err = s.Repo.GetCampaign(c, id)
+	if err != nil {
+		utils.Abort(c, err)
+		return
+	}
+
+	s.Logger.Info("campaign ", id, " is updated by ", c.GetString("Login"))
+
+	c.JSON(http.StatusOK, campaign)
+}
+
+// AdminDeleteCampaign godoc
+//
+//	@Summary		Delete promotional campaign
+//	@Description	Deleted campaign stops crediting bonuses, already credited bonuses are kept.
+//	@Schemes
+//	@Tags		Admin
+//	@Produce	json
+//	@Param		id				path	int		true	"Campaign ID"
+//	@Param		Authorization	header	string	true	"Bearer"
+//	@Security	ApiKeyAuth
+//	@Success	200	{object}	storage.Success	"Campaign is deleted"
+//	@Failure	401	{object}	utils.Problem	"Unauthorized"
+//	@Failure	403	{object}	utils.Problem	"Forbidden"
+//	@Failure	404	{object}	utils.Problem	"Campaign is not found"
+//	@Router		/api/admin/campaigns/{id} [delete]
+func (s *Service) AdminDeleteCampaign(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.Abort(c, utils.NewProblem(http.StatusNotFound, "campaign is not found"))
+		return
+	}
+	err = s.Repo.DeleteCampaign(c, id)
+	if err != nil {
+		utils.Abort(c, utils.NewProblem(http.StatusNotFound, "campaign is not found"))
+		return
+	}
+
+	s.Logger.Info("campaign ", id, " is deleted by ", c.GetString("Login"))
+
+	c.JSON(http.StatusOK, storage.Success{Success: true})
+}
@@ -0,0 +1,162 @@
+package handlers_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"strconv"
+	"time"
+
+	server "github.com/pisarevaa/gophermart/internal"
+	"github.com/pisarevaa/gophermart/internal/events"
+	"github.com/pisarevaa/gophermart/internal/handlers"
+	"github.com/pisarevaa/gophermart/internal/storage"
+	"github.com/pisarevaa/gophermart/internal/tasks"
+	"github.com/pisarevaa/gophermart/internal/utils"
+)
+
+func (suite *ServerTestSuite) TestCampaignsInMemory() {
+	ctx := context.Background()
+	m := storage.NewMemory()
+	m.Users[login] = storage.User{
+		Login: login,
+		Role:  storage.RoleUser,
+	}
+	suite.Require().NoError(m.StoreOrder(ctx, "12345678903", login))
+	suite.Require().NoError(m.StoreOrder(ctx, "79927398713", login))
+
+	ts := httptest.NewServer(server.NewRouter(suite.cfg, suite.logger, m, events.NewBroker(), nil))
+	defer ts.Close()
+
+	m.Users["admin"] = storage.User{Login: "admin", Role: storage.RoleAdmin}
+	adminToken, err := utils.GenerateJWTString(suite.cfg.TokenExpSec, suite.cfg.SecretKey, "admin", storage.RoleAdmin)
+	suite.Require().NoError(err)
+	m.Users["support"] = storage.User{Login: "support", Role: storage.RoleSupport}
+	supportToken, err := utils.GenerateJWTString(suite.cfg.TokenExpSec, suite.cfg.SecretKey, "support", storage.RoleSupport)
+	suite.Require().NoError(err)
+
+	now := time.Now()
+	double := handlers.CampaignRequest{
+		Name:       "double points",
+		StartsAt:   now.Add(-time.Hour),
+		EndsAt:     now.Add(time.Hour),
+		Tiers:      []string{"bronze"},
+		BonusType:  storage.CampaignBonusMultiplier,
+		BonusValue: 2,
+	}
+	resp, err := suite.client.R().
+		SetBody(double).
+		SetHeader("Authorization", "Bearer "+supportToken).
+		Post(ts.URL + "/api/admin/campaigns")
+	suite.Require().NoError(err)
+	suite.Require().Equal(403, resp.StatusCode())
+
+	var created storage.Campaign
+	resp, err = suite.client.R().
+		SetBody(double).
+		SetResult(&created).
+		SetHeader("Authorization", "Bearer "+adminToken).
+		Post(ts.URL + "/api/admin/campaigns")
+	suite.Require().NoError(err)
+	suite.Require().Equal(201, resp.StatusCode())
+	suite.Require().Equal("double points", created.Name)
+
+	firstOrder := handlers.CampaignRequest{
+		Name:           "first order",
+		StartsAt:       now.Add(-time.Hour),
+		EndsAt:         now.Add(time.Hour),
+		FirstOrderOnly: true,
+		BonusType:      storage.CampaignBonusFixed,
+		BonusValue:     50,
+	}
+	resp, err = suite.client.R().
+		SetBody(firstOrder).
+		SetHeader("Authorization", "Bearer "+adminToken).
+		Post(ts.URL + "/api/admin/campaigns")
+	suite.Require().NoError(err)
+	suite.Require().Equal(201, resp.StatusCode())
+
+	invalid := firstOrder
+	invalid.BonusType = "percent"
+	resp, err = suite.client.R().
+		SetBody(invalid).
+		SetHeader("Authorization", "Bearer "+adminToken).
+		Post(ts.URL + "/api/admin/campaigns")
+	suite.Require().NoError(err)
+	suite.Require().Equal(422, resp.StatusCode())
+
+	invalid = double
+	invalid.Tiers = []string{"platinum"}
+	resp, err = suite.client.R().
+		SetBody(invalid).
+		SetHeader("Authorization", "Bearer "+adminToken).
+		Post(ts.URL + "/api/admin/campaigns")
+	suite.Require().NoError(err)
+	suite.Require().Equal(422, resp.StatusCode())
+
+	var campaigns []storage.Campaign
+	resp, err = suite.client.R().
+		SetResult(&campaigns).
+		SetHeader("Authorization", "Bearer "+supportToken).
+		Get(ts.URL + "/api/admin/campaigns")
+	suite.Require().NoError(err)
+	suite.Require().Equal(200, resp.StatusCode())
+	suite.Require().Len(campaigns, 2)
+
+	// Первый заказ получает обе акции, каждый бонус записывается отдельным движением
+	tx, err := m.BeginTransaction(ctx)
+	suite.Require().NoError(err)
+	policy := tasks.NewAccrualPolicy(suite.cfg, time.Now())
+	status := storage.OrderStatus{Number: "12345678903", Status: "PROCESSED", Accrual: 100}
+	balance, err := tasks.ApplyOrderStatus(ctx, tx, login, status, policy, "")
+	suite.Require().NoError(err)
+	suite.Require().InDelta(250, balance, 0.001)
+	suite.Require().InDelta(150, m.Orders["12345678903"].CampaignBonus, 0.001)
+	var campaignMovements []storage.BalanceMovement
+	for _, movement := range m.Movements {
+		if movement.Kind == storage.MovementCampaignBonus {
+			campaignMovements = append(campaignMovements, movement)
+		}
+	}
+	suite.Require().Len(campaignMovements, 2)
+	suite.Require().Equal(created.ID, *campaignMovements[0].CampaignID)
+	suite.Require().InDelta(100, campaignMovements[0].Amount, 0.001)
+	suite.Require().InDelta(50, campaignMovements[1].Amount, 0.001)
+
+	// Второй заказ не первый, бонус только по удвоению
+	status = storage.OrderStatus{Number: "79927398713", Status: "PROCESSED", Accrual: 100}
+	balance, err = tasks.ApplyOrderStatus(ctx, tx, login, status, policy, "")
+	suite.Require().NoError(err)
+	suite.Require().InDelta(450, balance, 0.001)
+
+	// Отмена начисления списывает и бонус по акциям
+	balance, err = tasks.ReverseOrderAccrual(ctx, tx, m.Orders["79927398713"], storage.OrderStatus{
+		Number: "79927398713",
+		Status: "INVALID",
+	}, "support", "fraud", "")
+	suite.Require().NoError(err)
+	suite.Require().InDelta(250, balance, 0.001)
+	suite.Require().InDelta(0, m.Orders["79927398713"].CampaignBonus, 0.001)
+
+	// Завершенная акция больше не начисляет бонусы
+	double.EndsAt = now.Add(-time.Minute)
+	resp, err = suite.client.R().
+		SetBody(double).
+		SetHeader("Authorization", "Bearer "+adminToken).
+		Put(ts.URL + "/api/admin/campaigns/" + strconv.FormatInt(created.ID, 10))
+	suite.Require().NoError(err)
+	suite.Require().Equal(200, resp.StatusCode())
+	active, err := tx.GetActiveCampaigns(ctx, time.Now())
+	suite.Require().NoError(err)
+	suite.Require().Len(active, 1)
+
+	resp, err = suite.client.R().
+		SetHeader("Authorization", "Bearer "+adminToken).
+		Delete(ts.URL + "/api/admin/campaigns/" + strconv.FormatInt(created.ID, 10))
+	suite.Require().NoError(err)
+	suite.Require().Equal(200, resp.StatusCode())
+	resp, err = suite.client.R().
+		SetHeader("Authorization", "Bearer "+adminToken).
+		Delete(ts.URL + "/api/admin/campaigns/" + strconv.FormatInt(created.ID, 10))
+	suite.Require().NoError(err)
+	suite.Require().Equal(404, resp.StatusCode())
+}
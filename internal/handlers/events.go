@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/pisarevaa/gophermart/internal/events"
+)
+
+const keepAliveInterval = 15 * time.Second
+
+// StreamEvents godoc
+//
+//	@Summary		Stream order status and balance changes
+//	@Description	Server-Sent Events stream. Reconnect with Last-Event-ID header to receive missed events.
+//	@Description	Event ID from a previous server run replays all stored events of the current run.
+//	@Schemes
+//	@Tags		Orders
+//	@Produce	text/event-stream
+//	@Param		Last-Event-ID	header	string	false	"Last received event ID"
+//	@Param		Authorization	header	string	true	"Bearer"
+//	@Security	ApiKeyAuth
+//	@Success	200	{object}	events.OrderEvent	"Data of order.status events, balance events contain events.BalanceEvent"
+//	@Failure	401	{object}	utils.Problem		"Unauthorized"
+//	@Router		/api/user/orders/events [get]
+func (s *Service) StreamEvents(c *gin.Context) {
+	login := c.GetString("Login")
+
+	ch, replay, unsubscribe := s.Broker.Subscribe(login, c.GetHeader("Last-Event-ID"))
+	defer unsubscribe()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	for _, event := range replay {
+		if err := writeEvent(c, event); err != nil {
+			return
+		}
+	}
+	c.Writer.Flush()
+
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case event := <-ch:
+			if err := writeEvent(c, event); err != nil {
+				return
+			}
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(c.Writer, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		case <-c.Request.Context().Done():
+			return
+		}
+		c.Writer.Flush()
+	}
+}
+
+func writeEvent(c *gin.Context, event events.Event) error {
+	data, err := json.Marshal(event.Data)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(c.Writer, "id: %s\nevent: %s\ndata: %s\n\n", event.StreamID(), event.Type, data)
+	return err
+}
@@ -0,0 +1,65 @@
+package handlers_test
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	server "github.com/pisarevaa/gophermart/internal"
+	"github.com/pisarevaa/gophermart/internal/events"
+	"github.com/pisarevaa/gophermart/internal/storage"
+)
+
+func (suite *ServerTestSuite) TestStreamEventsInMemory() {
+	m := storage.NewMemory()
+	broker := events.NewBroker()
+
+	ts := httptest.NewServer(server.NewRouter(suite.cfg, suite.logger, m, broker, nil))
+	defer ts.Close()
+
+	first := broker.Publish(login, events.OrderStatusChanged, events.OrderEvent{Number: "1", Status: "PROCESSING"})
+	second := broker.Publish(login, events.OrderStatusChanged, events.OrderEvent{Number: "1", Status: "PROCESSED", Accrual: 100})
+	broker.Publish("other", events.BalanceChanged, events.BalanceEvent{Current: 1})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+"/api/user/orders/events", nil)
+	suite.Require().NoError(err)
+	req.Header.Set("Authorization", "Bearer "+suite.token)
+	req.Header.Set("Last-Event-ID", first.StreamID())
+	resp, err := http.DefaultClient.Do(req)
+	suite.Require().NoError(err)
+	defer resp.Body.Close()
+	suite.Require().Equal(200, resp.StatusCode)
+	suite.Require().Equal("text/event-stream", resp.Header.Get("Content-Type"))
+
+	reader := bufio.NewReader(resp.Body)
+	readEvent := func() []string {
+		var lines []string
+		for {
+			line, errRead := reader.ReadString('\n')
+			suite.Require().NoError(errRead)
+			line = strings.TrimSuffix(line, "\n")
+			if line == "" {
+				return lines
+			}
+			lines = append(lines, line)
+		}
+	}
+
+	replayed := readEvent()
+	suite.Require().Equal("id: "+second.StreamID(), replayed[0])
+	suite.Require().Equal("event: "+events.OrderStatusChanged, replayed[1])
+	suite.Require().Contains(replayed[2], `"status":"PROCESSED"`)
+	suite.Require().Equal(uint64(1), first.ID)
+
+	balance := broker.Publish(login, events.BalanceChanged, events.BalanceEvent{Current: 100})
+	live := readEvent()
+	suite.Require().Equal("id: "+balance.StreamID(), live[0])
+	suite.Require().Equal(uint64(4), balance.ID)
+	suite.Require().Equal("event: "+events.BalanceChanged, live[1])
+	suite.Require().Equal(`data: {"current":100}`, live[2])
+}
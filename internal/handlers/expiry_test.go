@@ -0,0 +1,152 @@
+package handlers_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"strconv"
+	"time"
+
+	server "github.com/pisarevaa/gophermart/internal"
+	"github.com/pisarevaa/gophermart/internal/events"
+	"github.com/pisarevaa/gophermart/internal/handlers"
+	"github.com/pisarevaa/gophermart/internal/storage"
+	"github.com/pisarevaa/gophermart/internal/tasks"
+)
+
+type UserBalanceInfo struct {
+	Current  float32 `json:"current"`
+	Expiring []struct {
+		Amount    float32   `json:"amount"`
+		ExpiresAt time.Time `json:"expires_at"`
+	} `json:"expiring"`
+}
+
+func (suite *ServerTestSuite) TestPointsExpiryInMemory() {
+	m := storage.NewMemory()
+	ctx := context.Background()
+
+	m.Users[login] = storage.User{
+		Login:   login,
+		Balance: float32(300),
+		Role:    storage.RoleUser,
+	}
+	now := time.Now()
+	m.Orders["12345678903"] = storage.Order{
+		Number:      "12345678903",
+		Status:      "PROCESSED",
+		Accrual:     float32(300),
+		Login:       login,
+		UploadedAt:  now,
+		ProcessedAt: &now,
+	}
+	soon := now.Add(time.Hour)
+	later := now.Add(24 * time.Hour)
+	suite.Require().NoError(m.StorePointLot(ctx, storage.PointLot{Login: login, Amount: 100, ExpiresAt: &later}))
+	suite.Require().NoError(m.StorePointLot(ctx, storage.PointLot{Login: login, Amount: 100}))
+	suite.Require().NoError(m.StorePointLot(ctx, storage.PointLot{Login: login, Amount: 100, ExpiresAt: &soon}))
+
+	ts := httptest.NewServer(server.NewRouter(suite.cfg, suite.logger, m, events.NewBroker(), nil))
+	defer ts.Close()
+
+	// Списание расходует сначала партию, которая сгорает раньше
+	resp, err := suite.client.R().
+		SetBody(handlers.Withdraw{Order: "12345678903", Sum: 150}).
+		SetHeader("Content-Type", "application/json").
+		SetHeader("Authorization", "Bearer "+suite.token).
+		Post(ts.URL + "/api/user/balance/withdraw")
+	suite.Require().NoError(err)
+	suite.Require().Equal(200, resp.StatusCode())
+	suite.Require().Equal(float32(50), m.PointLots[0].Remaining)
+	suite.Require().Equal(float32(100), m.PointLots[1].Remaining)
+	suite.Require().Equal(float32(0), m.PointLots[2].Remaining)
+
+	var balance UserBalanceInfo
+	resp, err = suite.client.R().
+		SetResult(&balance).
+		SetHeader("Authorization", "Bearer "+suite.token).
+		Get(ts.URL + "/api/user/balance")
+	suite.Require().NoError(err)
+	suite.Require().Equal(200, resp.StatusCode())
+	suite.Require().Len(balance.Expiring, 1)
+	suite.Require().Equal(float32(50), balance.Expiring[0].Amount)
+	suite.Require().WithinDuration(later, balance.Expiring[0].ExpiresAt, time.Second)
+
+	past := now.Add(-time.Minute)
+	m.PointLots[0].ExpiresAt = &past
+	task := tasks.NewTask(suite.cfg, suite.logger, m, nil, events.NewBroker())
+	suite.Require().NoError(task.ExpirePoints(ctx))
+	suite.Require().Equal(float32(100), m.Users[login].Balance)
+	suite.Require().Equal(float32(0), m.PointLots[0].Remaining)
+	movement := m.Movements[len(m.Movements)-1]
+	suite.Require().Equal(storage.MovementExpiry, movement.Kind)
+	suite.Require().Equal(float32(-50), movement.Amount)
+
+	// Баллы, зарезервированные холдом, не сгорают
+	user := m.Users[login]
+	user.Held = 80
+	m.Users[login] = user
+	m.PointLots[1].ExpiresAt = &past
+	suite.Require().NoError(task.ExpirePoints(ctx))
+	suite.Require().Equal(float32(80), m.Users[login].Balance)
+
+	logins, err := m.GetLoginsWithExpiredPoints(ctx, 100)
+	suite.Require().NoError(err)
+	suite.Require().Empty(logins)
+}
+
+func (suite *ServerTestSuite) TestPointsExpiryAfterHoldVoidInMemory() {
+	m := storage.NewMemory()
+	ctx := context.Background()
+
+	m.Users[login] = storage.User{
+		Login:   login,
+		Balance: float32(150),
+		Role:    storage.RoleUser,
+	}
+	now := time.Now()
+	m.Orders["12345678903"] = storage.Order{
+		Number:      "12345678903",
+		Status:      "PROCESSED",
+		Accrual:     float32(150),
+		Login:       login,
+		UploadedAt:  now,
+		ProcessedAt: &now,
+	}
+	later := now.Add(24 * time.Hour)
+	suite.Require().NoError(m.StorePointLot(ctx, storage.PointLot{Login: login, Amount: 100, ExpiresAt: &later}))
+	suite.Require().NoError(m.StorePointLot(ctx, storage.PointLot{Login: login, Amount: 50}))
+
+	ts := httptest.NewServer(server.NewRouter(suite.cfg, suite.logger, m, events.NewBroker(), nil))
+	defer ts.Close()
+
+	var hold HoldResponse
+	resp, err := suite.client.R().
+		SetResult(&hold).
+		SetBody(handlers.CreateHold{Order: "79927398713", Sum: 120}).
+		SetHeader("Content-Type", "application/json").
+		SetHeader("Authorization", "Bearer "+suite.token).
+		Post(ts.URL + "/api/user/balance/holds")
+	suite.Require().NoError(err)
+	suite.Require().Equal(201, resp.StatusCode())
+
+	// Сгорает только свободная часть партии, зарезервированная ждет закрытия холда
+	past := now.Add(-time.Minute)
+	m.PointLots[0].ExpiresAt = &past
+	task := tasks.NewTask(suite.cfg, suite.logger, m, nil, events.NewBroker())
+	suite.Require().NoError(task.ExpirePoints(ctx))
+	suite.Require().Equal(float32(120), m.Users[login].Balance)
+	suite.Require().Equal(float32(70), m.PointLots[0].Remaining)
+
+	resp, err = suite.client.R().
+		SetHeader("Authorization", "Bearer "+suite.token).
+		Post(ts.URL + "/api/user/balance/holds/" + strconv.FormatInt(hold.ID, 10) + "/void")
+	suite.Require().NoError(err)
+	suite.Require().Equal(200, resp.StatusCode())
+	suite.Require().Equal(float32(0), m.Users[login].Held)
+
+	// После отмены холда остаток партии сгорает, доступны только бессрочные баллы
+	suite.Require().NoError(task.ExpirePoints(ctx))
+	suite.Require().Equal(float32(50), m.Users[login].Balance)
+	suite.Require().Equal(float32(0), m.PointLots[0].Remaining)
+	suite.Require().Equal(float32(50), m.PointLots[1].Remaining)
+}
@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pisarevaa/gophermart/internal/fraud"
+	"github.com/pisarevaa/gophermart/internal/storage"
+	"github.com/pisarevaa/gophermart/internal/utils"
+)
+
+const fraudReviewsLimit = 100
+
+// FraudBlocked - код ответа на действие, заблокированное правилами антифрода.
+const FraudBlocked = "fraud_blocked"
+
+type ResolveFraudReview struct {
+	Status  string `json:"status"  binding:"required"`
+	Comment string `json:"comment"`
+}
+
+// checkFraud оценивает действие пользователя правилами антифрода и прерывает запрос с 403,
+// если действие заблокировано. Возвращает false, если ответ уже отправлен.
+func (s *Service) checkFraud(c *gin.Context, event fraud.Event) bool {
+	blocked, err := s.assessFraud(c, event)
+	if err != nil {
+		utils.Abort(c, err)
+		return false
+	}
+	if blocked {
+		utils.Abort(c, utils.NewProblem(http.StatusForbidden, "action is blocked and sent to review").WithCode(FraudBlocked))
+		return false
+	}
+	return true
+}
+
+// assessFraud оценивает действие пользователя правилами антифрода. Действие, требующее проверки,
+// выполняется и попадает в очередь проверки, заблокированное - только попадает в очередь.
+// Одобренное оператором заблокированное действие выполняется один раз при повторе с той же суммой.
+// Вызывается вне транзакции, заявка на проверку сохраняется даже если действие не выполнится.
+func (s *Service) assessFraud(c *gin.Context, event fraud.Event) (bool, error) {
+	event.Login = c.GetString("Login")
+	event.At = time.Now()
+	assessment, err := s.Fraud.Evaluate(c, s.Repo, event)
+	if err != nil {
+		return false, err
+	}
+	if assessment.Decision == fraud.DecisionAllow {
+		return false, nil
+	}
+	if assessment.Decision == fraud.DecisionBlock {
+		approved, errApproved := s.Repo.UseApprovedFraudReview(c, event.Login, event.Action, event.OrderNumber, event.Sum)
+		if errApproved != nil {
+			return false, errApproved
+		}
+		if approved {
+			return false, nil
+		}
+	}
+	id, err := s.Repo.StoreFraudReview(c, storage.FraudReview{
+		Login:       event.Login,
+		Action:      event.Action,
+		OrderNumber: event.OrderNumber,
+		Sum:         event.Sum,
+		Score:       assessment.Score,
+		Decision:    assessment.Decision,
+		Reasons:     assessment.Reasons,
+	})
+	if err != nil {
+		return false, err
+	}
+	s.Logger.Info(
+		event.Action, " of ", event.Login, " is sent to fraud review ", id,
+		" decision ", assessment.Decision, " score ", assessment.Score,
+	)
+	return assessment.Decision == fraud.DecisionBlock, nil
+}
+
+// AdminGetFraudReviews godoc
+//
+//	@Summary		Get fraud review queue
+//	@Description	Decision block means the action was rejected, review - the action was performed and needs a check.
+//	@Schemes
+//	@Tags		Admin
+//	@Produce	json
+//	@Param		status			query	string	false	"Review status (pending, approved, rejected), default pending"
+//	@Param		Authorization	header	string	true	"Bearer"
+//	@Security	ApiKeyAuth
+//	@Success	200	{object}	[]storage.FraudReview	"Response"
+//	@Failure	400	{object}	utils.Problem			"Unknown status"
+//	@Failure	401	{object}	utils.Problem			"Unauthorized"
+//	@Failure	403	{object}	utils.Problem			"Forbidden"
+//	@Failure	500	{object}	utils.Problem			"Error"
+//	@Router		/api/admin/fraud-reviews [get]
+func (s *Service) AdminGetFraudReviews(c *gin.Context) {
+	status := c.DefaultQuery("status", storage.FraudReviewPending)
+	if !slices.Contains(
+		[]string{storage.FraudReviewPending, storage.FraudReviewApproved, storage.FraudReviewRejected},
+		status,
+	) {
+		utils.Abort(c, utils.NewProblem(http.StatusBadRequest, "unknown status"))
+		return
+	}
+	reviews, err := s.Repo.GetFraudReviews(c, status, fraudReviewsLimit)
+	if err != nil {
+		utils.Abort(c, err)
+		return
+	}
+	if reviews == nil {
+		reviews = []storage.FraudReview{}
+	}
+	c.JSON(http.StatusOK, reviews)
+}
+
+// AdminResolveFraudReview godoc
+//
+//	@Summary		Resolve fraud review
+//	@Description	Approved blocked action is allowed once when the user repeats it with the same order number and sum.
+//	@Schemes
+//	@Tags		Admin
+//	@Accept		json
+//	@Produce	json
+//	@Param		id				path	int					true	"Review ID"
+//	@Param		request			body	ResolveFraudReview	true	"Status approved or rejected"
+//	@Param		Authorization	header	string				true	"Bearer"
+//	@Security	ApiKeyAuth
+//	@Success	200	{object}	storage.Success	"Review is resolved"
+//	@Failure	401	{object}	utils.Problem	"Unauthorized"
+//	@Failure	403	{object}	utils.Problem	"Forbidden"
+//	@Failure	404	{object}	utils.Problem	"Pending review is not found"
+//	@Failure	422	{object}	utils.Problem	"Unprocessable Entity"
+//	@Router		/api/admin/fraud-reviews/{id} [post]
+func (s *Service) AdminResolveFraudReview(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.Abort(c, utils.NewProblem(http.StatusNotFound, "pending review is not found"))
+		return
+	}
+	var request ResolveFraudReview
+	if err = c.ShouldBindJSON(&request); err != nil {
+		utils.Abort(c, utils.NewProblem(http.StatusUnprocessableEntity, err.Error()))
+		return
+	}
+	if request.Status != storage.FraudReviewApproved && request.Status != storage.FraudReviewRejected {
+		utils.Abort(c, utils.NewProblem(http.StatusUnprocessableEntity, "status must be approved or rejected"))
+		return
+	}
+	err = s.Repo.ResolveFraudReview(c, storage.FraudReview{
+		ID:       id,
+		Status:   request.Status,
+		Operator: c.GetString("Login"),
+		Comment:  request.Comment,
+	})
+	if err != nil {
+		utils.Abort(c, utils.NewProblem(http.StatusNotFound, "pending review is not found"))
+		return
+	}
+
+	s.Logger.Info("fraud review ", id, " is ", request.Status, " by ", c.GetString("Login"))
+
+	c.JSON(http.StatusOK, storage.Success{Success: true})
+}
@@ -0,0 +1,215 @@
+package handlers_test
+
+import (
+	"net/http/httptest"
+	"strconv"
+	"time"
+
+	server "github.com/pisarevaa/gophermart/internal"
+	"github.com/pisarevaa/gophermart/internal/configs"
+	"github.com/pisarevaa/gophermart/internal/events"
+	"github.com/pisarevaa/gophermart/internal/fraud"
+	"github.com/pisarevaa/gophermart/internal/handlers"
+	"github.com/pisarevaa/gophermart/internal/storage"
+	"github.com/pisarevaa/gophermart/internal/utils"
+)
+
+func (suite *ServerTestSuite) TestFraudReviewsInMemory() {
+	m := storage.NewMemory()
+	now := time.Now()
+	m.Users[login] = storage.User{
+		Login:     login,
+		Balance:   float32(1000),
+		Role:      storage.RoleUser,
+		CreatedAt: now,
+	}
+	processedAt := now.Add(-2 * time.Hour)
+	m.Orders["12345678903"] = storage.Order{
+		Number:      "12345678903",
+		Status:      "PROCESSED",
+		Accrual:     float32(1000),
+		Login:       login,
+		UploadedAt:  processedAt,
+		ProcessedAt: &processedAt,
+	}
+
+	cfg := suite.cfg
+	cfg.Fraud = configs.FraudConfig{
+		ReviewScore: 50,
+		BlockScore:  100,
+		Rules: []configs.FraudRule{
+			{Name: configs.FraudUploadVelocity, Score: 100, Limit: 1, WindowSec: 3600},
+			{Name: configs.FraudNewAccountWithdrawal, Score: 50, WindowSec: 86400},
+		},
+	}
+	ts := httptest.NewServer(server.NewRouter(cfg, suite.logger, m, events.NewBroker(), nil))
+	defer ts.Close()
+
+	m.Users["admin"] = storage.User{Login: "admin", Role: storage.RoleAdmin}
+	adminToken, err := utils.GenerateJWTString(suite.cfg.TokenExpSec, suite.cfg.SecretKey, "admin", storage.RoleAdmin)
+	suite.Require().NoError(err)
+
+	uploadOrder := func(number string) int {
+		resp, errUpload := suite.client.R().
+			SetBody(number).
+			SetHeader("Content-Type", "text/plain").
+			SetHeader("Authorization", "Bearer "+suite.token).
+			Post(ts.URL + "/api/user/orders")
+		suite.Require().NoError(errUpload)
+		return resp.StatusCode()
+	}
+	suite.Require().Equal(202, uploadOrder("79927398713"))
+	// Второй заказ за час превышает лимит и блокируется
+	suite.Require().Equal(403, uploadOrder("4561261212345467"))
+	suite.Require().NotContains(m.Orders, "4561261212345467")
+
+	// Списание с нового аккаунта выполняется, но попадает на проверку
+	resp, err := suite.client.R().
+		SetBody(handlers.Withdraw{Order: "12345678903", Sum: 100}).
+		SetHeader("Authorization", "Bearer "+suite.token).
+		Post(ts.URL + "/api/user/balance/withdraw")
+	suite.Require().NoError(err)
+	suite.Require().Equal(200, resp.StatusCode())
+
+	var reviews []storage.FraudReview
+	resp, err = suite.client.R().
+		SetResult(&reviews).
+		SetHeader("Authorization", "Bearer "+adminToken).
+		Get(ts.URL + "/api/admin/fraud-reviews")
+	suite.Require().NoError(err)
+	suite.Require().Equal(200, resp.StatusCode())
+	suite.Require().Len(reviews, 2)
+	suite.Require().Equal(fraud.ActionOrderUpload, reviews[0].Action)
+	suite.Require().Equal(fraud.DecisionBlock, reviews[0].Decision)
+	suite.Require().Equal(int64(100), reviews[0].Score)
+	suite.Require().Equal(fraud.ActionWithdrawal, reviews[1].Action)
+	suite.Require().Equal(fraud.DecisionReview, reviews[1].Decision)
+
+	resp, err = suite.client.R().
+		SetHeader("Authorization", "Bearer "+suite.token).
+		Get(ts.URL + "/api/admin/fraud-reviews")
+	suite.Require().NoError(err)
+	suite.Require().Equal(403, resp.StatusCode())
+
+	resolveURL := ts.URL + "/api/admin/fraud-reviews/" + strconv.FormatInt(reviews[0].ID, 10)
+	resp, err = suite.client.R().
+		SetBody(handlers.ResolveFraudReview{Status: "pending"}).
+		SetHeader("Authorization", "Bearer "+adminToken).
+		Post(resolveURL)
+	suite.Require().NoError(err)
+	suite.Require().Equal(422, resp.StatusCode())
+
+	resp, err = suite.client.R().
+		SetBody(handlers.ResolveFraudReview{Status: storage.FraudReviewApproved, Comment: "known customer"}).
+		SetHeader("Authorization", "Bearer "+adminToken).
+		Post(resolveURL)
+	suite.Require().NoError(err)
+	suite.Require().Equal(200, resp.StatusCode())
+
+	resp, err = suite.client.R().
+		SetBody(handlers.ResolveFraudReview{Status: storage.FraudReviewRejected}).
+		SetHeader("Authorization", "Bearer "+adminToken).
+		Post(resolveURL)
+	suite.Require().NoError(err)
+	suite.Require().Equal(404, resp.StatusCode())
+
+	// Одобренный заказ принимается при повторной загрузке
+	suite.Require().Equal(202, uploadOrder("4561261212345467"))
+	suite.Require().Contains(m.Orders, "4561261212345467")
+
+	resp, err = suite.client.R().
+		SetResult(&reviews).
+		SetHeader("Authorization", "Bearer "+adminToken).
+		Get(ts.URL + "/api/admin/fraud-reviews?status=approved")
+	suite.Require().NoError(err)
+	suite.Require().Equal(200, resp.StatusCode())
+	suite.Require().Len(reviews, 1)
+	suite.Require().Equal("admin", reviews[0].Operator)
+	suite.Require().Equal("known customer", reviews[0].Comment)
+
+	// Пакетная загрузка проверяется теми же правилами, что и загрузка одного заказа
+	var results []handlers.BatchOrderResult
+	resp, err = suite.client.R().
+		SetResult(&results).
+		SetBody([]string{"4561261212345467", "49927398716"}).
+		SetHeader("Content-Type", "application/json").
+		SetHeader("Authorization", "Bearer "+suite.token).
+		Post(ts.URL + "/api/user/orders/batch")
+	suite.Require().NoError(err)
+	suite.Require().Equal(200, resp.StatusCode())
+	suite.Require().Equal(handlers.BatchAlreadyYours, results[0].Result)
+	suite.Require().Equal(handlers.BatchBlocked, results[1].Result)
+	suite.Require().Equal(handlers.FraudBlocked, results[1].Code)
+	suite.Require().NotContains(m.Orders, "49927398716")
+}
+
+func (suite *ServerTestSuite) TestFraudApprovalInMemory() {
+	m := storage.NewMemory()
+	now := time.Now()
+	m.Users[login] = storage.User{
+		Login:     login,
+		Balance:   float32(1000),
+		Role:      storage.RoleUser,
+		CreatedAt: now,
+	}
+	m.Orders["12345678903"] = storage.Order{
+		Number:      "12345678903",
+		Status:      "PROCESSED",
+		Accrual:     float32(1000),
+		Login:       login,
+		UploadedAt:  now,
+		ProcessedAt: &now,
+	}
+
+	cfg := suite.cfg
+	cfg.Fraud = configs.FraudConfig{
+		ReviewScore: 50,
+		BlockScore:  100,
+		Rules:       []configs.FraudRule{{Name: configs.FraudNewAccountWithdrawal, Score: 100, WindowSec: 86400}},
+	}
+	ts := httptest.NewServer(server.NewRouter(cfg, suite.logger, m, events.NewBroker(), nil))
+	defer ts.Close()
+
+	m.Users["admin"] = storage.User{Login: "admin", Role: storage.RoleAdmin}
+	adminToken, err := utils.GenerateJWTString(suite.cfg.TokenExpSec, suite.cfg.SecretKey, "admin", storage.RoleAdmin)
+	suite.Require().NoError(err)
+
+	withdraw := func(sum float32) int {
+		resp, errWithdraw := suite.client.R().
+			SetBody(handlers.Withdraw{Order: "12345678903", Sum: sum}).
+			SetHeader("Authorization", "Bearer "+suite.token).
+			Post(ts.URL + "/api/user/balance/withdraw")
+		suite.Require().NoError(errWithdraw)
+		return resp.StatusCode()
+	}
+	suite.Require().Equal(403, withdraw(100))
+
+	resp, err := suite.client.R().
+		SetBody(handlers.ResolveFraudReview{Status: storage.FraudReviewApproved}).
+		SetHeader("Authorization", "Bearer "+adminToken).
+		Post(ts.URL + "/api/admin/fraud-reviews/" + strconv.FormatInt(m.FraudReviews[0].ID, 10))
+	suite.Require().NoError(err)
+	suite.Require().Equal(200, resp.StatusCode())
+
+	// Одобрение действует только для проверенной суммы и только один раз
+	suite.Require().Equal(403, withdraw(500))
+	suite.Require().Equal(200, withdraw(100))
+	suite.Require().Equal(403, withdraw(100))
+	suite.Require().NotNil(m.FraudReviews[0].UsedAt)
+
+	// Списание по холду проверяется при capture
+	var hold HoldResponse
+	resp, err = suite.client.R().
+		SetResult(&hold).
+		SetBody(handlers.CreateHold{Order: "12345678903", Sum: 50}).
+		SetHeader("Authorization", "Bearer "+suite.token).
+		Post(ts.URL + "/api/user/balance/holds")
+	suite.Require().NoError(err)
+	suite.Require().Equal(201, resp.StatusCode())
+	resp, err = suite.client.R().
+		SetHeader("Authorization", "Bearer "+suite.token).
+		Post(ts.URL + "/api/user/balance/holds/" + strconv.FormatInt(hold.ID, 10) + "/capture")
+	suite.Require().NoError(err)
+	suite.Require().Equal(403, resp.StatusCode())
+	suite.Require().InDelta(900, m.Users[login].Balance, 0.001)
+}
@@ -0,0 +1,323 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/pisarevaa/gophermart/internal/events"
+	"github.com/pisarevaa/gophermart/internal/fraud"
+	"github.com/pisarevaa/gophermart/internal/storage"
+	"github.com/pisarevaa/gophermart/internal/utils"
+)
+
+type CreateHold struct {
+	Order string  `json:"order" binding:"required"`
+	Sum   float32 `json:"sum"   binding:"required"`
+}
+
+type HoldResponse struct {
+	ID        int64                   `json:"id"         binding:"required"`
+	Order     string                  `json:"order"      binding:"required"`
+	Sum       float32                 `json:"sum"        binding:"required"`
+	Status    string                  `json:"status"     binding:"required"`
+	ExpiresAt utils.FormattedDatetime `json:"expires_at" binding:"required" swaggertype:"string" example:"2024-06-12T08:00:04+03:00"`
+}
+
+func newHoldResponse(hold storage.Hold) HoldResponse {
+	return HoldResponse{
+		ID:        hold.ID,
+		Order:     hold.OrderNumber,
+		Sum:       hold.Amount,
+		Status:    hold.Status,
+		ExpiresAt: utils.FormattedDatetime(hold.ExpiresAt),
+	}
+}
+
+// CreateHold godoc
+//
+//	@Summary		Reserve points for an order
+//	@Description	Reserved points are not available for withdrawal until the hold is captured, voided or expired.
+//	@Description	Withdrawal order is created on capture.
+//	@Description	Order number is checked by the same partner rules as uploaded orders.
+//	@Schemes
+//	@Tags		Balance
+//	@Accept		json
+//	@Produce	json
+//	@Param		request			body	CreateHold	true	"Body"
+//	@Param		Authorization	header	string		true	"Bearer"
+//	@Security	ApiKeyAuth
+//	@Success	201	{object}	HoldResponse	"Response"
+//	@Failure	400	{object}	utils.Problem	"Empty order number"
+//	@Failure	401	{object}	utils.Problem	"Unauthorized"
+//	@Failure	402	{object}	utils.Problem	"not enough balance"
+//	@Failure	422	{object}	utils.Problem	"Unprocessable Entity or order number is incorrect"
+//	@Failure	500	{object}	utils.Problem	"Error"
+//	@Router		/api/user/balance/holds [post]
+func (s *Service) CreateHold(c *gin.Context) {
+	login := c.GetString("Login")
+	var request CreateHold
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.Abort(c, utils.NewProblem(http.StatusUnprocessableEntity, err.Error()))
+		return
+	}
+	if request.Sum <= 0 {
+		utils.Abort(c, utils.NewProblem(http.StatusUnprocessableEntity, "sum must be positive"))
+		return
+	}
+	number, ok := s.validateOrderNumber(c, request.Order)
+	if !ok {
+		return
+	}
+	request.Order = number
+
+	// Заказ списания создается только при подтверждении холда, здесь проверяется лишь,
+	// что номер не занят заказом другого пользователя
+	if order, errOrder := s.Repo.GetOrder(c, request.Order); errOrder == nil && order.Login != login {
+		utils.Abort(c, utils.NewProblem(http.StatusUnprocessableEntity, "order is user's order"))
+		return
+	}
+
+	tx, err := s.Repo.BeginTransaction(c)
+	if err != nil {
+		utils.Abort(c, err)
+		return
+	}
+	defer tx.Rollback(c) //nolint:errcheck // ignore check
+
+	user, err := tx.GetUserWithLock(c, login)
+	if err != nil {
+		utils.Abort(c, utils.NewProblem(http.StatusUnauthorized, "user is not found"))
+		return
+	}
+	available := user.Balance - user.Held
+	if available-request.Sum < 0 {
+		utils.Abort(c, utils.NewProblem(http.StatusPaymentRequired, "not enough balance"))
+		return
+	}
+
+	hold := storage.Hold{
+		Login:       login,
+		OrderNumber: request.Order,
+		Amount:      request.Sum,
+		Status:      storage.HoldActive,
+		ExpiresAt:   time.Now().Add(time.Duration(s.Config.HoldTTLSec) * time.Second),
+	}
+	hold.ID, err = tx.StoreHold(c, hold)
+	if err != nil {
+		utils.Abort(c, err)
+		return
+	}
+
+	err = tx.StoreAuditEvent(c, withAmounts(
+		newAuditEvent(c, login, storage.AuditBalanceHeld, request.Order),
+		available,
+		available-request.Sum,
+	))
+	if err != nil {
+		utils.Abort(c, err)
+		return
+	}
+
+	err = tx.Commit(c)
+	if err != nil {
+		utils.Abort(c, err)
+		return
+	}
+
+	s.Logger.Info("hold ", hold.ID, " of ", hold.Amount, " points is created for order ", hold.OrderNumber)
+
+	c.JSON(http.StatusCreated, newHoldResponse(hold))
+}
+
+// GetHolds godoc
+//
+//	@Summary	Get user's holds
+//	@Schemes
+//	@Tags		Balance
+//	@Produce	json
+//	@Param		Authorization	header	string	true	"Bearer"
+//	@Security	ApiKeyAuth
+//	@Success	200	{object}	[]HoldResponse	"Response"
+//	@Success	204	{object}	storage.Success	"No holds"
+//	@Failure	401	{object}	utils.Problem	"Unauthorized"
+//	@Failure	500	{object}	utils.Problem	"Error"
+//	@Router		/api/user/balance/holds [get]
+func (s *Service) GetHolds(c *gin.Context) {
+	holds, err := s.Repo.GetHolds(c, c.GetString("Login"))
+	if err != nil {
+		utils.Abort(c, err)
+		return
+	}
+	if len(holds) == 0 {
+		c.Status(http.StatusNoContent)
+		return
+	}
+	holdsResponse := make([]HoldResponse, 0, len(holds))
+	for _, hold := range holds {
+		holdsResponse = append(holdsResponse, newHoldResponse(hold))
+	}
+	c.JSON(http.StatusOK, holdsResponse)
+}
+
+// CaptureHold godoc
+//
+//	@Summary		Capture hold
+//	@Description	Withdraws reserved points in favor of the hold's order. Withdrawal limits are applied on capture.
+//	@Schemes
+//	@Tags		Balance
+//	@Produce	json
+//	@Param		id				path	int		true	"Hold ID"
+//	@Param		Authorization	header	string	true	"Bearer"
+//	@Security	ApiKeyAuth
+//	@Success	200	{object}	HoldResponse	"Response"
+//	@Failure	401	{object}	utils.Problem	"Unauthorized"
+//	@Failure	404	{object}	utils.Problem	"Hold is not found"
+//	@Failure	403	{object}	utils.Problem	"Daily or monthly limit is exceeded or blocked by fraud rules"
+//	@Failure	409	{object}	utils.Problem	"Hold is already closed or expired"
+//	@Failure	422	{object}	utils.Problem	"Sum is out of limits or order is uploaded by other user"
+//	@Failure	429	{object}	utils.Problem	"Too many withdrawals per hour"
+//	@Failure	500	{object}	utils.Problem	"Error"
+//	@Router		/api/user/balance/holds/{id}/capture [post]
+func (s *Service) CaptureHold(c *gin.Context) {
+	s.closeHold(c, storage.HoldCaptured)
+}
+
+// VoidHold godoc
+//
+//	@Summary		Void hold
+//	@Description	Releases reserved points without withdrawal.
+//	@Schemes
+//	@Tags		Balance
+//	@Produce	json
+//	@Param		id				path	int		true	"Hold ID"
+//	@Param		Authorization	header	string	true	"Bearer"
+//	@Security	ApiKeyAuth
+//	@Success	200	{object}	HoldResponse	"Response"
+//	@Failure	401	{object}	utils.Problem	"Unauthorized"
+//	@Failure	404	{object}	utils.Problem	"Hold is not found"
+//	@Failure	409	{object}	utils.Problem	"Hold is already closed or expired"
+//	@Failure	500	{object}	utils.Problem	"Error"
+//	@Router		/api/user/balance/holds/{id}/void [post]
+func (s *Service) VoidHold(c *gin.Context) {
+	s.closeHold(c, storage.HoldVoided)
+}
+
+func (s *Service) closeHold(c *gin.Context, status string) {
+	login := c.GetString("Login")
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.Abort(c, utils.NewProblem(http.StatusNotFound, "hold is not found"))
+		return
+	}
+	if status == storage.HoldCaptured && !s.checkHoldFraud(c, login, id) {
+		return
+	}
+
+	tx, err := s.Repo.BeginTransaction(c)
+	if err != nil {
+		utils.Abort(c, err)
+		return
+	}
+	defer tx.Rollback(c) //nolint:errcheck // ignore check
+
+	// Пользователь блокируется раньше холда, в том же порядке, что и в фоновой задаче
+	user, err := tx.GetUserWithLock(c, login)
+	if err != nil {
+		utils.Abort(c, utils.NewProblem(http.StatusUnauthorized, "user is not found"))
+		return
+	}
+	hold, err := tx.GetHoldForUpdate(c, id)
+	if err != nil || hold.Login != login {
+		utils.Abort(c, utils.NewProblem(http.StatusNotFound, "hold is not found"))
+		return
+	}
+	if hold.Status != storage.HoldActive {
+		utils.Abort(c, utils.NewProblem(http.StatusConflict, "hold is already "+hold.Status))
+		return
+	}
+	if !hold.ExpiresAt.After(time.Now()) {
+		utils.Abort(c, utils.NewProblem(http.StatusConflict, "hold is expired"))
+		return
+	}
+	if status == storage.HoldCaptured {
+		violation, errLimits := s.checkWithdrawalLimits(c, tx, login, hold.Amount)
+		if errLimits != nil {
+			utils.Abort(c, errLimits)
+			return
+		}
+		if violation != nil {
+			utils.Abort(c, violation)
+			return
+		}
+		order, errOrder := tx.GetOrderWithLock(c, hold.OrderNumber, login)
+		if errOrder != nil {
+			utils.Abort(c, errOrder)
+			return
+		}
+		if order.Login != login {
+			utils.Abort(c, utils.NewProblem(http.StatusUnprocessableEntity, "order is user's order"))
+			return
+		}
+	}
+
+	err = tx.CloseHold(c, hold, status)
+	if err != nil {
+		utils.Abort(c, err)
+		return
+	}
+	if status == storage.HoldCaptured {
+		err = withdrawPoints(c, tx, user, hold.OrderNumber, hold.Amount, "hold "+strconv.FormatInt(hold.ID, 10))
+	} else {
+		available := user.Balance - user.Held
+		err = tx.StoreAuditEvent(c, withAmounts(
+			newAuditEvent(c, login, storage.AuditHoldReleased, hold.OrderNumber),
+			available,
+			available+hold.Amount,
+		))
+	}
+	if err != nil {
+		utils.Abort(c, err)
+		return
+	}
+
+	err = tx.Commit(c)
+	if err != nil {
+		utils.Abort(c, err)
+		return
+	}
+
+	if status == storage.HoldCaptured {
+		s.Broker.Publish(login, events.BalanceChanged, events.BalanceEvent{
+			Current: user.Balance - hold.Amount,
+		})
+	}
+
+	s.Logger.Info("hold ", hold.ID, " is ", status)
+
+	hold.Status = status
+	c.JSON(http.StatusOK, newHoldResponse(hold))
+}
+
+// checkHoldFraud оценивает списание по холду правилами антифрода до блокировки пользователя.
+// Состояние холда проверяется позже в транзакции, здесь он нужен только для номера заказа и суммы.
+func (s *Service) checkHoldFraud(c *gin.Context, login string, id int64) bool {
+	holds, err := s.Repo.GetHolds(c, login)
+	if err != nil {
+		utils.Abort(c, err)
+		return false
+	}
+	for _, hold := range holds {
+		if hold.ID == id {
+			return s.checkFraud(c, fraud.Event{
+				Action:      fraud.ActionWithdrawal,
+				OrderNumber: hold.OrderNumber,
+				Sum:         hold.Amount,
+			})
+		}
+	}
+	utils.Abort(c, utils.NewProblem(http.StatusNotFound, "hold is not found"))
+	return false
+}
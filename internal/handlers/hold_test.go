@@ -0,0 +1,117 @@
+package handlers_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"strconv"
+	"time"
+
+	server "github.com/pisarevaa/gophermart/internal"
+	"github.com/pisarevaa/gophermart/internal/events"
+	"github.com/pisarevaa/gophermart/internal/handlers"
+	"github.com/pisarevaa/gophermart/internal/storage"
+	"github.com/pisarevaa/gophermart/internal/tasks"
+)
+
+type HoldResponse struct {
+	ID        int64     `json:"id"`
+	Order     string    `json:"order"`
+	Sum       float32   `json:"sum"`
+	Status    string    `json:"status"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func (suite *ServerTestSuite) TestHoldsInMemory() {
+	cfg := suite.cfg
+	cfg.HoldTTLSec = 60
+
+	m := storage.NewMemory()
+	m.Users[login] = storage.User{
+		Login:   login,
+		Balance: float32(500),
+		Role:    storage.RoleUser,
+	}
+	now := time.Now()
+	for _, number := range []string{"12345678903", "79927398713"} {
+		m.Orders[number] = storage.Order{
+			Number:      number,
+			Status:      "PROCESSED",
+			Login:       login,
+			UploadedAt:  now,
+			ProcessedAt: &now,
+		}
+	}
+
+	ts := httptest.NewServer(server.NewRouter(cfg, suite.logger, m, events.NewBroker(), nil))
+	defer ts.Close()
+
+	createHold := func(request handlers.CreateHold) (HoldResponse, int) {
+		var hold HoldResponse
+		resp, err := suite.client.R().
+			SetResult(&hold).
+			SetBody(request).
+			SetHeader("Content-Type", "application/json").
+			SetHeader("Authorization", "Bearer "+suite.token).
+			Post(ts.URL + "/api/user/balance/holds")
+		suite.Require().NoError(err)
+		return hold, resp.StatusCode()
+	}
+	closeHold := func(id int64, action string) int {
+		resp, err := suite.client.R().
+			SetHeader("Authorization", "Bearer "+suite.token).
+			Post(ts.URL + "/api/user/balance/holds/" + strconv.FormatInt(id, 10) + "/" + action)
+		suite.Require().NoError(err)
+		return resp.StatusCode()
+	}
+
+	captured, status := createHold(handlers.CreateHold{Order: "12345678903", Sum: 300})
+	suite.Require().Equal(201, status)
+	suite.Require().Equal(storage.HoldActive, captured.Status)
+	suite.Require().WithinDuration(now.Add(time.Minute), captured.ExpiresAt, 5*time.Second)
+	suite.Require().Equal(float32(300), m.Users[login].Held)
+
+	// Зарезервированные баллы недоступны ни для нового холда, ни для списания
+	_, status = createHold(handlers.CreateHold{Order: "79927398713", Sum: 300})
+	suite.Require().Equal(402, status)
+	resp, err := suite.client.R().
+		SetBody(handlers.Withdraw{Order: "79927398713", Sum: 300}).
+		SetHeader("Content-Type", "application/json").
+		SetHeader("Authorization", "Bearer "+suite.token).
+		Post(ts.URL + "/api/user/balance/withdraw")
+	suite.Require().NoError(err)
+	suite.Require().Equal(402, resp.StatusCode())
+
+	suite.Require().Equal(200, closeHold(captured.ID, "capture"))
+	suite.Require().Equal(409, closeHold(captured.ID, "void"))
+	suite.Require().Equal(float32(200), m.Users[login].Balance)
+	suite.Require().Equal(float32(300), m.Users[login].Withdrawn)
+	suite.Require().Equal(float32(0), m.Users[login].Held)
+	suite.Require().Equal(float32(300), m.Orders["12345678903"].Withdrawn)
+
+	voided, status := createHold(handlers.CreateHold{Order: "79927398713", Sum: 100})
+	suite.Require().Equal(201, status)
+	suite.Require().Equal(200, closeHold(voided.ID, "void"))
+	suite.Require().Equal(float32(200), m.Users[login].Balance)
+	suite.Require().Equal(float32(0), m.Users[login].Held)
+	suite.Require().Equal(404, closeHold(100, "capture"))
+
+	expired, status := createHold(handlers.CreateHold{Order: "79927398713", Sum: 50})
+	suite.Require().Equal(201, status)
+	m.Holds[expired.ID-1].ExpiresAt = now.Add(-time.Second)
+	suite.Require().Equal(409, closeHold(expired.ID, "capture"))
+
+	// Заказ списания по новому номеру создается только при подтверждении холда
+	pending, status := createHold(handlers.CreateHold{Order: " 49927398716\n", Sum: 50})
+	suite.Require().Equal(201, status)
+	suite.Require().Equal("49927398716", pending.Order)
+	suite.Require().NotContains(m.Orders, "49927398716")
+	suite.Require().Equal(200, closeHold(pending.ID, "capture"))
+	suite.Require().Equal(float32(50), m.Orders["49927398716"].Withdrawn)
+	suite.Require().Equal(login, m.Orders["49927398716"].Login)
+
+	task := tasks.NewTask(cfg, suite.logger, m, nil, events.NewBroker())
+	suite.Require().NoError(task.ReleaseExpiredHolds(context.Background()))
+	suite.Require().Equal(storage.HoldExpired, m.Holds[expired.ID-1].Status)
+	suite.Require().Equal(float32(0), m.Users[login].Held)
+	suite.Require().Equal(float32(150), m.Users[login].Balance)
+}
@@ -1,15 +1,40 @@
 package handlers
 
 import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
 
-	"github.com/ShiraazMoollatjie/goluhn"
 	"github.com/gin-gonic/gin"
+	"github.com/pisarevaa/gophermart/internal/fraud"
 	"github.com/pisarevaa/gophermart/internal/storage"
 	"github.com/pisarevaa/gophermart/internal/utils"
 )
 
+type OrderStatusHistoryResponse struct {
+	Status    string                  `json:"status"    binding:"required"`
+	Accrual   float32                 `json:"accrual"   binding:"required"`
+	ChangedAt utils.FormattedDatetime `json:"changedAt" binding:"required" swaggertype:"string" example:"2024-06-12T08:00:04+03:00"`
+}
+
+type OrderDetailResponse struct {
+	OrderReponse
+	History []OrderStatusHistoryResponse `json:"history" binding:"required"`
+}
+
+const (
+	defaultOrdersLimit = 100
+	maxOrdersLimit     = 1000
+)
+
+var orderStatuses = []string{"NEW", "REGISTERED", "PROCESSING", "INVALID", "PROCESSED"} //nolint:gochecknoglobals // read-only list
+
 type OrderReponse struct {
 	Number     string                  `json:"number"     binding:"required"`
 	Status     string                  `json:"status"     binding:"required"`
@@ -19,7 +44,10 @@ type OrderReponse struct {
 
 // AddOrder godoc
 //
-//	@Summary	Add an order
+//	@Summary		Add an order
+//	@Description	Number is trimmed and checked by partner rules, violated rule is returned in code:
+//	@Description	order_number_empty (400), order_number_prefix, order_number_characters,
+//	@Description	order_number_length or order_number_checksum (422).
 //	@Schemes
 //	@Tags		Orders
 //	@Accept		plain
@@ -27,29 +55,24 @@ type OrderReponse struct {
 //	@Param		request			body	string	true	"Body"
 //	@Param		Authorization	header	string	true	"Bearer"
 //	@Security	ApiKeyAuth
-//	@Success	202	{object}	storage.Success	"Response"
-//	@Success	200	{object}	storage.Success	"Order is already added"
-//	@Failure	401	{object}	storage.Error	"Unauthorized"
-//	@Failure	400	{object}	storage.Error	"Error or incorrect data"
-//	@Failure	422	{object}	storage.Error	"incorrect order number"
-//	@Failure	409	{object}	storage.Error	"Order number is already added by other user"
-//	@Failure	500	{object}	storage.Error	"Error"
+//	@Success	202	{object}	storage.Success		"Response"
+//	@Success	200	{object}	storage.Success		"Order is already added"
+//	@Failure	401	{object}	utils.Problem		"Unauthorized"
+//	@Failure	400	{object}	utils.Problem		"Error or empty order number"
+//	@Failure	403	{object}	utils.Problem		"Blocked by fraud rules and sent to review"
+//	@Failure	422	{object}	utils.Problem		"incorrect order number"
+//	@Failure	409	{object}	utils.Problem		"Order number is already added by other user"
+//	@Failure	500	{object}	utils.Problem		"Error"
 //	@Router		/api/user/orders [post]
 func (s *Service) AddOrder(c *gin.Context) {
 	login := c.GetString("Login")
 	body, err := io.ReadAll(c.Request.Body)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		utils.Abort(c, utils.NewProblem(http.StatusBadRequest, err.Error()))
 		return
 	}
-	number := string(body)
-	if number == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "order number is empty string"})
-		return
-	}
-	err = goluhn.Validate(number)
-	if err != nil {
-		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	number, ok := s.validateOrderNumber(c, string(body))
+	if !ok {
 		return
 	}
 
@@ -60,14 +83,18 @@ func (s *Service) AddOrder(c *gin.Context) {
 				Success: true,
 			})
 		} else {
-			c.JSON(http.StatusConflict, gin.H{"error": "order number is already added by other user"})
+			utils.Abort(c, utils.NewProblem(http.StatusConflict, "order number is already added by other user"))
 		}
 		return
 	}
 
+	if !s.checkFraud(c, fraud.Event{Action: fraud.ActionOrderUpload, OrderNumber: number}) {
+		return
+	}
+
 	err = s.Repo.StoreOrder(c, number, login)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		utils.Abort(c, err)
 		return
 	}
 
@@ -84,26 +111,170 @@ func (s *Service) AddOrder(c *gin.Context) {
 //	@Schemes
 //	@Tags		Orders
 //	@Produce	json
+//	@Param		limit			query	int		false	"Page size (default 100, max 1000)"
+//	@Param		cursor			query	string	false	"Cursor from X-Next-Cursor header"
+//	@Param		status			query	string	false	"Comma separated statuses (NEW, REGISTERED, PROCESSING, INVALID, PROCESSED)"
+//	@Param		from			query	string	false	"Uploaded from datetime (RFC3339)"
+//	@Param		to				query	string	false	"Uploaded to datetime (RFC3339)"
+//	@Param		sort			query	string	false	"Sort direction by upload date (asc, desc)"
 //	@Param		Authorization	header	string	true	"Bearer"
 //	@Security	ApiKeyAuth
-//	@Success	200	{object}	OrderReponse	"Response"
+//	@Success	200	{object}	[]OrderReponse	"Response, next page is in Link and X-Next-Cursor headers"
 //	@Success	204	{object}	storage.Success	"No orders"
-//	@Failure	401	{object}	storage.Error	"Unauthorized"
-//	@Failure	500	{object}	storage.Error	"Error"
+//	@Failure	400	{object}	utils.Problem	"Incorrect query parameters"
+//	@Failure	401	{object}	utils.Problem	"Unauthorized"
+//	@Failure	500	{object}	utils.Problem	"Error"
 //	@Router		/api/user/orders [get]
 func (s *Service) GetOrders(c *gin.Context) {
 	login := c.GetString("Login")
 
-	orders, err := s.Repo.GetOrders(c, login, false)
+	filter, err := parseOrdersFilter(c, login)
+	if err != nil {
+		utils.Abort(c, utils.NewProblem(http.StatusBadRequest, err.Error()))
+		return
+	}
+	// Запрашиваем на один заказ больше, чтобы понять, есть ли следующая страница
+	pageSize := filter.Limit
+	filter.Limit++
+
+	orders, err := s.Repo.GetOrdersPage(c, filter)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		utils.Abort(c, err)
 		return
 	}
 
 	if len(orders) == 0 {
-		c.JSON(http.StatusNoContent, []OrderReponse{})
+		c.Status(http.StatusNoContent)
+		return
+	}
+
+	if len(orders) > pageSize {
+		orders = orders[:pageSize]
+		last := orders[len(orders)-1]
+		cursor, errCursor := encodeOrdersCursor(storage.OrdersCursor{UploadedAt: last.UploadedAt, Number: last.Number})
+		if errCursor != nil {
+			utils.Abort(c, errCursor)
+			return
+		}
+		next := *c.Request.URL
+		query := next.Query()
+		query.Set("cursor", cursor)
+		next.RawQuery = query.Encode()
+		c.Header("X-Next-Cursor", cursor)
+		c.Header("Link", fmt.Sprintf("<%s>; rel=\"next\"", next.RequestURI()))
+	}
+
+	c.JSON(http.StatusOK, newOrdersResponse(orders))
+}
+
+// GetOrder godoc
+//
+//	@Summary	Get user's order with status history
+//	@Schemes
+//	@Tags		Orders
+//	@Produce	json
+//	@Param		number			path	string	true	"Order number"
+//	@Param		Authorization	header	string	true	"Bearer"
+//	@Security	ApiKeyAuth
+//	@Success	200	{object}	OrderDetailResponse	"Response"
+//	@Failure	401	{object}	utils.Problem		"Unauthorized"
+//	@Failure	404	{object}	utils.Problem		"Order is not found"
+//	@Failure	500	{object}	utils.Problem		"Error"
+//	@Router		/api/user/orders/{number} [get]
+func (s *Service) GetOrder(c *gin.Context) {
+	login := c.GetString("Login")
+
+	order, err := s.Repo.GetOrder(c, c.Param("number"))
+	if err != nil || order.Login != login {
+		utils.Abort(c, utils.NewProblem(http.StatusNotFound, "order is not found"))
+		return
+	}
+
+	history, err := s.Repo.GetOrderStatusHistory(c, order.Number)
+	if err != nil {
+		utils.Abort(c, err)
+		return
 	}
 
+	orderResponse := OrderDetailResponse{
+		OrderReponse: newOrdersResponse([]storage.Order{order})[0],
+		History:      []OrderStatusHistoryResponse{},
+	}
+	for _, h := range history {
+		orderResponse.History = append(orderResponse.History, OrderStatusHistoryResponse{
+			Status:    h.Status,
+			Accrual:   h.Accrual,
+			ChangedAt: utils.FormattedDatetime(h.CreatedAt),
+		})
+	}
+
+	c.JSON(http.StatusOK, orderResponse)
+}
+
+func parseOrdersFilter(c *gin.Context, login string) (storage.OrdersFilter, error) {
+	filter := storage.OrdersFilter{
+		Login: login,
+		Limit: defaultOrdersLimit,
+	}
+	var err error
+	if limit := c.Query("limit"); limit != "" {
+		filter.Limit, err = strconv.Atoi(limit)
+		if err != nil || filter.Limit <= 0 || filter.Limit > maxOrdersLimit {
+			return filter, fmt.Errorf("limit must be between 1 and %d", maxOrdersLimit)
+		}
+	}
+	if cursor := c.Query("cursor"); cursor != "" {
+		filter.Cursor, err = decodeOrdersCursor(cursor)
+		if err != nil {
+			return filter, errors.New("cursor is wrong")
+		}
+	}
+	for _, statuses := range c.QueryArray("status") {
+		for _, status := range strings.Split(statuses, ",") {
+			if !slices.Contains(orderStatuses, status) {
+				return filter, errors.New("unknown order status " + status)
+			}
+			filter.Statuses = append(filter.Statuses, status)
+		}
+	}
+	if filter.From, err = parseTimeQuery(c, "from"); err != nil {
+		return filter, err
+	}
+	if filter.To, err = parseTimeQuery(c, "to"); err != nil {
+		return filter, err
+	}
+	switch c.DefaultQuery("sort", "asc") {
+	case "asc":
+	case "desc":
+		filter.Descending = true
+	default:
+		return filter, errors.New("sort must be asc or desc")
+	}
+	return filter, nil
+}
+
+func encodeOrdersCursor(cursor storage.OrdersCursor) (string, error) {
+	data, err := json.Marshal(cursor)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodeOrdersCursor(value string) (*storage.OrdersCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	var cursor storage.OrdersCursor
+	err = json.Unmarshal(data, &cursor)
+	if err != nil {
+		return nil, err
+	}
+	return &cursor, nil
+}
+
+func newOrdersResponse(orders []storage.Order) []OrderReponse {
 	var ordersResponse []OrderReponse
 	for _, order := range orders {
 		ordersResponse = append(
@@ -116,6 +287,5 @@ func (s *Service) GetOrders(c *gin.Context) {
 			},
 		)
 	}
-
-	c.JSON(http.StatusOK, ordersResponse)
+	return ordersResponse
 }
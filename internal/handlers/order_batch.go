@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/pisarevaa/gophermart/internal/fraud"
+	"github.com/pisarevaa/gophermart/internal/ordernumber"
+	"github.com/pisarevaa/gophermart/internal/storage"
+	"github.com/pisarevaa/gophermart/internal/utils"
+)
+
+const maxBatchSize = 1000
+
+const (
+	BatchAccepted     = "accepted"
+	BatchAlreadyYours = "already_yours"
+	BatchOtherUser    = "owned_by_other_user"
+	BatchInvalid      = "invalid"
+	BatchBlocked      = "blocked"
+)
+
+type BatchOrderResult struct {
+	Number string `json:"number" binding:"required"`
+	Result string `json:"result" binding:"required"`
+	Error  string `json:"error,omitempty"`
+	Code   string `json:"code,omitempty"`
+}
+
+// AddOrdersBatch godoc
+//
+//	@Summary		Add orders in batch
+//	@Description	Accepts JSON array of order numbers or newline-delimited plain text.
+//	@Description	Invalid numbers have result invalid and code of violated partner rule,
+//	@Description	numbers blocked by fraud rules have result blocked and code fraud_blocked.
+//	@Schemes
+//	@Tags		Orders
+//	@Accept		json,plain
+//	@Produce	json
+//	@Param		request			body	[]string	true	"Body"
+//	@Param		Authorization	header	string		true	"Bearer"
+//	@Security	ApiKeyAuth
+//	@Success	202	{object}	[]BatchOrderResult	"At least one order is accepted"
+//	@Success	200	{object}	[]BatchOrderResult	"No new orders"
+//	@Failure	400	{object}	utils.Problem		"Error or incorrect data"
+//	@Failure	401	{object}	utils.Problem		"Unauthorized"
+//	@Failure	413	{object}	utils.Problem		"Too many orders"
+//	@Failure	500	{object}	utils.Problem		"Error"
+//	@Router		/api/user/orders/batch [post]
+func (s *Service) AddOrdersBatch(c *gin.Context) {
+	login := c.GetString("Login")
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		utils.Abort(c, utils.NewProblem(http.StatusBadRequest, err.Error()))
+		return
+	}
+	numbers, err := parseBatchNumbers(c.ContentType(), body)
+	if err != nil {
+		utils.Abort(c, utils.NewProblem(http.StatusBadRequest, err.Error()))
+		return
+	}
+	if len(numbers) == 0 {
+		utils.Abort(c, utils.NewProblem(http.StatusBadRequest, "order numbers are empty"))
+		return
+	}
+	if len(numbers) > maxBatchSize {
+		utils.Abort(c, utils.NewProblem(http.StatusRequestEntityTooLarge, fmt.Sprintf("batch is limited to %d orders", maxBatchSize)))
+		return
+	}
+
+	blocked, err := s.assessBatchFraud(c, numbers)
+	if err != nil {
+		utils.Abort(c, err)
+		return
+	}
+
+	tx, err := s.Repo.BeginTransaction(c)
+	if err != nil {
+		utils.Abort(c, err)
+		return
+	}
+	defer tx.Rollback(c) //nolint:errcheck // ignore check
+
+	results := make([]BatchOrderResult, 0, len(numbers))
+	accepted := 0
+	for _, number := range numbers {
+		result, errResult := s.addBatchOrder(c, tx, number, login, blocked)
+		if errResult != nil {
+			utils.Abort(c, errResult)
+			return
+		}
+		if result.Result == BatchAccepted {
+			accepted++
+		}
+		results = append(results, result)
+	}
+
+	err = tx.Commit(c)
+	if err != nil {
+		utils.Abort(c, err)
+		return
+	}
+
+	s.Logger.Info("successfully store ", accepted, " of ", len(numbers), " orders login ", login)
+
+	if accepted > 0 {
+		c.JSON(http.StatusAccepted, results)
+		return
+	}
+	c.JSON(http.StatusOK, results)
+}
+
+func (s *Service) addBatchOrder(
+	c *gin.Context,
+	tx storage.Transaction,
+	number string,
+	login string,
+	blocked map[string]bool,
+) (BatchOrderResult, error) {
+	result := BatchOrderResult{Number: number}
+	number, err := s.OrderNumbers.Validate(number)
+	var numberErr *ordernumber.Error
+	if errors.As(err, &numberErr) {
+		result.Result = BatchInvalid
+		result.Error = numberErr.Message
+		result.Code = numberErr.Code
+		return result, nil
+	}
+	if err != nil {
+		return result, err
+	}
+	result.Number = number
+	if blocked[number] {
+		result.Result = BatchBlocked
+		result.Error = "order is blocked and sent to review"
+		result.Code = FraudBlocked
+		return result, nil
+	}
+	inserted, err := tx.InsertOrder(c, number, login)
+	if err != nil {
+		return result, err
+	}
+	if inserted {
+		result.Result = BatchAccepted
+		return result, nil
+	}
+	order, err := tx.GetOrderForUpdate(c, number)
+	if err != nil {
+		return result, err
+	}
+	if order.Login == login {
+		result.Result = BatchAlreadyYours
+	} else {
+		result.Result = BatchOtherUser
+	}
+	return result, nil
+}
+
+// assessBatchFraud оценивает правилами антифрода еще не загруженные номера пакета, каждый с учетом
+// принятых раньше в том же пакете. Возвращает заблокированные номера.
+func (s *Service) assessBatchFraud(c *gin.Context, numbers []string) (map[string]bool, error) {
+	blocked := make(map[string]bool)
+	seen := make(map[string]bool)
+	var pending int64
+	for _, raw := range numbers {
+		number, err := s.OrderNumbers.Validate(raw)
+		if err != nil || seen[number] {
+			continue
+		}
+		seen[number] = true
+		if _, err = s.Repo.GetOrder(c, number); err == nil {
+			continue
+		}
+		isBlocked, err := s.assessFraud(c, fraud.Event{
+			Action:      fraud.ActionOrderUpload,
+			OrderNumber: number,
+			Pending:     pending,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if isBlocked {
+			blocked[number] = true
+			continue
+		}
+		pending++
+	}
+	return blocked, nil
+}
+
+// parseBatchNumbers разбирает JSON массив номеров или текст с номером на каждой строке.
+func parseBatchNumbers(contentType string, body []byte) ([]string, error) {
+	var numbers []string
+	if contentType == "application/json" {
+		err := json.Unmarshal(body, &numbers)
+		if err != nil {
+			return nil, err
+		}
+		for i := range numbers {
+			numbers[i] = strings.TrimSpace(numbers[i])
+		}
+		return numbers, nil
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	for scanner.Scan() {
+		number := strings.TrimSpace(scanner.Text())
+		if number != "" {
+			numbers = append(numbers, number)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return numbers, nil
+}
@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/pisarevaa/gophermart/internal/ordernumber"
+	"github.com/pisarevaa/gophermart/internal/utils"
+)
+
+// validateOrderNumber проверяет номер заказа правилами партнеров и возвращает очищенный номер.
+// Если номер пустой, отправляет 400, если не прошел остальные проверки - 422 с кодом нарушенного правила,
+// в обоих случаях возвращает false.
+func (s *Service) validateOrderNumber(c *gin.Context, raw string) (string, bool) {
+	number, err := s.OrderNumbers.Validate(raw)
+	if err == nil {
+		return number, true
+	}
+	var numberErr *ordernumber.Error
+	if !errors.As(err, &numberErr) {
+		utils.Abort(c, err)
+		return number, false
+	}
+	status := http.StatusUnprocessableEntity
+	if numberErr.Code == ordernumber.CodeEmpty {
+		status = http.StatusBadRequest
+	}
+	utils.Abort(c, utils.NewProblem(status, numberErr.Message).WithCode(numberErr.Code))
+	return number, false
+}
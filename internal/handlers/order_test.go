@@ -1,16 +1,25 @@
 package handlers_test
 
 import (
+	"context"
 	"errors"
 	"net/http/httptest"
 	"time"
 
 	"github.com/ShiraazMoollatjie/goluhn"
+	"github.com/go-resty/resty/v2"
 	"github.com/golang/mock/gomock"
 
 	server "github.com/pisarevaa/gophermart/internal"
+	"github.com/pisarevaa/gophermart/internal/accrual"
+	"github.com/pisarevaa/gophermart/internal/events"
+	"github.com/pisarevaa/gophermart/internal/fakeaccrual"
+	"github.com/pisarevaa/gophermart/internal/handlers"
 	mock "github.com/pisarevaa/gophermart/internal/mocks"
+	"github.com/pisarevaa/gophermart/internal/ordernumber"
 	"github.com/pisarevaa/gophermart/internal/storage"
+	"github.com/pisarevaa/gophermart/internal/tasks"
+	"github.com/pisarevaa/gophermart/internal/utils"
 )
 
 type OrderReponse struct {
@@ -20,6 +29,15 @@ type OrderReponse struct {
 	UploadedAt time.Time `json:"uploadedAt" binding:"required"`
 }
 
+type OrderDetailResponse struct {
+	OrderReponse
+	History []struct {
+		Status    string    `json:"status"    binding:"required"`
+		Accrual   float32   `json:"accrual"   binding:"required"`
+		ChangedAt time.Time `json:"changedAt" binding:"required"`
+	} `json:"history" binding:"required"`
+}
+
 func (suite *ServerTestSuite) TestAddOrderMockDB() {
 	ctrl := gomock.NewController(suite.T())
 	defer ctrl.Finish()
@@ -30,13 +48,21 @@ func (suite *ServerTestSuite) TestAddOrderMockDB() {
 		GetOrder(gomock.Any(), gomock.Any()).
 		Return(storage.Order{}, errors.New("not found"))
 
+	m.EXPECT().
+		GetUploadedOrdersCount(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(int64(0), nil)
+
+	m.EXPECT().
+		GetOrdersCountByStatus(gomock.Any(), gomock.Any()).
+		Return(map[string]int64{}, nil)
+
 	m.EXPECT().
 		StoreOrder(gomock.Any(), gomock.Any(), gomock.Any()).
 		Return(nil)
 
 	number := goluhn.Generate(9)
 
-	ts := httptest.NewServer(server.NewRouter(suite.cfg, suite.logger, m))
+	ts := httptest.NewServer(server.NewRouter(suite.cfg, suite.logger, m, events.NewBroker(), nil))
 	defer ts.Close()
 
 	resp, err := suite.client.R().
@@ -65,10 +91,10 @@ func (suite *ServerTestSuite) TestGetOrdersMockDB() {
 	}}
 
 	m.EXPECT().
-		GetOrders(gomock.Any(), gomock.Any(), gomock.Any()).
+		GetOrdersPage(gomock.Any(), gomock.Any()).
 		Return(orders, nil)
 
-	ts := httptest.NewServer(server.NewRouter(suite.cfg, suite.logger, m))
+	ts := httptest.NewServer(server.NewRouter(suite.cfg, suite.logger, m, events.NewBroker(), nil))
 	defer ts.Close()
 
 	var ordersResponse []OrderReponse
@@ -87,7 +113,7 @@ func (suite *ServerTestSuite) TestAddAndGetOrdersInMemory() {
 
 	number := goluhn.Generate(9)
 
-	ts := httptest.NewServer(server.NewRouter(suite.cfg, suite.logger, m))
+	ts := httptest.NewServer(server.NewRouter(suite.cfg, suite.logger, m, events.NewBroker(), nil))
 	defer ts.Close()
 
 	resp, err := suite.client.R().
@@ -108,3 +134,252 @@ func (suite *ServerTestSuite) TestAddAndGetOrdersInMemory() {
 	suite.Require().Equal(200, resp.StatusCode())
 	suite.Require().Len(ordersResponse, 1)
 }
+
+func (suite *ServerTestSuite) TestGetOrdersPaginationInMemory() {
+	m := storage.NewMemory()
+
+	now := time.Now()
+	for i, status := range []string{"NEW", "PROCESSED", "INVALID"} {
+		number := goluhn.Generate(9)
+		m.Orders[number] = storage.Order{
+			Number:     number,
+			Status:     status,
+			Login:      login,
+			UploadedAt: now.Add(time.Duration(i) * time.Minute),
+		}
+	}
+
+	ts := httptest.NewServer(server.NewRouter(suite.cfg, suite.logger, m, events.NewBroker(), nil))
+	defer ts.Close()
+
+	var ordersResponse []OrderReponse
+	resp, err := suite.client.R().
+		SetResult(&ordersResponse).
+		SetQueryParam("limit", "2").
+		SetHeader("Authorization", "Bearer "+suite.token).
+		Get(ts.URL + "/api/user/orders")
+	suite.Require().NoError(err)
+	suite.Require().Equal(200, resp.StatusCode())
+	suite.Require().Len(ordersResponse, 2)
+	suite.Require().Equal("NEW", ordersResponse[0].Status)
+	cursor := resp.Header().Get("X-Next-Cursor")
+	suite.Require().NotEmpty(cursor)
+	suite.Require().Contains(resp.Header().Get("Link"), `rel="next"`)
+
+	resp, err = suite.client.R().
+		SetResult(&ordersResponse).
+		SetQueryParam("limit", "2").
+		SetQueryParam("cursor", cursor).
+		SetHeader("Authorization", "Bearer "+suite.token).
+		Get(ts.URL + "/api/user/orders")
+	suite.Require().NoError(err)
+	suite.Require().Equal(200, resp.StatusCode())
+	suite.Require().Len(ordersResponse, 1)
+	suite.Require().Equal("INVALID", ordersResponse[0].Status)
+	suite.Require().Empty(resp.Header().Get("X-Next-Cursor"))
+
+	resp, err = suite.client.R().
+		SetResult(&ordersResponse).
+		SetQueryParam("status", "NEW,PROCESSED").
+		SetQueryParam("sort", "desc").
+		SetHeader("Authorization", "Bearer "+suite.token).
+		Get(ts.URL + "/api/user/orders")
+	suite.Require().NoError(err)
+	suite.Require().Equal(200, resp.StatusCode())
+	suite.Require().Len(ordersResponse, 2)
+	suite.Require().Equal("PROCESSED", ordersResponse[0].Status)
+
+	for number, order := range m.Orders {
+		if order.Status == "NEW" {
+			order.Status = "REGISTERED"
+			m.Orders[number] = order
+		}
+	}
+	resp, err = suite.client.R().
+		SetResult(&ordersResponse).
+		SetQueryParam("status", "REGISTERED").
+		SetHeader("Authorization", "Bearer "+suite.token).
+		Get(ts.URL + "/api/user/orders")
+	suite.Require().NoError(err)
+	suite.Require().Equal(200, resp.StatusCode())
+	suite.Require().Len(ordersResponse, 1)
+	suite.Require().Equal("REGISTERED", ordersResponse[0].Status)
+
+	resp, err = suite.client.R().
+		SetQueryParam("limit", "0").
+		SetHeader("Authorization", "Bearer "+suite.token).
+		Get(ts.URL + "/api/user/orders")
+	suite.Require().NoError(err)
+	suite.Require().Equal(400, resp.StatusCode())
+}
+
+func (suite *ServerTestSuite) TestGetOrderWithHistoryInMemory() {
+	m := storage.NewMemory()
+
+	number := goluhn.Generate(9)
+	err := m.StoreOrder(context.Background(), number, login)
+	suite.Require().NoError(err)
+	err = m.UpdateOrderStatus(context.Background(), storage.OrderStatus{Number: number, Status: "PROCESSING"})
+	suite.Require().NoError(err)
+	err = m.UpdateOrderStatus(context.Background(), storage.OrderStatus{Number: number, Status: "PROCESSED", Accrual: 500})
+	suite.Require().NoError(err)
+
+	otherNumber := goluhn.Generate(9)
+	err = m.StoreOrder(context.Background(), otherNumber, "other")
+	suite.Require().NoError(err)
+
+	ts := httptest.NewServer(server.NewRouter(suite.cfg, suite.logger, m, events.NewBroker(), nil))
+	defer ts.Close()
+
+	var orderResponse OrderDetailResponse
+	resp, err := suite.client.R().
+		SetResult(&orderResponse).
+		SetHeader("Authorization", "Bearer "+suite.token).
+		Get(ts.URL + "/api/user/orders/" + number)
+	suite.Require().NoError(err)
+	suite.Require().Equal(200, resp.StatusCode())
+	suite.Require().Equal("PROCESSED", orderResponse.Status)
+	suite.Require().Len(orderResponse.History, 3)
+	suite.Require().Equal("NEW", orderResponse.History[0].Status)
+	suite.Require().Equal(float32(500), orderResponse.History[2].Accrual)
+
+	resp, err = suite.client.R().
+		SetHeader("Authorization", "Bearer "+suite.token).
+		Get(ts.URL + "/api/user/orders/" + otherNumber)
+	suite.Require().NoError(err)
+	suite.Require().Equal(404, resp.StatusCode())
+}
+
+func (suite *ServerTestSuite) TestUpdateOrderStatusesRecordsHistory() {
+	fake := fakeaccrual.NewServer()
+	accrualServer := httptest.NewServer(fake.Handler())
+	defer accrualServer.Close()
+	router := accrual.NewRouter(accrual.Provider{
+		Name:   accrual.DefaultProvider,
+		Client: accrual.NewHTTPClient(accrualServer.URL, resty.New(), suite.logger),
+	})
+
+	ctx := context.Background()
+	m := storage.NewMemory()
+	m.Users[login] = storage.User{Login: login, Role: storage.RoleUser}
+	suite.Require().NoError(m.StoreOrder(ctx, "12345678903", login))
+
+	task := tasks.NewTask(suite.cfg, suite.logger, m, router, events.NewBroker())
+	// Промежуточный статус попадает в историю один раз, повторный опрос без изменений ее не дополняет
+	fake.SetOrder("12345678903", "PROCESSING", 0)
+	suite.Require().NoError(task.UpdateOrderStatuses(ctx))
+	suite.Require().NoError(task.UpdateOrderStatuses(ctx))
+	fake.SetOrder("12345678903", "PROCESSED", 100)
+	suite.Require().NoError(task.UpdateOrderStatuses(ctx))
+
+	history, err := m.GetOrderStatusHistory(ctx, "12345678903")
+	suite.Require().NoError(err)
+	var statuses []string
+	for _, h := range history {
+		statuses = append(statuses, h.Status)
+	}
+	suite.Require().Equal([]string{"NEW", "PROCESSING", "PROCESSED"}, statuses)
+}
+
+func (suite *ServerTestSuite) TestAddOrdersBatchInMemory() {
+	m := storage.NewMemory()
+
+	yours := goluhn.Generate(9)
+	err := m.StoreOrder(context.Background(), yours, login)
+	suite.Require().NoError(err)
+	other := goluhn.Generate(9)
+	err = m.StoreOrder(context.Background(), other, "other")
+	suite.Require().NoError(err)
+	accepted := goluhn.Generate(9)
+
+	ts := httptest.NewServer(server.NewRouter(suite.cfg, suite.logger, m, events.NewBroker(), nil))
+	defer ts.Close()
+
+	var results []handlers.BatchOrderResult
+	resp, err := suite.client.R().
+		SetResult(&results).
+		SetBody(accepted+"\n"+yours+"\n\n"+other+"\n12345\n").
+		SetHeader("Content-Type", "text/plain").
+		SetHeader("Authorization", "Bearer "+suite.token).
+		Post(ts.URL + "/api/user/orders/batch")
+	suite.Require().NoError(err)
+	suite.Require().Equal(202, resp.StatusCode())
+	suite.Require().Len(results, 4)
+	suite.Require().Equal(handlers.BatchAccepted, results[0].Result)
+	suite.Require().Equal(handlers.BatchAlreadyYours, results[1].Result)
+	suite.Require().Equal(handlers.BatchOtherUser, results[2].Result)
+	suite.Require().Equal(handlers.BatchInvalid, results[3].Result)
+	suite.Require().Equal(login, m.Orders[accepted].Login)
+
+	resp, err = suite.client.R().
+		SetResult(&results).
+		SetBody([]string{accepted}).
+		SetHeader("Content-Type", "application/json").
+		SetHeader("Authorization", "Bearer "+suite.token).
+		Post(ts.URL + "/api/user/orders/batch")
+	suite.Require().NoError(err)
+	suite.Require().Equal(200, resp.StatusCode())
+	suite.Require().Equal(handlers.BatchAlreadyYours, results[0].Result)
+}
+
+func (suite *ServerTestSuite) TestAddOrderValidationInMemory() {
+	m := storage.NewMemory()
+
+	ts := httptest.NewServer(server.NewRouter(suite.cfg, suite.logger, m, events.NewBroker(), nil))
+	defer ts.Close()
+
+	steps := []struct {
+		body   string
+		status int
+		code   string
+	}{
+		{body: " 12345678903\n", status: 202},
+		{body: "\n", status: 400, code: ordernumber.CodeEmpty},
+		{body: "1234-5678903", status: 422, code: ordernumber.CodeCharacters},
+		{body: "1", status: 422, code: ordernumber.CodeLength},
+		{body: "12345678900", status: 422, code: ordernumber.CodeChecksum},
+	}
+	for _, step := range steps {
+		var numberError utils.Problem
+		resp, err := suite.client.R().
+			SetBody(step.body).
+			SetError(&numberError).
+			SetHeader("Content-Type", "text/plain").
+			SetHeader("Authorization", "Bearer "+suite.token).
+			Post(ts.URL + "/api/user/orders")
+		suite.Require().NoError(err)
+		suite.Require().Equal(step.status, resp.StatusCode(), step.body)
+		suite.Require().Equal(step.code, numberError.Code, step.body)
+	}
+	suite.Require().Contains(m.Orders, "12345678903")
+
+	var results []handlers.BatchOrderResult
+	resp, err := suite.client.R().
+		SetResult(&results).
+		SetBody([]string{"79927398713", "12345678900"}).
+		SetHeader("Content-Type", "application/json").
+		SetHeader("Authorization", "Bearer "+suite.token).
+		Post(ts.URL + "/api/user/orders/batch")
+	suite.Require().NoError(err)
+	suite.Require().Equal(202, resp.StatusCode())
+	suite.Require().Equal(handlers.BatchAccepted, results[0].Result)
+	suite.Require().Equal(handlers.BatchInvalid, results[1].Result)
+	suite.Require().Equal(ordernumber.CodeChecksum, results[1].Code)
+
+	resp, err = suite.client.R().
+		SetBody(handlers.Withdraw{Order: "12345678900", Sum: 10}).
+		SetHeader("Authorization", "Bearer "+suite.token).
+		Post(ts.URL + "/api/user/balance/withdraw")
+	suite.Require().NoError(err)
+	suite.Require().Equal(422, resp.StatusCode())
+
+	var numberError utils.Problem
+	resp, err = suite.client.R().
+		SetBody(handlers.CreateHold{Order: "12345678900", Sum: 10}).
+		SetError(&numberError).
+		SetHeader("Authorization", "Bearer "+suite.token).
+		Post(ts.URL + "/api/user/balance/holds")
+	suite.Require().NoError(err)
+	suite.Require().Equal(422, resp.StatusCode())
+	suite.Require().Equal(ordernumber.CodeChecksum, numberError.Code)
+}
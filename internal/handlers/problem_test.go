@@ -0,0 +1,88 @@
+package handlers_test
+
+import (
+	"errors"
+	"net/http/httptest"
+
+	"github.com/golang/mock/gomock"
+
+	server "github.com/pisarevaa/gophermart/internal"
+	"github.com/pisarevaa/gophermart/internal/events"
+	mock "github.com/pisarevaa/gophermart/internal/mocks"
+	"github.com/pisarevaa/gophermart/internal/storage"
+	"github.com/pisarevaa/gophermart/internal/utils"
+)
+
+func (suite *ServerTestSuite) TestProblemResponsesMockDB() {
+	ctrl := gomock.NewController(suite.T())
+	defer ctrl.Finish()
+
+	m := mock.NewMockStorage(ctrl)
+
+	m.EXPECT().
+		GetUser(gomock.Any(), gomock.Any()).
+		Return(storage.User{Login: login}, nil)
+
+	m.EXPECT().
+		GetExpiringPointLots(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil, errors.New("failed to connect to host=10.0.0.5 user=gophermart"))
+
+	ts := httptest.NewServer(server.NewRouter(suite.cfg, suite.logger, m, events.NewBroker(), nil))
+	defer ts.Close()
+
+	// Подробности внутренней ошибки не отдаются клиенту, вместо них возвращается идентификатор запроса
+	var problem utils.Problem
+	resp, err := suite.client.R().
+		SetError(&problem).
+		SetHeader(utils.RequestIDHeader, "problem-request").
+		SetHeader("Authorization", "Bearer "+suite.token).
+		Get(ts.URL + "/api/user/balance")
+	suite.Require().NoError(err)
+	suite.Require().Equal(500, resp.StatusCode())
+	suite.Require().Equal(utils.ProblemContentType, resp.Header().Get("Content-Type"))
+	suite.Require().Equal(utils.CodeInternal, problem.Code)
+	suite.Require().Equal(500, problem.Status)
+	suite.Require().Equal("problem-request", problem.RequestID)
+	suite.Require().Equal("/api/user/balance", problem.Instance)
+	suite.Require().NotContains(problem.Detail, "10.0.0.5")
+
+	problem = utils.Problem{}
+	resp, err = suite.client.R().
+		SetError(&problem).
+		Get(ts.URL + "/api/user/balance")
+	suite.Require().NoError(err)
+	suite.Require().Equal(401, resp.StatusCode())
+	suite.Require().Equal(utils.ProblemContentType, resp.Header().Get("Content-Type"))
+	suite.Require().Equal(utils.CodeUnauthorized, problem.Code)
+	suite.Require().Equal("Unauthorized", problem.Title)
+	suite.Require().Equal("Authorization token is not set", problem.Detail)
+	suite.Require().NotEmpty(problem.RequestID)
+}
+
+func (suite *ServerTestSuite) TestRoleAuthErrorsMockDB() {
+	ctrl := gomock.NewController(suite.T())
+	defer ctrl.Finish()
+
+	m := mock.NewMockStorage(ctrl)
+
+	gomock.InOrder(
+		m.EXPECT().
+			GetUser(gomock.Any(), gomock.Any()).
+			Return(storage.User{}, storage.ErrUserNotFound),
+		m.EXPECT().
+			GetUser(gomock.Any(), gomock.Any()).
+			Return(storage.User{}, errors.New("connection refused")),
+	)
+
+	ts := httptest.NewServer(server.NewRouter(suite.cfg, suite.logger, m, events.NewBroker(), nil))
+	defer ts.Close()
+
+	// Только отсутствующий пользователь означает 401, ошибка хранилища отдается как 500
+	for _, status := range []int{401, 500} {
+		resp, err := suite.client.R().
+			SetHeader("Authorization", "Bearer "+suite.token).
+			Get(ts.URL + "/api/admin/audit")
+		suite.Require().NoError(err)
+		suite.Require().Equal(status, resp.StatusCode())
+	}
+}
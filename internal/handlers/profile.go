@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/pisarevaa/gophermart/internal/loyalty"
+	"github.com/pisarevaa/gophermart/internal/utils"
+)
+
+type UserProfile struct {
+	Login        string  `json:"login"                  binding:"required"`
+	Tier         string  `json:"tier"                   binding:"required"`
+	Multiplier   float32 `json:"multiplier"             binding:"required"`
+	AccrualTotal float32 `json:"accrual_total"          binding:"required"`
+	NextTier     string  `json:"next_tier,omitempty"`
+	ToNextTier   float32 `json:"to_next_tier,omitempty"`
+}
+
+// GetProfile godoc
+//
+//	@Summary		Get user's profile
+//	@Description	Returns loyalty tier, its accrual multiplier, accrual total for the last 12 months
+//	@Description	and points left to the next tier. Tiers are recalculated by a scheduled job.
+//	@Schemes
+//	@Tags		User
+//	@Produce	json
+//	@Param		Authorization	header	string	true	"Bearer"
+//	@Security	ApiKeyAuth
+//	@Success	200	{object}	UserProfile		"Response"
+//	@Failure	401	{object}	utils.Problem	"Unauthorized"
+//	@Failure	500	{object}	utils.Problem	"Error"
+//	@Router		/api/user/profile [get]
+func (s *Service) GetProfile(c *gin.Context) {
+	login := c.GetString("Login")
+	user, err := s.Repo.GetUser(c, login)
+	if err != nil {
+		utils.Abort(c, utils.NewProblem(http.StatusUnauthorized, "user is not found"))
+		return
+	}
+	total, err := s.Repo.GetAccrualTotal(c, login, loyalty.WindowStart(time.Now()))
+	if err != nil {
+		utils.Abort(c, err)
+		return
+	}
+
+	tiers := loyalty.NewTiers(s.Config.LoyaltyTiers)
+	tier := tiers.ByName(user.Tier)
+	profile := UserProfile{
+		Login:        user.Login,
+		Tier:         tier.Name,
+		Multiplier:   tier.Multiplier,
+		AccrualTotal: total,
+	}
+	if next, ok := tiers.Next(tier.Name); ok {
+		profile.NextTier = next.Name
+		profile.ToNextTier = max(next.Threshold-total, 0)
+	}
+	c.JSON(http.StatusOK, profile)
+}
@@ -0,0 +1,72 @@
+package handlers_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"time"
+
+	server "github.com/pisarevaa/gophermart/internal"
+	"github.com/pisarevaa/gophermart/internal/events"
+	"github.com/pisarevaa/gophermart/internal/handlers"
+	"github.com/pisarevaa/gophermart/internal/storage"
+	"github.com/pisarevaa/gophermart/internal/tasks"
+)
+
+func (suite *ServerTestSuite) TestLoyaltyTiersInMemory() {
+	ctx := context.Background()
+	m := storage.NewMemory()
+	m.Users[login] = storage.User{
+		Login: login,
+		Role:  storage.RoleUser,
+	}
+	suite.Require().NoError(m.StoreOrder(ctx, "12345678903", login))
+	suite.Require().NoError(m.StoreBalanceMovement(ctx, storage.BalanceMovement{
+		Login:  login,
+		Kind:   storage.MovementAccrual,
+		Amount: 1200,
+	}))
+
+	task := tasks.NewTask(suite.cfg, suite.logger, m, nil, events.NewBroker())
+	suite.Require().NoError(task.RecalculateTiers(ctx))
+	suite.Require().Equal("silver", m.Users[login].Tier)
+
+	ts := httptest.NewServer(server.NewRouter(suite.cfg, suite.logger, m, events.NewBroker(), nil))
+	defer ts.Close()
+
+	var profile handlers.UserProfile
+	resp, err := suite.client.R().
+		SetResult(&profile).
+		SetHeader("Authorization", "Bearer "+suite.token).
+		Get(ts.URL + "/api/user/profile")
+	suite.Require().NoError(err)
+	suite.Require().Equal(200, resp.StatusCode())
+	suite.Require().Equal(handlers.UserProfile{
+		Login:        login,
+		Tier:         "silver",
+		Multiplier:   1.1,
+		AccrualTotal: 1200,
+		NextTier:     "gold",
+		ToNextTier:   3800,
+	}, profile)
+
+	// Начисление умножается на множитель уровня, бонус записывается отдельным движением
+	tx, err := m.BeginTransaction(ctx)
+	suite.Require().NoError(err)
+	status := storage.OrderStatus{Number: "12345678903", Status: "PROCESSED", Accrual: 100}
+	balance, err := tasks.ApplyOrderStatus(ctx, tx, login, status, tasks.NewAccrualPolicy(suite.cfg, time.Now()), "")
+	suite.Require().NoError(err)
+	suite.Require().InDelta(110, balance, 0.001)
+	suite.Require().InDelta(10, m.Orders["12345678903"].TierBonus, 0.001)
+	suite.Require().Equal(storage.MovementTierBonus, m.Movements[len(m.Movements)-1].Kind)
+
+	// Отмена начисления списывает и бонус
+	order := m.Orders["12345678903"]
+	balance, err = tasks.ReverseOrderAccrual(ctx, tx, order, storage.OrderStatus{
+		Number:  order.Number,
+		Status:  "PROCESSED",
+		Accrual: 50,
+	}, "support", "returned goods", "")
+	suite.Require().NoError(err)
+	suite.Require().InDelta(55, balance, 0.001)
+	suite.Require().InDelta(5, m.Orders["12345678903"].TierBonus, 0.001)
+}
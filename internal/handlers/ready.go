@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	ReadyOK          = "ok"
+	ReadyUnavailable = "unavailable"
+)
+
+type Readiness struct {
+	Status    string            `json:"status"    binding:"required"`
+	Database  string            `json:"database"  binding:"required"`
+	Accrual   string            `json:"accrual"   binding:"required"`
+	Providers map[string]string `json:"providers"`
+}
+
+// Ready godoc
+//
+//	@Summary		Readiness check
+//	@Description	Checks database connection and reports circuit breaker state (closed, open, half-open) of each accrual system.
+//	@Description	Accrual is open only when all accrual systems are unavailable.
+//	@Schemes
+//	@Tags		Health
+//	@Produce	json
+//	@Success	200	{object}	Readiness	"Service is ready"
+//	@Failure	503	{object}	Readiness	"Database is unavailable"
+//	@Router		/api/ready [get]
+func (s *Service) Ready(c *gin.Context) {
+	readiness := Readiness{
+		Status:   ReadyOK,
+		Database: ReadyOK,
+	}
+	if s.Accrual != nil {
+		readiness.Accrual = s.Accrual.State()
+		readiness.Providers = s.Accrual.States()
+	}
+	// Открытый выключатель не делает сервис неготовым: пользователи могут работать с уже
+	// рассчитанными заказами, а опрос возобновится после восстановления системы расчета
+	if err := s.Repo.Ping(c); err != nil {
+		s.Logger.Error("database is unavailable: ", err)
+		readiness.Status = ReadyUnavailable
+		readiness.Database = ReadyUnavailable
+		c.JSON(http.StatusServiceUnavailable, readiness)
+		return
+	}
+	c.JSON(http.StatusOK, readiness)
+}
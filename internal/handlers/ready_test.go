@@ -0,0 +1,47 @@
+package handlers_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+
+	server "github.com/pisarevaa/gophermart/internal"
+	"github.com/pisarevaa/gophermart/internal/accrual"
+	"github.com/pisarevaa/gophermart/internal/events"
+	"github.com/pisarevaa/gophermart/internal/fakeaccrual"
+	"github.com/pisarevaa/gophermart/internal/handlers"
+	"github.com/pisarevaa/gophermart/internal/storage"
+)
+
+func (suite *ServerTestSuite) TestReadyInMemory() {
+	fake := fakeaccrual.NewServer()
+	accrualServer := httptest.NewServer(fake.Handler())
+	defer accrualServer.Close()
+	breaker := accrual.NewCircuitBreaker(
+		accrual.NewHTTPClient(accrualServer.URL, resty.New(), suite.logger), suite.logger, 1, time.Minute,
+	)
+
+	accrualRouter := accrual.NewRouter(accrual.Provider{Name: accrual.DefaultProvider, Client: breaker})
+
+	ts := httptest.NewServer(server.NewRouter(suite.cfg, suite.logger, storage.NewMemory(), events.NewBroker(), accrualRouter))
+	defer ts.Close()
+
+	var readiness handlers.Readiness
+	resp, err := suite.client.R().SetResult(&readiness).Get(ts.URL + "/api/ready")
+	suite.Require().NoError(err)
+	suite.Require().Equal(200, resp.StatusCode())
+	suite.Require().Equal(handlers.ReadyOK, readiness.Database)
+	suite.Require().Equal(accrual.StateClosed, readiness.Accrual)
+
+	fake.SetInternalErrors(1)
+	_, err = breaker.GetOrderStatus(context.Background(), "12345678903")
+	suite.Require().Error(err)
+
+	resp, err = suite.client.R().SetResult(&readiness).Get(ts.URL + "/api/ready")
+	suite.Require().NoError(err)
+	suite.Require().Equal(200, resp.StatusCode())
+	suite.Require().Equal(accrual.StateOpen, readiness.Accrual)
+	suite.Require().Equal(accrual.StateOpen, readiness.Providers[accrual.DefaultProvider])
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/pisarevaa/gophermart/internal/storage"
+	"github.com/pisarevaa/gophermart/internal/utils"
+)
+
+type ReferralResponse struct {
+	Referee   string                  `json:"referee"    binding:"required"`
+	Status    string                  `json:"status"     binding:"required"`
+	Bonus     float32                 `json:"bonus"      binding:"required"`
+	CreatedAt utils.FormattedDatetime `json:"created_at" binding:"required" swaggertype:"string" example:"2024-06-12T08:00:04+03:00"`
+}
+
+type ReferralsResponse struct {
+	Code      string             `json:"code"      binding:"required"`
+	Referrals []ReferralResponse `json:"referrals" binding:"required"`
+}
+
+// storeReferral сохраняет приглашение нового пользователя. Приглашение с того же IP-адреса,
+// с которого регистрировался пригласивший, или сверх лимита приглашений отклоняется без
+// ошибки для нового пользователя, а пригласивший с совпадающим адресом помечается для проверки.
+func (s *Service) storeReferral(c *gin.Context, tx storage.Transaction, referrer storage.User, referee string) error {
+	referral := storage.Referral{
+		Referrer: referrer.Login,
+		Referee:  referee,
+		Status:   storage.ReferralPending,
+	}
+	if referrer.RegistrationIP != "" && referrer.RegistrationIP == c.ClientIP() {
+		referral.Status = storage.ReferralRejected
+		referral.RejectReason = "same registration IP"
+		err := tx.FlagUser(c, referrer.Login, fmt.Sprintf("referral %s from the same IP", referee))
+		if err != nil {
+			return err
+		}
+	} else if s.Config.ReferralMaxCount > 0 {
+		// Пригласивший блокируется, чтобы параллельные регистрации не превысили лимит приглашений
+		_, err := tx.GetUserWithLock(c, referrer.Login)
+		if err != nil {
+			return err
+		}
+		count, err := tx.GetReferralsCount(c, referrer.Login)
+		if err != nil {
+			return err
+		}
+		if count >= s.Config.ReferralMaxCount {
+			referral.Status = storage.ReferralRejected
+			referral.RejectReason = "referral limit is reached"
+		}
+	}
+	if referral.Status == storage.ReferralRejected {
+		s.Logger.Info("referral of ", referee, " by ", referrer.Login, " is rejected: ", referral.RejectReason)
+	}
+	return tx.StoreReferral(c, referral)
+}
+
+// GetReferrals godoc
+//
+//	@Summary		Get user's referral code and invited users
+//	@Description	Bonus is credited to the user when the first order of the invited user is processed.
+//	@Description	Status is pending, rewarded or rejected (failed fraud checks).
+//	@Schemes
+//	@Tags		Referrals
+//	@Produce	json
+//	@Param		Authorization	header	string	true	"Bearer"
+//	@Security	ApiKeyAuth
+//	@Success	200	{object}	ReferralsResponse	"Response"
+//	@Failure	401	{object}	utils.Problem		"Unauthorized"
+//	@Failure	500	{object}	utils.Problem		"Error"
+//	@Router		/api/user/referrals [get]
+func (s *Service) GetReferrals(c *gin.Context) {
+	login := c.GetString("Login")
+	user, err := s.Repo.GetUser(c, login)
+	if err != nil {
+		utils.Abort(c, err)
+		return
+	}
+	referrals, err := s.Repo.GetReferrals(c, login)
+	if err != nil {
+		utils.Abort(c, err)
+		return
+	}
+	response := ReferralsResponse{
+		Code:      user.ReferralCode,
+		Referrals: []ReferralResponse{},
+	}
+	for _, referral := range referrals {
+		response.Referrals = append(response.Referrals, ReferralResponse{
+			Referee:   referral.Referee,
+			Status:    referral.Status,
+			Bonus:     referral.ReferrerBonus,
+			CreatedAt: utils.FormattedDatetime(referral.CreatedAt),
+		})
+	}
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,114 @@
+package handlers_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	server "github.com/pisarevaa/gophermart/internal"
+	"github.com/pisarevaa/gophermart/internal/events"
+	"github.com/pisarevaa/gophermart/internal/storage"
+	"github.com/pisarevaa/gophermart/internal/tasks"
+	"github.com/pisarevaa/gophermart/internal/utils"
+)
+
+type referralsResponse struct {
+	Code      string `json:"code"`
+	Referrals []struct {
+		Referee string  `json:"referee"`
+		Status  string  `json:"status"`
+		Bonus   float32 `json:"bonus"`
+	} `json:"referrals"`
+}
+
+func (suite *ServerTestSuite) TestReferralsInMemory() {
+	ctx := context.Background()
+	m := storage.NewMemory()
+	cfg := suite.cfg
+	cfg.ReferrerBonus = 100
+	cfg.RefereeBonus = 50
+	cfg.ReferralMaxCount = 1
+	cfg.TrustedProxies = []string{"127.0.0.1", "::1"}
+	ts := httptest.NewServer(server.NewRouter(cfg, suite.logger, m, events.NewBroker(), nil))
+	defer ts.Close()
+
+	register := func(login string, code string, ip string) int {
+		resp, err := suite.client.R().
+			SetBody(storage.RegisterUser{Login: login, Password: "123", ReferralCode: code}).
+			SetHeader("X-Forwarded-For", ip).
+			Post(ts.URL + "/api/user/register")
+		suite.Require().NoError(err)
+		return resp.StatusCode()
+	}
+	suite.Require().Equal(200, register("referrer", "", "10.0.0.1"))
+
+	token, err := utils.GenerateJWTString(cfg.TokenExpSec, cfg.SecretKey, "referrer", storage.RoleUser)
+	suite.Require().NoError(err)
+	var referrals referralsResponse
+	resp, err := suite.client.R().
+		SetResult(&referrals).
+		SetHeader("Authorization", "Bearer "+token).
+		Get(ts.URL + "/api/user/referrals")
+	suite.Require().NoError(err)
+	suite.Require().Equal(200, resp.StatusCode())
+	suite.Require().Len(referrals.Code, 8)
+	suite.Require().Empty(referrals.Referrals)
+	code := referrals.Code
+
+	suite.Require().Equal(422, register("stranger", "UNKNOWN1", "10.0.0.9"))
+	suite.Require().Equal(200, register("friend", " "+strings.ToLower(code)+" ", "10.0.0.2"))
+	// Регистрация с адреса пригласившего и сверх лимита не мешает новому пользователю,
+	// но приглашение отклоняется
+	suite.Require().Equal(200, register("sibling", code, "10.0.0.1"))
+	suite.Require().NotNil(m.Users["referrer"].FlaggedAt)
+	suite.Require().Equal(200, register("cousin", code, "10.0.0.3"))
+
+	// Первый начисленный заказ приглашенного приносит бонусы обоим участникам
+	suite.Require().NoError(m.StoreOrder(ctx, "12345678903", "friend"))
+	suite.Require().NoError(m.StoreOrder(ctx, "79927398713", "friend"))
+	tx, err := m.BeginTransaction(ctx)
+	suite.Require().NoError(err)
+	policy := tasks.NewAccrualPolicy(cfg, time.Now())
+	status := storage.OrderStatus{Number: "12345678903", Status: "PROCESSED", Accrual: 10}
+	balance, err := tasks.ApplyOrderStatus(ctx, tx, "friend", status, policy, "")
+	suite.Require().NoError(err)
+	suite.Require().InDelta(60, balance, 0.001)
+	suite.Require().InDelta(60, m.Users["friend"].Balance, 0.001)
+	suite.Require().InDelta(100, m.Users["referrer"].Balance, 0.001)
+
+	status = storage.OrderStatus{Number: "79927398713", Status: "PROCESSED", Accrual: 10}
+	balance, err = tasks.ApplyOrderStatus(ctx, tx, "friend", status, policy, "")
+	suite.Require().NoError(err)
+	suite.Require().InDelta(70, balance, 0.001)
+	suite.Require().InDelta(100, m.Users["referrer"].Balance, 0.001)
+
+	resp, err = suite.client.R().
+		SetResult(&referrals).
+		SetHeader("Authorization", "Bearer "+token).
+		Get(ts.URL + "/api/user/referrals")
+	suite.Require().NoError(err)
+	suite.Require().Equal(200, resp.StatusCode())
+	suite.Require().Len(referrals.Referrals, 3)
+	suite.Require().Equal("cousin", referrals.Referrals[0].Referee)
+	suite.Require().Equal(storage.ReferralRejected, referrals.Referrals[0].Status)
+	suite.Require().Equal(storage.ReferralRejected, referrals.Referrals[1].Status)
+	suite.Require().Equal("friend", referrals.Referrals[2].Referee)
+	suite.Require().Equal(storage.ReferralRewarded, referrals.Referrals[2].Status)
+	suite.Require().InDelta(100, referrals.Referrals[2].Bonus, 0.001)
+}
+
+func (suite *ServerTestSuite) TestRegistrationIPIgnoresUntrustedForwardedFor() {
+	m := storage.NewMemory()
+	ts := httptest.NewServer(server.NewRouter(suite.cfg, suite.logger, m, events.NewBroker(), nil))
+	defer ts.Close()
+
+	// Без доверенных прокси заголовок подделывается клиентом, поэтому адрес берется из соединения
+	resp, err := suite.client.R().
+		SetBody(storage.RegisterUser{Login: "spoofer", Password: "123"}).
+		SetHeader("X-Forwarded-For", "10.0.0.1").
+		Post(ts.URL + "/api/user/register")
+	suite.Require().NoError(err)
+	suite.Require().Equal(200, resp.StatusCode())
+	suite.Require().Equal("127.0.0.1", m.Users["spoofer"].RegistrationIP)
+}
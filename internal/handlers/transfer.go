@@ -0,0 +1,247 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/pisarevaa/gophermart/internal/events"
+	"github.com/pisarevaa/gophermart/internal/storage"
+	"github.com/pisarevaa/gophermart/internal/utils"
+)
+
+const balanceHistoryLimit = 100
+
+type Transfer struct {
+	To      string  `json:"to"      binding:"required"`
+	Sum     float32 `json:"sum"     binding:"required"`
+	Comment string  `json:"comment"`
+}
+
+type BalanceMovementResponse struct {
+	Kind         string                  `json:"kind"                   binding:"required"`
+	Amount       float32                 `json:"amount"                 binding:"required"`
+	Order        string                  `json:"order,omitempty"`
+	Counterparty string                  `json:"counterparty,omitempty"`
+	Reason       string                  `json:"reason,omitempty"`
+	CreatedAt    utils.FormattedDatetime `json:"created_at"             binding:"required" swaggertype:"string" example:"2024-06-12T08:00:04+03:00"`
+}
+
+// TransferBalance godoc
+//
+//	@Summary		Transfer points to another user
+//	@Description	Max sum of one transfer and sum of transfers for the last 24 hours are limited by configuration.
+//	@Schemes
+//	@Tags		Balance
+//	@Accept		json
+//	@Produce	json
+//	@Param		request			body	Transfer	true	"Body"
+//	@Param		Authorization	header	string		true	"Bearer"
+//	@Security	ApiKeyAuth
+//	@Success	200	{object}	storage.Success	"Response"
+//	@Failure	401	{object}	utils.Problem	"Unauthorized"
+//	@Failure	402	{object}	utils.Problem	"not enough balance"
+//	@Failure	404	{object}	utils.Problem	"Recipient is not found"
+//	@Failure	422	{object}	utils.Problem	"Unprocessable Entity or limit is exceeded"
+//	@Failure	500	{object}	utils.Problem	"Error"
+//	@Router		/api/user/balance/transfer [post]
+func (s *Service) TransferBalance(c *gin.Context) {
+	login := c.GetString("Login")
+	var transfer Transfer
+	if err := c.ShouldBindJSON(&transfer); err != nil {
+		utils.Abort(c, utils.NewProblem(http.StatusUnprocessableEntity, err.Error()))
+		return
+	}
+	if transfer.Sum <= 0 {
+		utils.Abort(c, utils.NewProblem(http.StatusUnprocessableEntity, "sum must be positive"))
+		return
+	}
+	if transfer.To == login {
+		utils.Abort(c, utils.NewProblem(http.StatusUnprocessableEntity, "can't transfer to yourself"))
+		return
+	}
+	if s.Config.TransferMaxSum > 0 && float64(transfer.Sum) > s.Config.TransferMaxSum {
+		utils.Abort(c, utils.NewProblem(http.StatusUnprocessableEntity, "transfer sum limit is exceeded"))
+		return
+	}
+
+	tx, err := s.Repo.BeginTransaction(c)
+	if err != nil {
+		utils.Abort(c, err)
+		return
+	}
+	defer tx.Rollback(c) //nolint:errcheck // ignore check
+
+	// Пользователи блокируются в порядке логинов, поэтому встречные переводы
+	// не могут заблокировать друг друга
+	locked := make(map[string]storage.User, 2)
+	for _, l := range sortedPair(login, transfer.To) {
+		user, errLock := tx.GetUserWithLock(c, l)
+		if errLock != nil {
+			if l == login {
+				utils.Abort(c, utils.NewProblem(http.StatusUnauthorized, "user is not found"))
+			} else {
+				utils.Abort(c, utils.NewProblem(http.StatusNotFound, "recipient is not found"))
+			}
+			return
+		}
+		locked[l] = user
+	}
+	sender, recipient := locked[login], locked[transfer.To]
+
+	if sender.Balance-sender.Held-transfer.Sum < 0 {
+		utils.Abort(c, utils.NewProblem(http.StatusPaymentRequired, "not enough balance"))
+		return
+	}
+	if s.Config.TransferDailyLimit > 0 {
+		transferred, errSum := tx.GetMovementsSum(c, login, storage.MovementTransferOut, time.Now().Add(-24*time.Hour))
+		if errSum != nil {
+			utils.Abort(c, errSum)
+			return
+		}
+		if float64(transfer.Sum-transferred) > s.Config.TransferDailyLimit {
+			utils.Abort(c, utils.NewProblem(http.StatusUnprocessableEntity, "daily transfer limit is exceeded"))
+			return
+		}
+	}
+
+	err = s.storeTransfer(c, tx, sender, recipient, transfer)
+	if err != nil {
+		utils.Abort(c, err)
+		return
+	}
+
+	err = tx.Commit(c)
+	if err != nil {
+		utils.Abort(c, err)
+		return
+	}
+
+	s.Broker.Publish(sender.Login, events.BalanceChanged, events.BalanceEvent{
+		Current: sender.Balance - transfer.Sum,
+	})
+	s.Broker.Publish(recipient.Login, events.BalanceChanged, events.BalanceEvent{
+		Current: recipient.Balance + transfer.Sum,
+	})
+
+	s.Logger.Info("user ", sender.Login, " transferred ", transfer.Sum, " points to ", recipient.Login)
+
+	c.JSON(http.StatusOK, storage.Success{Success: true})
+}
+
+// storeTransfer переносит баллы между заблокированными пользователями. Отправитель расходует
+// самые старые партии, получатель получает новую партию со сроком сгорания от момента перевода.
+func (s *Service) storeTransfer(
+	c *gin.Context,
+	tx storage.Transaction,
+	sender storage.User,
+	recipient storage.User,
+	transfer Transfer,
+) error {
+	err := tx.AccrualUserBalance(c, -transfer.Sum, sender.Login)
+	if err != nil {
+		return err
+	}
+	consumed, err := tx.ConsumePointLots(c, sender.Login, transfer.Sum)
+	if err != nil {
+		return err
+	}
+	err = tx.AccrualUserBalance(c, transfer.Sum, recipient.Login)
+	if err != nil {
+		return err
+	}
+	// Переданные баллы сгорают в те же сроки, что и у отправителя, иначе переводом можно
+	// продлевать срок жизни баллов. Баллы отправителя вне партий не сгорают и у получателя
+	remaining := transfer.Sum
+	for _, lot := range consumed {
+		err = tx.StorePointLot(c, storage.PointLot{
+			Login:     recipient.Login,
+			Amount:    lot.Amount,
+			ExpiresAt: lot.ExpiresAt,
+		})
+		if err != nil {
+			return err
+		}
+		remaining -= lot.Amount
+	}
+	if remaining > 0 {
+		err = tx.StorePointLot(c, storage.PointLot{
+			Login:  recipient.Login,
+			Amount: remaining,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	err = tx.StoreBalanceMovement(c, storage.BalanceMovement{
+		Login:        sender.Login,
+		Kind:         storage.MovementTransferOut,
+		Amount:       -transfer.Sum,
+		Reason:       transfer.Comment,
+		Counterparty: recipient.Login,
+	})
+	if err != nil {
+		return err
+	}
+	err = tx.StoreBalanceMovement(c, storage.BalanceMovement{
+		Login:        recipient.Login,
+		Kind:         storage.MovementTransferIn,
+		Amount:       transfer.Sum,
+		Reason:       transfer.Comment,
+		Counterparty: sender.Login,
+	})
+	if err != nil {
+		return err
+	}
+	return tx.StoreAuditEvent(c, withAmounts(
+		newAuditEvent(c, sender.Login, storage.AuditBalanceTransferred, recipient.Login),
+		sender.Balance,
+		sender.Balance-transfer.Sum,
+	))
+}
+
+func sortedPair(a string, b string) []string {
+	if b < a {
+		return []string{b, a}
+	}
+	return []string{a, b}
+}
+
+// GetBalanceHistory godoc
+//
+//	@Summary		Get user's balance history
+//	@Description	Returns last balance movements: accruals, withdrawals, transfers, adjustments and expirations.
+//	@Schemes
+//	@Tags		Balance
+//	@Produce	json
+//	@Param		Authorization	header	string	true	"Bearer"
+//	@Security	ApiKeyAuth
+//	@Success	200	{object}	[]BalanceMovementResponse	"Response"
+//	@Success	204	{object}	storage.Success				"No movements"
+//	@Failure	401	{object}	utils.Problem				"Unauthorized"
+//	@Failure	500	{object}	utils.Problem				"Error"
+//	@Router		/api/user/balance/history [get]
+func (s *Service) GetBalanceHistory(c *gin.Context) {
+	movements, err := s.Repo.GetBalanceMovements(c, c.GetString("Login"), balanceHistoryLimit)
+	if err != nil {
+		utils.Abort(c, err)
+		return
+	}
+	if len(movements) == 0 {
+		c.Status(http.StatusNoContent)
+		return
+	}
+	history := make([]BalanceMovementResponse, 0, len(movements))
+	for _, movement := range movements {
+		history = append(history, BalanceMovementResponse{
+			Kind:         movement.Kind,
+			Amount:       movement.Amount,
+			Order:        movement.OrderNumber,
+			Counterparty: movement.Counterparty,
+			Reason:       movement.Reason,
+			CreatedAt:    utils.FormattedDatetime(movement.CreatedAt),
+		})
+	}
+	c.JSON(http.StatusOK, history)
+}
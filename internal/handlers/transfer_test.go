@@ -0,0 +1,98 @@
+package handlers_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"time"
+
+	server "github.com/pisarevaa/gophermart/internal"
+	"github.com/pisarevaa/gophermart/internal/events"
+	"github.com/pisarevaa/gophermart/internal/handlers"
+	"github.com/pisarevaa/gophermart/internal/storage"
+)
+
+type BalanceMovementResponse struct {
+	Kind         string  `json:"kind"`
+	Amount       float32 `json:"amount"`
+	Counterparty string  `json:"counterparty"`
+	Reason       string  `json:"reason"`
+}
+
+func (suite *ServerTestSuite) TestTransferBalanceInMemory() {
+	cfg := suite.cfg
+	cfg.TransferMaxSum = 300
+	cfg.TransferDailyLimit = 400
+
+	m := storage.NewMemory()
+	m.Users[login] = storage.User{
+		Login:   login,
+		Balance: float32(500),
+		Held:    float32(50),
+		Role:    storage.RoleUser,
+	}
+	m.Users["family"] = storage.User{
+		Login: "family",
+		Role:  storage.RoleUser,
+	}
+	soon := time.Now().Add(time.Hour)
+	suite.Require().NoError(m.StorePointLot(context.Background(), storage.PointLot{Login: login, Amount: 200, ExpiresAt: &soon}))
+
+	ts := httptest.NewServer(server.NewRouter(cfg, suite.logger, m, events.NewBroker(), nil))
+	defer ts.Close()
+
+	transfer := func(request handlers.Transfer) int {
+		resp, err := suite.client.R().
+			SetBody(request).
+			SetHeader("Content-Type", "application/json").
+			SetHeader("Authorization", "Bearer "+suite.token).
+			Post(ts.URL + "/api/user/balance/transfer")
+		suite.Require().NoError(err)
+		return resp.StatusCode()
+	}
+
+	suite.Require().Equal(422, transfer(handlers.Transfer{To: login, Sum: 100}))
+	suite.Require().Equal(422, transfer(handlers.Transfer{To: "family", Sum: -100}))
+	suite.Require().Equal(422, transfer(handlers.Transfer{To: "family", Sum: 350}))
+	suite.Require().Equal(404, transfer(handlers.Transfer{To: "stranger", Sum: 100}))
+
+	suite.Require().Equal(200, transfer(handlers.Transfer{To: "family", Sum: 300, Comment: "pool"}))
+	suite.Require().Equal(float32(200), m.Users[login].Balance)
+	suite.Require().Equal(float32(300), m.Users["family"].Balance)
+
+	// Доступно 150 баллов с учетом холда, а дневной лимит позволяет перевести еще 100
+	suite.Require().Equal(402, transfer(handlers.Transfer{To: "family", Sum: 160}))
+	suite.Require().Equal(422, transfer(handlers.Transfer{To: "family", Sum: 110}))
+	suite.Require().Equal(200, transfer(handlers.Transfer{To: "family", Sum: 100}))
+	suite.Require().Equal(float32(100), m.Users[login].Balance)
+	suite.Require().Equal(float32(400), m.Users["family"].Balance)
+
+	var history []BalanceMovementResponse
+	resp, err := suite.client.R().
+		SetResult(&history).
+		SetHeader("Authorization", "Bearer "+suite.token).
+		Get(ts.URL + "/api/user/balance/history")
+	suite.Require().NoError(err)
+	suite.Require().Equal(200, resp.StatusCode())
+	suite.Require().Len(history, 2)
+	suite.Require().Equal(storage.MovementTransferOut, history[1].Kind)
+	suite.Require().Equal(float32(-300), history[1].Amount)
+	suite.Require().Equal("family", history[1].Counterparty)
+	suite.Require().Equal("pool", history[1].Reason)
+
+	recipientMovements, err := m.GetBalanceMovements(context.Background(), "family", 10)
+	suite.Require().NoError(err)
+	suite.Require().Len(recipientMovements, 2)
+	suite.Require().Equal(storage.MovementTransferIn, recipientMovements[0].Kind)
+	suite.Require().Equal(login, recipientMovements[0].Counterparty)
+
+	// Переданные баллы сохраняют срок сгорания партий отправителя, баллы вне партий не сгорают
+	suite.Require().Len(m.PointLots, 4)
+	suite.Require().Equal(float32(0), m.PointLots[0].Remaining)
+	suite.Require().Equal("family", m.PointLots[1].Login)
+	suite.Require().Equal(float32(200), m.PointLots[1].Amount)
+	suite.Require().Equal(soon, *m.PointLots[1].ExpiresAt)
+	suite.Require().Equal(float32(100), m.PointLots[2].Amount)
+	suite.Require().Nil(m.PointLots[2].ExpiresAt)
+	suite.Require().Equal(float32(100), m.PointLots[3].Amount)
+	suite.Require().Nil(m.PointLots[3].ExpiresAt)
+}
@@ -1,25 +1,43 @@
 package handlers
 
 import (
+	"github.com/pisarevaa/gophermart/internal/accrual"
 	"github.com/pisarevaa/gophermart/internal/configs"
+	"github.com/pisarevaa/gophermart/internal/events"
+	"github.com/pisarevaa/gophermart/internal/fraud"
+	"github.com/pisarevaa/gophermart/internal/ordernumber"
 	"github.com/pisarevaa/gophermart/internal/storage"
 	"go.uber.org/zap"
 )
 
 type Service struct {
-	Config configs.Config
-	Logger *zap.SugaredLogger
-	Repo   storage.Storage
+	Config       configs.Config
+	Logger       *zap.SugaredLogger
+	Repo         storage.Storage
+	Broker       *events.Broker
+	Accrual      *accrual.Router
+	Fraud        *fraud.Engine
+	OrderNumbers *ordernumber.Validator
 }
 
 func NewController(
 	config configs.Config,
 	logger *zap.SugaredLogger,
 	repo storage.Storage,
+	broker *events.Broker,
+	accrualRouter *accrual.Router,
 ) *Service {
+	fraudEngine, err := fraud.NewEngineFromConfig(config.Fraud)
+	if err != nil {
+		logger.Fatal("unable to create fraud engine: ", err)
+	}
 	return &Service{
-		Config: config,
-		Logger: logger,
-		Repo:   repo,
+		Config:       config,
+		Logger:       logger,
+		Repo:         repo,
+		Broker:       broker,
+		Accrual:      accrualRouter,
+		Fraud:        fraudEngine,
+		OrderNumbers: ordernumber.NewValidator(config.OrderNumberRules),
 	}
 }
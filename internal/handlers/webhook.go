@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/pisarevaa/gophermart/internal/storage"
+	"github.com/pisarevaa/gophermart/internal/utils"
+)
+
+const webhookDeliveriesLimit = 100
+
+type CreateWebhook struct {
+	URL string `json:"url" binding:"required"`
+}
+
+type WebhookResponse struct {
+	ID        int64     `json:"id"        binding:"required"`
+	URL       string    `json:"url"       binding:"required"`
+	CreatedAt time.Time `json:"createdAt" binding:"required"`
+}
+
+type CreatedWebhook struct {
+	WebhookResponse
+	Secret string `json:"secret" binding:"required"`
+}
+
+func newWebhookResponse(webhook storage.Webhook) WebhookResponse {
+	return WebhookResponse{
+		ID:        webhook.ID,
+		URL:       webhook.URL,
+		CreatedAt: webhook.CreatedAt,
+	}
+}
+
+// CreateWebhook godoc
+//
+//	@Summary		Register webhook
+//	@Description	Webhook receives order.finalized events signed with HMAC-SHA256 in X-Gophermart-Signature header.
+//	@Description	URL must resolve to public address, loopback, private and link-local addresses are rejected.
+//	@Schemes
+//	@Tags		Webhooks
+//	@Accept		json
+//	@Produce	json
+//	@Param		request			body	CreateWebhook	true	"Body"
+//	@Param		Authorization	header	string			true	"Bearer"
+//	@Security	ApiKeyAuth
+//	@Success	201	{object}	CreatedWebhook	"Response, secret is shown only once"
+//	@Failure	401	{object}	utils.Problem	"Unauthorized"
+//	@Failure	422	{object}	utils.Problem	"Incorrect URL"
+//	@Failure	500	{object}	utils.Problem	"Error"
+//	@Router		/api/user/webhooks [post]
+func (s *Service) CreateWebhook(c *gin.Context) {
+	login := c.GetString("Login")
+	var request CreateWebhook
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.Abort(c, utils.NewProblem(http.StatusUnprocessableEntity, err.Error()))
+		return
+	}
+	webhookURL, err := url.Parse(request.URL)
+	if err != nil || (webhookURL.Scheme != "http" && webhookURL.Scheme != "https") || webhookURL.Host == "" {
+		utils.Abort(c, utils.NewProblem(http.StatusUnprocessableEntity, "url must be absolute http or https URL"))
+		return
+	}
+	// Вебхук не должен открывать доступ к внутренней сети сервиса, при доставке адрес проверяется повторно
+	if !s.Config.WebhookAllowPrivate {
+		if err = utils.CheckWebhookHost(c, webhookURL.Hostname()); err != nil {
+			utils.Abort(c, utils.NewProblem(http.StatusUnprocessableEntity, "url must resolve to public address"))
+			return
+		}
+	}
+
+	secret, err := utils.GenerateWebhookSecret()
+	if err != nil {
+		utils.Abort(c, err)
+		return
+	}
+	webhook := storage.Webhook{
+		Login:     login,
+		URL:       webhookURL.String(),
+		Secret:    secret,
+		CreatedAt: time.Now(),
+	}
+	webhook.ID, err = s.Repo.StoreWebhook(c, webhook)
+	if err != nil {
+		utils.Abort(c, err)
+		return
+	}
+
+	s.Logger.Info("webhook ", webhook.ID, " is created for login ", login)
+
+	c.JSON(http.StatusCreated, CreatedWebhook{
+		WebhookResponse: newWebhookResponse(webhook),
+		Secret:          secret,
+	})
+}
+
+// GetWebhooks godoc
+//
+//	@Summary	Get user's webhooks
+//	@Schemes
+//	@Tags		Webhooks
+//	@Produce	json
+//	@Param		Authorization	header	string	true	"Bearer"
+//	@Security	ApiKeyAuth
+//	@Success	200	{object}	[]WebhookResponse	"Response"
+//	@Failure	401	{object}	utils.Problem		"Unauthorized"
+//	@Failure	500	{object}	utils.Problem		"Error"
+//	@Router		/api/user/webhooks [get]
+func (s *Service) GetWebhooks(c *gin.Context) {
+	webhooks, err := s.Repo.GetWebhooks(c, c.GetString("Login"))
+	if err != nil {
+		utils.Abort(c, err)
+		return
+	}
+	webhooksResponse := []WebhookResponse{}
+	for _, webhook := range webhooks {
+		webhooksResponse = append(webhooksResponse, newWebhookResponse(webhook))
+	}
+	c.JSON(http.StatusOK, webhooksResponse)
+}
+
+// DeleteWebhook godoc
+//
+//	@Summary	Delete webhook
+//	@Schemes
+//	@Tags		Webhooks
+//	@Produce	json
+//	@Param		id				path	int		true	"Webhook ID"
+//	@Param		Authorization	header	string	true	"Bearer"
+//	@Security	ApiKeyAuth
+//	@Success	200	{object}	storage.Success	"Response"
+//	@Failure	401	{object}	utils.Problem	"Unauthorized"
+//	@Failure	404	{object}	utils.Problem	"Webhook is not found"
+//	@Router		/api/user/webhooks/{id} [delete]
+func (s *Service) DeleteWebhook(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.Abort(c, utils.NewProblem(http.StatusNotFound, "webhook is not found"))
+		return
+	}
+	err = s.Repo.DeleteWebhook(c, id, c.GetString("Login"))
+	if err != nil {
+		utils.Abort(c, utils.NewProblem(http.StatusNotFound, "webhook is not found"))
+		return
+	}
+	c.JSON(http.StatusOK, storage.Success{
+		Success: true,
+	})
+}
+
+// GetWebhookDeliveries godoc
+//
+//	@Summary	Get webhook delivery log
+//	@Schemes
+//	@Tags		Webhooks
+//	@Produce	json
+//	@Param		id				path	int		true	"Webhook ID"
+//	@Param		Authorization	header	string	true	"Bearer"
+//	@Security	ApiKeyAuth
+//	@Success	200	{object}	[]storage.WebhookDelivery	"Response, latest attempts first"
+//	@Failure	401	{object}	utils.Problem				"Unauthorized"
+//	@Failure	404	{object}	utils.Problem				"Webhook is not found"
+//	@Failure	500	{object}	utils.Problem				"Error"
+//	@Router		/api/user/webhooks/{id}/deliveries [get]
+func (s *Service) GetWebhookDeliveries(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.Abort(c, utils.NewProblem(http.StatusNotFound, "webhook is not found"))
+		return
+	}
+	deliveries, err := s.Repo.GetWebhookDeliveries(c, id, c.GetString("Login"), webhookDeliveriesLimit)
+	if err != nil {
+		utils.Abort(c, err)
+		return
+	}
+	if deliveries == nil {
+		deliveries = []storage.WebhookDelivery{}
+	}
+	c.JSON(http.StatusOK, deliveries)
+}
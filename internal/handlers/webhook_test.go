@@ -0,0 +1,143 @@
+package handlers_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"time"
+
+	server "github.com/pisarevaa/gophermart/internal"
+	"github.com/pisarevaa/gophermart/internal/events"
+	"github.com/pisarevaa/gophermart/internal/handlers"
+	"github.com/pisarevaa/gophermart/internal/storage"
+	"github.com/pisarevaa/gophermart/internal/tasks"
+	"github.com/pisarevaa/gophermart/internal/utils"
+)
+
+func (suite *ServerTestSuite) TestWebhookDeliveryInMemory() {
+	m := storage.NewMemory()
+
+	var (
+		attempts  int
+		body      []byte
+		signature string
+		timestamp int64
+	)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		body, _ = io.ReadAll(r.Body)
+		signature = r.Header.Get(utils.WebhookSignatureHeader)
+		timestamp, _ = strconv.ParseInt(r.Header.Get(utils.WebhookTimestampHeader), 10, 64)
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer receiver.Close()
+
+	// Тестовый получатель слушает loopback-адрес
+	cfg := suite.cfg
+	cfg.WebhookAllowPrivate = true
+	ts := httptest.NewServer(server.NewRouter(cfg, suite.logger, m, events.NewBroker(), nil))
+	defer ts.Close()
+
+	var created handlers.CreatedWebhook
+	resp, err := suite.client.R().
+		SetResult(&created).
+		SetBody(handlers.CreateWebhook{URL: receiver.URL}).
+		SetHeader("Content-Type", "application/json").
+		SetHeader("Authorization", "Bearer "+suite.token).
+		Post(ts.URL + "/api/user/webhooks")
+	suite.Require().NoError(err)
+	suite.Require().Equal(201, resp.StatusCode())
+	suite.Require().NotEmpty(created.Secret)
+
+	resp, err = suite.client.R().
+		SetBody(handlers.CreateWebhook{URL: "ftp://example.com"}).
+		SetHeader("Content-Type", "application/json").
+		SetHeader("Authorization", "Bearer "+suite.token).
+		Post(ts.URL + "/api/user/webhooks")
+	suite.Require().NoError(err)
+	suite.Require().Equal(422, resp.StatusCode())
+
+	ctx := context.Background()
+	suite.Require().NoError(m.StoreWebhookMessages(ctx, login, storage.WebhookOrderFinalized, []byte(`{"number":"1"}`)))
+	suite.Require().NoError(m.StoreWebhookMessages(ctx, "other", storage.WebhookOrderFinalized, []byte(`{"number":"2"}`)))
+	suite.Require().Len(m.WebhookOutbox, 1)
+
+	task := tasks.NewTask(cfg, suite.logger, m, nil, events.NewBroker())
+	suite.Require().NoError(task.DeliverWebhooks(ctx))
+	suite.Require().Equal(1, m.WebhookOutbox[0].Attempts)
+	suite.Require().Nil(m.WebhookOutbox[0].DeliveredAt)
+	suite.Require().True(m.WebhookOutbox[0].NextAttemptAt.After(time.Now()))
+
+	// Повторная попытка наступает по расписанию backoff, здесь переносим ее на текущий момент
+	m.WebhookOutbox[0].NextAttemptAt = time.Now()
+	suite.Require().NoError(task.DeliverWebhooks(ctx))
+	suite.Require().NotNil(m.WebhookOutbox[0].DeliveredAt)
+	suite.Require().Equal(`{"number":"1"}`, string(body))
+	suite.Require().Equal(utils.SignWebhookPayload(created.Secret, timestamp, body), signature)
+
+	var deliveries []storage.WebhookDelivery
+	resp, err = suite.client.R().
+		SetResult(&deliveries).
+		SetHeader("Authorization", "Bearer "+suite.token).
+		Get(ts.URL + "/api/user/webhooks/" + strconv.FormatInt(created.ID, 10) + "/deliveries")
+	suite.Require().NoError(err)
+	suite.Require().Equal(200, resp.StatusCode())
+	suite.Require().Len(deliveries, 2)
+	suite.Require().Equal(200, deliveries[0].StatusCode)
+	suite.Require().Equal(2, deliveries[0].Attempt)
+	suite.Require().Equal(503, deliveries[1].StatusCode)
+	suite.Require().NotEmpty(deliveries[1].Error)
+	suite.Require().Equal(storage.WebhookOrderFinalized, deliveries[0].Event)
+}
+
+func (suite *ServerTestSuite) TestWebhookPrivateAddressInMemory() {
+	m := storage.NewMemory()
+
+	var attempts int
+	receiver := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, _ *http.Request) {
+		attempts++
+	}))
+	defer receiver.Close()
+
+	ts := httptest.NewServer(server.NewRouter(suite.cfg, suite.logger, m, events.NewBroker(), nil))
+	defer ts.Close()
+
+	for _, address := range []string{
+		receiver.URL,
+		"http://localhost:8080/hook",
+		"http://10.0.0.1/hook",
+		"http://192.168.1.10/hook",
+		"http://169.254.169.254/latest/meta-data",
+		"http://[::1]:8080/hook",
+	} {
+		resp, err := suite.client.R().
+			SetBody(handlers.CreateWebhook{URL: address}).
+			SetHeader("Content-Type", "application/json").
+			SetHeader("Authorization", "Bearer "+suite.token).
+			Post(ts.URL + "/api/user/webhooks")
+		suite.Require().NoError(err)
+		suite.Require().Equal(422, resp.StatusCode(), address)
+	}
+	suite.Require().Empty(m.Webhooks)
+
+	// Адрес мог смениться после регистрации, поэтому доставка тоже не подключается к внутренней сети
+	ctx := context.Background()
+	_, err := m.StoreWebhook(ctx, storage.Webhook{Login: login, URL: receiver.URL, Secret: "secret"})
+	suite.Require().NoError(err)
+	suite.Require().NoError(m.StoreWebhookMessages(ctx, login, storage.WebhookOrderFinalized, []byte(`{"number":"1"}`)))
+	task := tasks.NewTask(suite.cfg, suite.logger, m, nil, events.NewBroker())
+	suite.Require().NoError(task.DeliverWebhooks(ctx))
+	suite.Require().Zero(attempts)
+	suite.Require().Equal(1, m.WebhookOutbox[0].Attempts)
+	suite.Require().Contains(m.Deliveries[0].Error, utils.ErrWebhookAddress.Error())
+
+	// Сообщения удаленного вебхука больше не доставляются
+	suite.Require().NoError(m.DeleteWebhook(ctx, 1, login))
+	m.WebhookOutbox[0].NextAttemptAt = time.Now()
+	suite.Require().NoError(task.DeliverWebhooks(ctx))
+	suite.Require().Equal(1, m.WebhookOutbox[0].Attempts)
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/pisarevaa/gophermart/internal/storage"
+	"github.com/pisarevaa/gophermart/internal/utils"
+)
+
+// Коды нарушенных правил списания, возвращаются в поле code ответа.
+const (
+	WithdrawalNotPositive  = "withdrawal_sum_not_positive"
+	WithdrawalBelowMin     = "withdrawal_below_min"
+	WithdrawalAboveMax     = "withdrawal_above_max"
+	WithdrawalDailyLimit   = "withdrawal_daily_limit"
+	WithdrawalMonthlyLimit = "withdrawal_monthly_limit"
+	WithdrawalHourlyCount  = "withdrawal_hourly_count"
+)
+
+// checkWithdrawalLimits проверяет сумму списания и историю списаний пользователя за последний час,
+// сутки и месяц. Пользователь должен быть заблокирован в транзакции tx, чтобы параллельные списания
+// не обошли лимиты. Возвращает nil, если списание не нарушает ни одного правила.
+func (s *Service) checkWithdrawalLimits(
+	c *gin.Context,
+	tx storage.Transaction,
+	login string,
+	sum float32,
+) (*utils.Problem, error) {
+	cfg := s.Config
+	if cfg.WithdrawMinSum > 0 && float64(sum) < cfg.WithdrawMinSum {
+		return utils.NewProblem(
+			http.StatusUnprocessableEntity,
+			fmt.Sprintf("min withdrawal sum is %g", cfg.WithdrawMinSum),
+		).WithCode(WithdrawalBelowMin), nil
+	}
+	if cfg.WithdrawMaxSum > 0 && float64(sum) > cfg.WithdrawMaxSum {
+		return utils.NewProblem(
+			http.StatusUnprocessableEntity,
+			fmt.Sprintf("max withdrawal sum is %g", cfg.WithdrawMaxSum),
+		).WithCode(WithdrawalAboveMax), nil
+	}
+	now := time.Now()
+	if cfg.WithdrawHourlyCount > 0 {
+		count, err := tx.GetMovementsCount(c, login, storage.MovementWithdrawal, now.Add(-time.Hour))
+		if err != nil {
+			return nil, err
+		}
+		if count >= cfg.WithdrawHourlyCount {
+			return utils.NewProblem(
+				http.StatusTooManyRequests,
+				fmt.Sprintf("max %d withdrawals per hour", cfg.WithdrawHourlyCount),
+			).WithCode(WithdrawalHourlyCount), nil
+		}
+	}
+	// Списания хранятся отрицательными движениями
+	if cfg.WithdrawDailyLimit > 0 {
+		withdrawn, err := tx.GetMovementsSum(c, login, storage.MovementWithdrawal, now.Add(-24*time.Hour))
+		if err != nil {
+			return nil, err
+		}
+		if float64(sum-withdrawn) > cfg.WithdrawDailyLimit {
+			return utils.NewProblem(
+				http.StatusForbidden,
+				fmt.Sprintf("daily withdrawal limit %g is exceeded", cfg.WithdrawDailyLimit),
+			).WithCode(WithdrawalDailyLimit), nil
+		}
+	}
+	if cfg.WithdrawMonthlyLimit > 0 {
+		withdrawn, err := tx.GetMovementsSum(c, login, storage.MovementWithdrawal, now.AddDate(0, -1, 0))
+		if err != nil {
+			return nil, err
+		}
+		if float64(sum-withdrawn) > cfg.WithdrawMonthlyLimit {
+			return utils.NewProblem(
+				http.StatusForbidden,
+				fmt.Sprintf("monthly withdrawal limit %g is exceeded", cfg.WithdrawMonthlyLimit),
+			).WithCode(WithdrawalMonthlyLimit), nil
+		}
+	}
+	return nil, nil //nolint:nilnil // nil означает отсутствие нарушений
+}
@@ -0,0 +1,73 @@
+package handlers_test
+
+import (
+	"net/http/httptest"
+	"time"
+
+	server "github.com/pisarevaa/gophermart/internal"
+	"github.com/pisarevaa/gophermart/internal/events"
+	"github.com/pisarevaa/gophermart/internal/handlers"
+	"github.com/pisarevaa/gophermart/internal/storage"
+	"github.com/pisarevaa/gophermart/internal/utils"
+)
+
+func (suite *ServerTestSuite) TestWithdrawalLimitsInMemory() {
+	m := storage.NewMemory()
+	m.Users[login] = storage.User{
+		Login:   login,
+		Balance: float32(1000),
+		Role:    storage.RoleUser,
+	}
+	now := time.Now()
+	m.Orders["12345678903"] = storage.Order{
+		Number:      "12345678903",
+		Status:      "PROCESSED",
+		Accrual:     float32(1000),
+		Login:       login,
+		UploadedAt:  now,
+		ProcessedAt: &now,
+	}
+	// Списание десятидневной давности учитывается только в месячном лимите
+	m.Movements = append(m.Movements, storage.BalanceMovement{
+		Login:     login,
+		Kind:      storage.MovementWithdrawal,
+		Amount:    -150,
+		CreatedAt: now.AddDate(0, 0, -10),
+	})
+
+	cfg := suite.cfg
+	cfg.WithdrawMinSum = 10
+	cfg.WithdrawMaxSum = 200
+	cfg.WithdrawDailyLimit = 300
+	cfg.WithdrawMonthlyLimit = 400
+	cfg.WithdrawHourlyCount = 2
+	ts := httptest.NewServer(server.NewRouter(cfg, suite.logger, m, events.NewBroker(), nil))
+	defer ts.Close()
+
+	steps := []struct {
+		sum    float32
+		status int
+		code   string
+	}{
+		{sum: -100, status: 422, code: handlers.WithdrawalNotPositive},
+		{sum: 5, status: 422, code: handlers.WithdrawalBelowMin},
+		{sum: 250, status: 422, code: handlers.WithdrawalAboveMax},
+		{sum: 200, status: 200},
+		{sum: 150, status: 403, code: handlers.WithdrawalDailyLimit},
+		{sum: 100, status: 403, code: handlers.WithdrawalMonthlyLimit},
+		{sum: 50, status: 200},
+		{sum: 10, status: 429, code: handlers.WithdrawalHourlyCount},
+	}
+	for _, step := range steps {
+		var limitError utils.Problem
+		resp, err := suite.client.R().
+			SetBody(handlers.Withdraw{Order: "12345678903", Sum: step.sum}).
+			SetError(&limitError).
+			SetHeader("Authorization", "Bearer "+suite.token).
+			Post(ts.URL + "/api/user/balance/withdraw")
+		suite.Require().NoError(err)
+		suite.Require().Equal(step.status, resp.StatusCode(), step.sum)
+		suite.Require().Equal(step.code, limitError.Code, step.sum)
+	}
+	suite.Require().InDelta(750, m.Users[login].Balance, 0.001)
+}
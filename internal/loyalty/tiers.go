@@ -0,0 +1,75 @@
+package loyalty
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/pisarevaa/gophermart/internal/configs"
+)
+
+// WindowMonths - период, за который суммируются начисления для определения уровня.
+const WindowMonths = 12
+
+// Tiers - уровни лояльности, упорядоченные по возрастанию порога.
+type Tiers []configs.LoyaltyTier
+
+var baseTier = configs.LoyaltyTier{Multiplier: 1}
+
+func NewTiers(tiers []configs.LoyaltyTier) Tiers {
+	sorted := slices.Clone(tiers)
+	slices.SortStableFunc(sorted, func(a, b configs.LoyaltyTier) int {
+		return cmp.Compare(a.Threshold, b.Threshold)
+	})
+	return sorted
+}
+
+// WindowStart возвращает начало периода, за который считаются начисления.
+func WindowStart(now time.Time) time.Time {
+	return now.AddDate(0, -WindowMonths, 0)
+}
+
+// ByTotal возвращает уровень для суммы начислений за период.
+func (t Tiers) ByTotal(total float32) configs.LoyaltyTier {
+	tier := baseTier
+	if len(t) > 0 {
+		tier = t[0]
+	}
+	for _, candidate := range t {
+		if total >= candidate.Threshold {
+			tier = candidate
+		}
+	}
+	return tier
+}
+
+// ByName возвращает уровень пользователя. Пустой или удаленный из конфигурации уровень
+// считается начальным.
+func (t Tiers) ByName(name string) configs.LoyaltyTier {
+	i := slices.IndexFunc(t, func(tier configs.LoyaltyTier) bool { return tier.Name == name })
+	if i == -1 {
+		return t.ByTotal(0)
+	}
+	return t[i]
+}
+
+// Next возвращает уровень, следующий за данным.
+func (t Tiers) Next(name string) (configs.LoyaltyTier, bool) {
+	current := t.ByName(name)
+	for _, tier := range t {
+		if tier.Threshold > current.Threshold {
+			return tier, true
+		}
+	}
+	return configs.LoyaltyTier{}, false
+}
+
+// Bonus возвращает баллы, начисляемые сверх accrual по множителю уровня, с точностью до копеек.
+func (t Tiers) Bonus(name string, accrual float32) float32 {
+	multiplier := t.ByName(name).Multiplier
+	if multiplier <= 1 {
+		return 0
+	}
+	return float32(math.Round(float64(accrual*(multiplier-1))*100) / 100)
+}
@@ -0,0 +1,48 @@
+package loyalty_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/pisarevaa/gophermart/internal/configs"
+	"github.com/pisarevaa/gophermart/internal/loyalty"
+)
+
+type TiersTestSuite struct {
+	suite.Suite
+	tiers loyalty.Tiers
+}
+
+func (suite *TiersTestSuite) SetupSuite() {
+	suite.tiers = loyalty.NewTiers([]configs.LoyaltyTier{
+		{Name: "gold", Threshold: 5000, Multiplier: 1.25},
+		{Name: "bronze", Threshold: 0, Multiplier: 1},
+		{Name: "silver", Threshold: 1000, Multiplier: 1.1},
+	})
+}
+
+func TestTiersSuite(t *testing.T) {
+	suite.Run(t, new(TiersTestSuite))
+}
+
+func (suite *TiersTestSuite) TestByTotal() {
+	suite.Require().Equal("bronze", suite.tiers.ByTotal(999).Name)
+	suite.Require().Equal("silver", suite.tiers.ByTotal(1000).Name)
+	suite.Require().Equal("gold", suite.tiers.ByTotal(7000).Name)
+	suite.Require().Equal("bronze", suite.tiers.ByName("").Name)
+}
+
+func (suite *TiersTestSuite) TestNext() {
+	next, ok := suite.tiers.Next("silver")
+	suite.Require().True(ok)
+	suite.Require().Equal("gold", next.Name)
+	_, ok = suite.tiers.Next("gold")
+	suite.Require().False(ok)
+}
+
+func (suite *TiersTestSuite) TestBonus() {
+	suite.Require().InDelta(0, suite.tiers.Bonus("bronze", 100), 0.001)
+	suite.Require().InDelta(25, suite.tiers.Bonus("gold", 100), 0.001)
+	suite.Require().InDelta(0, loyalty.NewTiers(nil).Bonus("gold", 100), 0.001)
+}
@@ -7,6 +7,7 @@ package mock
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 	storage "github.com/pisarevaa/gophermart/internal/storage"
@@ -50,214 +51,1222 @@ func (mr *MockStorageMockRecorder) BeginTransaction(ctx interface{}) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BeginTransaction", reflect.TypeOf((*MockStorage)(nil).BeginTransaction), ctx)
 }
 
+// ClearUserFlag mocks base method.
+func (m *MockStorage) ClearUserFlag(ctx context.Context, login string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClearUserFlag", ctx, login)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ClearUserFlag indicates an expected call of ClearUserFlag.
+func (mr *MockStorageMockRecorder) ClearUserFlag(ctx, login interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClearUserFlag", reflect.TypeOf((*MockStorage)(nil).ClearUserFlag), ctx, login)
+}
+
 // CloseConnection mocks base method.
 func (m *MockStorage) CloseConnection() {
 	m.ctrl.T.Helper()
-	m.ctrl.Call(m, "CloseConnection")
+	m.ctrl.Call(m, "CloseConnection")
+}
+
+// CloseConnection indicates an expected call of CloseConnection.
+func (mr *MockStorageMockRecorder) CloseConnection() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloseConnection", reflect.TypeOf((*MockStorage)(nil).CloseConnection))
+}
+
+// DeleteCampaign mocks base method.
+func (m *MockStorage) DeleteCampaign(ctx context.Context, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteCampaign", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteCampaign indicates an expected call of DeleteCampaign.
+func (mr *MockStorageMockRecorder) DeleteCampaign(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCampaign", reflect.TypeOf((*MockStorage)(nil).DeleteCampaign), ctx, id)
+}
+
+// DeleteWebhook mocks base method.
+func (m *MockStorage) DeleteWebhook(ctx context.Context, id int64, login string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteWebhook", ctx, id, login)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteWebhook indicates an expected call of DeleteWebhook.
+func (mr *MockStorageMockRecorder) DeleteWebhook(ctx, id, login interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWebhook", reflect.TypeOf((*MockStorage)(nil).DeleteWebhook), ctx, id, login)
+}
+
+// GetAPIKeyByHash mocks base method.
+func (m *MockStorage) GetAPIKeyByHash(ctx context.Context, keyHash string) (storage.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAPIKeyByHash", ctx, keyHash)
+	ret0, _ := ret[0].(storage.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAPIKeyByHash indicates an expected call of GetAPIKeyByHash.
+func (mr *MockStorageMockRecorder) GetAPIKeyByHash(ctx, keyHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAPIKeyByHash", reflect.TypeOf((*MockStorage)(nil).GetAPIKeyByHash), ctx, keyHash)
+}
+
+// GetAPIKeys mocks base method.
+func (m *MockStorage) GetAPIKeys(ctx context.Context, login string) ([]storage.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAPIKeys", ctx, login)
+	ret0, _ := ret[0].([]storage.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAPIKeys indicates an expected call of GetAPIKeys.
+func (mr *MockStorageMockRecorder) GetAPIKeys(ctx, login interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAPIKeys", reflect.TypeOf((*MockStorage)(nil).GetAPIKeys), ctx, login)
+}
+
+// GetAccrualTotal mocks base method.
+func (m *MockStorage) GetAccrualTotal(ctx context.Context, login string, since time.Time) (float32, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccrualTotal", ctx, login, since)
+	ret0, _ := ret[0].(float32)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccrualTotal indicates an expected call of GetAccrualTotal.
+func (mr *MockStorageMockRecorder) GetAccrualTotal(ctx, login, since interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccrualTotal", reflect.TypeOf((*MockStorage)(nil).GetAccrualTotal), ctx, login, since)
+}
+
+// GetAccrualTotals mocks base method.
+func (m *MockStorage) GetAccrualTotals(ctx context.Context, since time.Time) ([]storage.AccrualTotal, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccrualTotals", ctx, since)
+	ret0, _ := ret[0].([]storage.AccrualTotal)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccrualTotals indicates an expected call of GetAccrualTotals.
+func (mr *MockStorageMockRecorder) GetAccrualTotals(ctx, since interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccrualTotals", reflect.TypeOf((*MockStorage)(nil).GetAccrualTotals), ctx, since)
+}
+
+// GetAuditEvents mocks base method.
+func (m *MockStorage) GetAuditEvents(ctx context.Context, filter storage.AuditFilter) ([]storage.AuditEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAuditEvents", ctx, filter)
+	ret0, _ := ret[0].([]storage.AuditEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAuditEvents indicates an expected call of GetAuditEvents.
+func (mr *MockStorageMockRecorder) GetAuditEvents(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAuditEvents", reflect.TypeOf((*MockStorage)(nil).GetAuditEvents), ctx, filter)
+}
+
+// GetBalanceMovements mocks base method.
+func (m *MockStorage) GetBalanceMovements(ctx context.Context, login string, limit int) ([]storage.BalanceMovement, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBalanceMovements", ctx, login, limit)
+	ret0, _ := ret[0].([]storage.BalanceMovement)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBalanceMovements indicates an expected call of GetBalanceMovements.
+func (mr *MockStorageMockRecorder) GetBalanceMovements(ctx, login, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBalanceMovements", reflect.TypeOf((*MockStorage)(nil).GetBalanceMovements), ctx, login, limit)
+}
+
+// GetCampaign mocks base method.
+func (m *MockStorage) GetCampaign(ctx context.Context, id int64) (storage.Campaign, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCampaign", ctx, id)
+	ret0, _ := ret[0].(storage.Campaign)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCampaign indicates an expected call of GetCampaign.
+func (mr *MockStorageMockRecorder) GetCampaign(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCampaign", reflect.TypeOf((*MockStorage)(nil).GetCampaign), ctx, id)
+}
+
+// GetCampaigns mocks base method.
+func (m *MockStorage) GetCampaigns(ctx context.Context) ([]storage.Campaign, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCampaigns", ctx)
+	ret0, _ := ret[0].([]storage.Campaign)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCampaigns indicates an expected call of GetCampaigns.
+func (mr *MockStorageMockRecorder) GetCampaigns(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCampaigns", reflect.TypeOf((*MockStorage)(nil).GetCampaigns), ctx)
+}
+
+// GetExpiredHolds mocks base method.
+func (m *MockStorage) GetExpiredHolds(ctx context.Context, limit int) ([]storage.Hold, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetExpiredHolds", ctx, limit)
+	ret0, _ := ret[0].([]storage.Hold)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetExpiredHolds indicates an expected call of GetExpiredHolds.
+func (mr *MockStorageMockRecorder) GetExpiredHolds(ctx, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExpiredHolds", reflect.TypeOf((*MockStorage)(nil).GetExpiredHolds), ctx, limit)
+}
+
+// GetExpiringPointLots mocks base method.
+func (m *MockStorage) GetExpiringPointLots(ctx context.Context, login string, limit int) ([]storage.PointLot, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetExpiringPointLots", ctx, login, limit)
+	ret0, _ := ret[0].([]storage.PointLot)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetExpiringPointLots indicates an expected call of GetExpiringPointLots.
+func (mr *MockStorageMockRecorder) GetExpiringPointLots(ctx, login, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExpiringPointLots", reflect.TypeOf((*MockStorage)(nil).GetExpiringPointLots), ctx, login, limit)
+}
+
+// GetFlaggedUsers mocks base method.
+func (m *MockStorage) GetFlaggedUsers(ctx context.Context) ([]storage.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFlaggedUsers", ctx)
+	ret0, _ := ret[0].([]storage.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFlaggedUsers indicates an expected call of GetFlaggedUsers.
+func (mr *MockStorageMockRecorder) GetFlaggedUsers(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFlaggedUsers", reflect.TypeOf((*MockStorage)(nil).GetFlaggedUsers), ctx)
+}
+
+// GetFraudReviews mocks base method.
+func (m *MockStorage) GetFraudReviews(ctx context.Context, status string, limit int) ([]storage.FraudReview, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFraudReviews", ctx, status, limit)
+	ret0, _ := ret[0].([]storage.FraudReview)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFraudReviews indicates an expected call of GetFraudReviews.
+func (mr *MockStorageMockRecorder) GetFraudReviews(ctx, status, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFraudReviews", reflect.TypeOf((*MockStorage)(nil).GetFraudReviews), ctx, status, limit)
+}
+
+// GetHolds mocks base method.
+func (m *MockStorage) GetHolds(ctx context.Context, login string) ([]storage.Hold, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetHolds", ctx, login)
+	ret0, _ := ret[0].([]storage.Hold)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetHolds indicates an expected call of GetHolds.
+func (mr *MockStorageMockRecorder) GetHolds(ctx, login interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHolds", reflect.TypeOf((*MockStorage)(nil).GetHolds), ctx, login)
+}
+
+// GetLoginsWithExpiredPoints mocks base method.
+func (m *MockStorage) GetLoginsWithExpiredPoints(ctx context.Context, limit int) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLoginsWithExpiredPoints", ctx, limit)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLoginsWithExpiredPoints indicates an expected call of GetLoginsWithExpiredPoints.
+func (mr *MockStorageMockRecorder) GetLoginsWithExpiredPoints(ctx, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLoginsWithExpiredPoints", reflect.TypeOf((*MockStorage)(nil).GetLoginsWithExpiredPoints), ctx, limit)
+}
+
+// GetOrder mocks base method.
+func (m *MockStorage) GetOrder(ctx context.Context, number string) (storage.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrder", ctx, number)
+	ret0, _ := ret[0].(storage.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrder indicates an expected call of GetOrder.
+func (mr *MockStorageMockRecorder) GetOrder(ctx, number interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrder", reflect.TypeOf((*MockStorage)(nil).GetOrder), ctx, number)
+}
+
+// GetOrderStatusHistory mocks base method.
+func (m *MockStorage) GetOrderStatusHistory(ctx context.Context, number string) ([]storage.OrderStatusHistory, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrderStatusHistory", ctx, number)
+	ret0, _ := ret[0].([]storage.OrderStatusHistory)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrderStatusHistory indicates an expected call of GetOrderStatusHistory.
+func (mr *MockStorageMockRecorder) GetOrderStatusHistory(ctx, number interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrderStatusHistory", reflect.TypeOf((*MockStorage)(nil).GetOrderStatusHistory), ctx, number)
+}
+
+// GetOrders mocks base method.
+func (m *MockStorage) GetOrders(ctx context.Context, login string, onlyWithdrawn bool) ([]storage.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrders", ctx, login, onlyWithdrawn)
+	ret0, _ := ret[0].([]storage.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrders indicates an expected call of GetOrders.
+func (mr *MockStorageMockRecorder) GetOrders(ctx, login, onlyWithdrawn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrders", reflect.TypeOf((*MockStorage)(nil).GetOrders), ctx, login, onlyWithdrawn)
+}
+
+// GetOrdersCountByStatus mocks base method.
+func (m *MockStorage) GetOrdersCountByStatus(ctx context.Context, login string) (map[string]int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrdersCountByStatus", ctx, login)
+	ret0, _ := ret[0].(map[string]int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrdersCountByStatus indicates an expected call of GetOrdersCountByStatus.
+func (mr *MockStorageMockRecorder) GetOrdersCountByStatus(ctx, login interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrdersCountByStatus", reflect.TypeOf((*MockStorage)(nil).GetOrdersCountByStatus), ctx, login)
+}
+
+// GetOrdersCountToUpdate mocks base method.
+func (m *MockStorage) GetOrdersCountToUpdate(ctx context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrdersCountToUpdate", ctx)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrdersCountToUpdate indicates an expected call of GetOrdersCountToUpdate.
+func (mr *MockStorageMockRecorder) GetOrdersCountToUpdate(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrdersCountToUpdate", reflect.TypeOf((*MockStorage)(nil).GetOrdersCountToUpdate), ctx)
+}
+
+// GetOrdersPage mocks base method.
+func (m *MockStorage) GetOrdersPage(ctx context.Context, filter storage.OrdersFilter) ([]storage.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrdersPage", ctx, filter)
+	ret0, _ := ret[0].([]storage.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrdersPage indicates an expected call of GetOrdersPage.
+func (mr *MockStorageMockRecorder) GetOrdersPage(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrdersPage", reflect.TypeOf((*MockStorage)(nil).GetOrdersPage), ctx, filter)
+}
+
+// GetPendingBalance mocks base method.
+func (m *MockStorage) GetPendingBalance(ctx context.Context, login string) ([]storage.PendingBalance, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPendingBalance", ctx, login)
+	ret0, _ := ret[0].([]storage.PendingBalance)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPendingBalance indicates an expected call of GetPendingBalance.
+func (mr *MockStorageMockRecorder) GetPendingBalance(ctx, login interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPendingBalance", reflect.TypeOf((*MockStorage)(nil).GetPendingBalance), ctx, login)
+}
+
+// GetReferrals mocks base method.
+func (m *MockStorage) GetReferrals(ctx context.Context, referrer string) ([]storage.Referral, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetReferrals", ctx, referrer)
+	ret0, _ := ret[0].([]storage.Referral)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetReferrals indicates an expected call of GetReferrals.
+func (mr *MockStorageMockRecorder) GetReferrals(ctx, referrer interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReferrals", reflect.TypeOf((*MockStorage)(nil).GetReferrals), ctx, referrer)
+}
+
+// GetUploadedOrdersCount mocks base method.
+func (m *MockStorage) GetUploadedOrdersCount(ctx context.Context, login string, since time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUploadedOrdersCount", ctx, login, since)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUploadedOrdersCount indicates an expected call of GetUploadedOrdersCount.
+func (mr *MockStorageMockRecorder) GetUploadedOrdersCount(ctx, login, since interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUploadedOrdersCount", reflect.TypeOf((*MockStorage)(nil).GetUploadedOrdersCount), ctx, login, since)
+}
+
+// GetUser mocks base method.
+func (m *MockStorage) GetUser(ctx context.Context, login string) (storage.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUser", ctx, login)
+	ret0, _ := ret[0].(storage.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUser indicates an expected call of GetUser.
+func (mr *MockStorageMockRecorder) GetUser(ctx, login interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockStorage)(nil).GetUser), ctx, login)
+}
+
+// GetUserByReferralCode mocks base method.
+func (m *MockStorage) GetUserByReferralCode(ctx context.Context, code string) (storage.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByReferralCode", ctx, code)
+	ret0, _ := ret[0].(storage.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserByReferralCode indicates an expected call of GetUserByReferralCode.
+func (mr *MockStorageMockRecorder) GetUserByReferralCode(ctx, code interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByReferralCode", reflect.TypeOf((*MockStorage)(nil).GetUserByReferralCode), ctx, code)
+}
+
+// GetWebhookDeliveries mocks base method.
+func (m *MockStorage) GetWebhookDeliveries(ctx context.Context, webhookID int64, login string, limit int) ([]storage.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebhookDeliveries", ctx, webhookID, login, limit)
+	ret0, _ := ret[0].([]storage.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebhookDeliveries indicates an expected call of GetWebhookDeliveries.
+func (mr *MockStorageMockRecorder) GetWebhookDeliveries(ctx, webhookID, login, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhookDeliveries", reflect.TypeOf((*MockStorage)(nil).GetWebhookDeliveries), ctx, webhookID, login, limit)
+}
+
+// GetWebhookMessagesCountToDeliver mocks base method.
+func (m *MockStorage) GetWebhookMessagesCountToDeliver(ctx context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebhookMessagesCountToDeliver", ctx)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebhookMessagesCountToDeliver indicates an expected call of GetWebhookMessagesCountToDeliver.
+func (mr *MockStorageMockRecorder) GetWebhookMessagesCountToDeliver(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhookMessagesCountToDeliver", reflect.TypeOf((*MockStorage)(nil).GetWebhookMessagesCountToDeliver), ctx)
+}
+
+// GetWebhooks mocks base method.
+func (m *MockStorage) GetWebhooks(ctx context.Context, login string) ([]storage.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebhooks", ctx, login)
+	ret0, _ := ret[0].([]storage.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebhooks indicates an expected call of GetWebhooks.
+func (mr *MockStorageMockRecorder) GetWebhooks(ctx, login interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhooks", reflect.TypeOf((*MockStorage)(nil).GetWebhooks), ctx, login)
+}
+
+// Ping mocks base method.
+func (m *MockStorage) Ping(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Ping", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Ping indicates an expected call of Ping.
+func (mr *MockStorageMockRecorder) Ping(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ping", reflect.TypeOf((*MockStorage)(nil).Ping), ctx)
+}
+
+// ResolveFraudReview mocks base method.
+func (m *MockStorage) ResolveFraudReview(ctx context.Context, review storage.FraudReview) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResolveFraudReview", ctx, review)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResolveFraudReview indicates an expected call of ResolveFraudReview.
+func (mr *MockStorageMockRecorder) ResolveFraudReview(ctx, review interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResolveFraudReview", reflect.TypeOf((*MockStorage)(nil).ResolveFraudReview), ctx, review)
+}
+
+// SetUserRole mocks base method.
+func (m *MockStorage) SetUserRole(ctx context.Context, login, role string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserRole", ctx, login, role)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetUserRole indicates an expected call of SetUserRole.
+func (mr *MockStorageMockRecorder) SetUserRole(ctx, login, role interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserRole", reflect.TypeOf((*MockStorage)(nil).SetUserRole), ctx, login, role)
+}
+
+// SetUserTier mocks base method.
+func (m *MockStorage) SetUserTier(ctx context.Context, login, tier string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserTier", ctx, login, tier)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetUserTier indicates an expected call of SetUserTier.
+func (mr *MockStorageMockRecorder) SetUserTier(ctx, login, tier interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserTier", reflect.TypeOf((*MockStorage)(nil).SetUserTier), ctx, login, tier)
+}
+
+// StoreAdminAction mocks base method.
+func (m *MockStorage) StoreAdminAction(ctx context.Context, action storage.AdminAction) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StoreAdminAction", ctx, action)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StoreAdminAction indicates an expected call of StoreAdminAction.
+func (mr *MockStorageMockRecorder) StoreAdminAction(ctx, action interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StoreAdminAction", reflect.TypeOf((*MockStorage)(nil).StoreAdminAction), ctx, action)
+}
+
+// StoreAuditEvent mocks base method.
+func (m *MockStorage) StoreAuditEvent(ctx context.Context, event storage.AuditEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StoreAuditEvent", ctx, event)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StoreAuditEvent indicates an expected call of StoreAuditEvent.
+func (mr *MockStorageMockRecorder) StoreAuditEvent(ctx, event interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StoreAuditEvent", reflect.TypeOf((*MockStorage)(nil).StoreAuditEvent), ctx, event)
+}
+
+// StoreCampaign mocks base method.
+func (m *MockStorage) StoreCampaign(ctx context.Context, campaign storage.Campaign) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StoreCampaign", ctx, campaign)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StoreCampaign indicates an expected call of StoreCampaign.
+func (mr *MockStorageMockRecorder) StoreCampaign(ctx, campaign interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StoreCampaign", reflect.TypeOf((*MockStorage)(nil).StoreCampaign), ctx, campaign)
+}
+
+// StoreFraudReview mocks base method.
+func (m *MockStorage) StoreFraudReview(ctx context.Context, review storage.FraudReview) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StoreFraudReview", ctx, review)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StoreFraudReview indicates an expected call of StoreFraudReview.
+func (mr *MockStorageMockRecorder) StoreFraudReview(ctx, review interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StoreFraudReview", reflect.TypeOf((*MockStorage)(nil).StoreFraudReview), ctx, review)
+}
+
+// StoreOrder mocks base method.
+func (m *MockStorage) StoreOrder(ctx context.Context, number, login string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StoreOrder", ctx, number, login)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StoreOrder indicates an expected call of StoreOrder.
+func (mr *MockStorageMockRecorder) StoreOrder(ctx, number, login interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StoreOrder", reflect.TypeOf((*MockStorage)(nil).StoreOrder), ctx, number, login)
+}
+
+// StoreUser mocks base method.
+func (m *MockStorage) StoreUser(ctx context.Context, login, passwordHash string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StoreUser", ctx, login, passwordHash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StoreUser indicates an expected call of StoreUser.
+func (mr *MockStorageMockRecorder) StoreUser(ctx, login, passwordHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StoreUser", reflect.TypeOf((*MockStorage)(nil).StoreUser), ctx, login, passwordHash)
+}
+
+// StoreWebhook mocks base method.
+func (m *MockStorage) StoreWebhook(ctx context.Context, webhook storage.Webhook) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StoreWebhook", ctx, webhook)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StoreWebhook indicates an expected call of StoreWebhook.
+func (mr *MockStorageMockRecorder) StoreWebhook(ctx, webhook interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StoreWebhook", reflect.TypeOf((*MockStorage)(nil).StoreWebhook), ctx, webhook)
+}
+
+// TouchAPIKey mocks base method.
+func (m *MockStorage) TouchAPIKey(ctx context.Context, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TouchAPIKey", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// TouchAPIKey indicates an expected call of TouchAPIKey.
+func (mr *MockStorageMockRecorder) TouchAPIKey(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TouchAPIKey", reflect.TypeOf((*MockStorage)(nil).TouchAPIKey), ctx, id)
+}
+
+// UpdateCampaign mocks base method.
+func (m *MockStorage) UpdateCampaign(ctx context.Context, campaign storage.Campaign) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateCampaign", ctx, campaign)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateCampaign indicates an expected call of UpdateCampaign.
+func (mr *MockStorageMockRecorder) UpdateCampaign(ctx, campaign interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateCampaign", reflect.TypeOf((*MockStorage)(nil).UpdateCampaign), ctx, campaign)
+}
+
+// UseApprovedFraudReview mocks base method.
+func (m *MockStorage) UseApprovedFraudReview(ctx context.Context, login, action, orderNumber string, sum float32) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseApprovedFraudReview", ctx, login, action, orderNumber, sum)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UseApprovedFraudReview indicates an expected call of UseApprovedFraudReview.
+func (mr *MockStorageMockRecorder) UseApprovedFraudReview(ctx, login, action, orderNumber, sum interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseApprovedFraudReview", reflect.TypeOf((*MockStorage)(nil).UseApprovedFraudReview), ctx, login, action, orderNumber, sum)
+}
+
+// MockTransaction is a mock of Transaction interface.
+type MockTransaction struct {
+	ctrl     *gomock.Controller
+	recorder *MockTransactionMockRecorder
+}
+
+// MockTransactionMockRecorder is the mock recorder for MockTransaction.
+type MockTransactionMockRecorder struct {
+	mock *MockTransaction
+}
+
+// NewMockTransaction creates a new mock instance.
+func NewMockTransaction(ctrl *gomock.Controller) *MockTransaction {
+	mock := &MockTransaction{ctrl: ctrl}
+	mock.recorder = &MockTransactionMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTransaction) EXPECT() *MockTransactionMockRecorder {
+	return m.recorder
+}
+
+// AccrualUserBalance mocks base method.
+func (m *MockTransaction) AccrualUserBalance(ctx context.Context, accraul float32, login string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AccrualUserBalance", ctx, accraul, login)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AccrualUserBalance indicates an expected call of AccrualUserBalance.
+func (mr *MockTransactionMockRecorder) AccrualUserBalance(ctx, accraul, login interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AccrualUserBalance", reflect.TypeOf((*MockTransaction)(nil).AccrualUserBalance), ctx, accraul, login)
+}
+
+// CloseHold mocks base method.
+func (m *MockTransaction) CloseHold(ctx context.Context, hold storage.Hold, status string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CloseHold", ctx, hold, status)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CloseHold indicates an expected call of CloseHold.
+func (mr *MockTransactionMockRecorder) CloseHold(ctx, hold, status interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloseHold", reflect.TypeOf((*MockTransaction)(nil).CloseHold), ctx, hold, status)
+}
+
+// Commit mocks base method.
+func (m *MockTransaction) Commit(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Commit", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Commit indicates an expected call of Commit.
+func (mr *MockTransactionMockRecorder) Commit(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Commit", reflect.TypeOf((*MockTransaction)(nil).Commit), ctx)
+}
+
+// ConsumePointLots mocks base method.
+func (m *MockTransaction) ConsumePointLots(ctx context.Context, login string, amount float32) ([]storage.PointLot, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConsumePointLots", ctx, login, amount)
+	ret0, _ := ret[0].([]storage.PointLot)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConsumePointLots indicates an expected call of ConsumePointLots.
+func (mr *MockTransactionMockRecorder) ConsumePointLots(ctx, login, amount interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConsumePointLots", reflect.TypeOf((*MockTransaction)(nil).ConsumePointLots), ctx, login, amount)
+}
+
+// ExpirePointLots mocks base method.
+func (m *MockTransaction) ExpirePointLots(ctx context.Context, login string, amount float32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExpirePointLots", ctx, login, amount)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ExpirePointLots indicates an expected call of ExpirePointLots.
+func (mr *MockTransactionMockRecorder) ExpirePointLots(ctx, login, amount interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExpirePointLots", reflect.TypeOf((*MockTransaction)(nil).ExpirePointLots), ctx, login, amount)
+}
+
+// FlagUser mocks base method.
+func (m *MockTransaction) FlagUser(ctx context.Context, login, reason string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FlagUser", ctx, login, reason)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FlagUser indicates an expected call of FlagUser.
+func (mr *MockTransactionMockRecorder) FlagUser(ctx, login, reason interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FlagUser", reflect.TypeOf((*MockTransaction)(nil).FlagUser), ctx, login, reason)
+}
+
+// GetActiveCampaigns mocks base method.
+func (m *MockTransaction) GetActiveCampaigns(ctx context.Context, at time.Time) ([]storage.Campaign, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetActiveCampaigns", ctx, at)
+	ret0, _ := ret[0].([]storage.Campaign)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetActiveCampaigns indicates an expected call of GetActiveCampaigns.
+func (mr *MockTransactionMockRecorder) GetActiveCampaigns(ctx, at interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetActiveCampaigns", reflect.TypeOf((*MockTransaction)(nil).GetActiveCampaigns), ctx, at)
+}
+
+// GetExpiredPoints mocks base method.
+func (m *MockTransaction) GetExpiredPoints(ctx context.Context, login string) (float32, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetExpiredPoints", ctx, login)
+	ret0, _ := ret[0].(float32)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetExpiredPoints indicates an expected call of GetExpiredPoints.
+func (mr *MockTransactionMockRecorder) GetExpiredPoints(ctx, login interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExpiredPoints", reflect.TypeOf((*MockTransaction)(nil).GetExpiredPoints), ctx, login)
+}
+
+// GetHoldForUpdate mocks base method.
+func (m *MockTransaction) GetHoldForUpdate(ctx context.Context, id int64) (storage.Hold, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetHoldForUpdate", ctx, id)
+	ret0, _ := ret[0].(storage.Hold)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetHoldForUpdate indicates an expected call of GetHoldForUpdate.
+func (mr *MockTransactionMockRecorder) GetHoldForUpdate(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHoldForUpdate", reflect.TypeOf((*MockTransaction)(nil).GetHoldForUpdate), ctx, id)
+}
+
+// GetMovementsCount mocks base method.
+func (m *MockTransaction) GetMovementsCount(ctx context.Context, login, kind string, since time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMovementsCount", ctx, login, kind, since)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
-// CloseConnection indicates an expected call of CloseConnection.
-func (mr *MockStorageMockRecorder) CloseConnection() *gomock.Call {
+// GetMovementsCount indicates an expected call of GetMovementsCount.
+func (mr *MockTransactionMockRecorder) GetMovementsCount(ctx, login, kind, since interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloseConnection", reflect.TypeOf((*MockStorage)(nil).CloseConnection))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMovementsCount", reflect.TypeOf((*MockTransaction)(nil).GetMovementsCount), ctx, login, kind, since)
 }
 
-// GetOrder mocks base method.
-func (m *MockStorage) GetOrder(ctx context.Context, number string) (storage.Order, error) {
+// GetMovementsSum mocks base method.
+func (m *MockTransaction) GetMovementsSum(ctx context.Context, login, kind string, since time.Time) (float32, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetOrder", ctx, number)
+	ret := m.ctrl.Call(m, "GetMovementsSum", ctx, login, kind, since)
+	ret0, _ := ret[0].(float32)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMovementsSum indicates an expected call of GetMovementsSum.
+func (mr *MockTransactionMockRecorder) GetMovementsSum(ctx, login, kind, since interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMovementsSum", reflect.TypeOf((*MockTransaction)(nil).GetMovementsSum), ctx, login, kind, since)
+}
+
+// GetOrderForUpdate mocks base method.
+func (m *MockTransaction) GetOrderForUpdate(ctx context.Context, number string) (storage.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrderForUpdate", ctx, number)
 	ret0, _ := ret[0].(storage.Order)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetOrder indicates an expected call of GetOrder.
-func (mr *MockStorageMockRecorder) GetOrder(ctx, number interface{}) *gomock.Call {
+// GetOrderForUpdate indicates an expected call of GetOrderForUpdate.
+func (mr *MockTransactionMockRecorder) GetOrderForUpdate(ctx, number interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrder", reflect.TypeOf((*MockStorage)(nil).GetOrder), ctx, number)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrderForUpdate", reflect.TypeOf((*MockTransaction)(nil).GetOrderForUpdate), ctx, number)
 }
 
-// GetOrders mocks base method.
-func (m *MockStorage) GetOrders(ctx context.Context, login string, onlyWithdrawn bool) ([]storage.Order, error) {
+// GetOrderToUpdateStatus mocks base method.
+func (m *MockTransaction) GetOrderToUpdateStatus(ctx context.Context) (storage.OrderToUpdate, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetOrders", ctx, login, onlyWithdrawn)
-	ret0, _ := ret[0].([]storage.Order)
+	ret := m.ctrl.Call(m, "GetOrderToUpdateStatus", ctx)
+	ret0, _ := ret[0].(storage.OrderToUpdate)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetOrders indicates an expected call of GetOrders.
-func (mr *MockStorageMockRecorder) GetOrders(ctx, login, onlyWithdrawn interface{}) *gomock.Call {
+// GetOrderToUpdateStatus indicates an expected call of GetOrderToUpdateStatus.
+func (mr *MockTransactionMockRecorder) GetOrderToUpdateStatus(ctx interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrders", reflect.TypeOf((*MockStorage)(nil).GetOrders), ctx, login, onlyWithdrawn)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrderToUpdateStatus", reflect.TypeOf((*MockTransaction)(nil).GetOrderToUpdateStatus), ctx)
 }
 
-// GetOrdersCountToUpdate mocks base method.
-func (m *MockStorage) GetOrdersCountToUpdate(ctx context.Context) (int64, error) {
+// GetOrderWithLock mocks base method.
+func (m *MockTransaction) GetOrderWithLock(ctx context.Context, number, login string) (storage.Order, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetOrdersCountToUpdate", ctx)
+	ret := m.ctrl.Call(m, "GetOrderWithLock", ctx, number, login)
+	ret0, _ := ret[0].(storage.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrderWithLock indicates an expected call of GetOrderWithLock.
+func (mr *MockTransactionMockRecorder) GetOrderWithLock(ctx, number, login interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrderWithLock", reflect.TypeOf((*MockTransaction)(nil).GetOrderWithLock), ctx, number, login)
+}
+
+// GetPendingReferralForUpdate mocks base method.
+func (m *MockTransaction) GetPendingReferralForUpdate(ctx context.Context, referee string) (storage.Referral, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPendingReferralForUpdate", ctx, referee)
+	ret0, _ := ret[0].(storage.Referral)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPendingReferralForUpdate indicates an expected call of GetPendingReferralForUpdate.
+func (mr *MockTransactionMockRecorder) GetPendingReferralForUpdate(ctx, referee interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPendingReferralForUpdate", reflect.TypeOf((*MockTransaction)(nil).GetPendingReferralForUpdate), ctx, referee)
+}
+
+// GetProcessedOrdersCount mocks base method.
+func (m *MockTransaction) GetProcessedOrdersCount(ctx context.Context, login string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetProcessedOrdersCount", ctx, login)
 	ret0, _ := ret[0].(int64)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetOrdersCountToUpdate indicates an expected call of GetOrdersCountToUpdate.
-func (mr *MockStorageMockRecorder) GetOrdersCountToUpdate(ctx interface{}) *gomock.Call {
+// GetProcessedOrdersCount indicates an expected call of GetProcessedOrdersCount.
+func (mr *MockTransactionMockRecorder) GetProcessedOrdersCount(ctx, login interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrdersCountToUpdate", reflect.TypeOf((*MockStorage)(nil).GetOrdersCountToUpdate), ctx)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProcessedOrdersCount", reflect.TypeOf((*MockTransaction)(nil).GetProcessedOrdersCount), ctx, login)
 }
 
-// GetUser mocks base method.
-func (m *MockStorage) GetUser(ctx context.Context, login string) (storage.User, error) {
+// GetReferralsCount mocks base method.
+func (m *MockTransaction) GetReferralsCount(ctx context.Context, referrer string) (int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUser", ctx, login)
+	ret := m.ctrl.Call(m, "GetReferralsCount", ctx, referrer)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetReferralsCount indicates an expected call of GetReferralsCount.
+func (mr *MockTransactionMockRecorder) GetReferralsCount(ctx, referrer interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReferralsCount", reflect.TypeOf((*MockTransaction)(nil).GetReferralsCount), ctx, referrer)
+}
+
+// GetUserWithLock mocks base method.
+func (m *MockTransaction) GetUserWithLock(ctx context.Context, login string) (storage.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserWithLock", ctx, login)
 	ret0, _ := ret[0].(storage.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetUser indicates an expected call of GetUser.
-func (mr *MockStorageMockRecorder) GetUser(ctx, login interface{}) *gomock.Call {
+// GetUserWithLock indicates an expected call of GetUserWithLock.
+func (mr *MockTransactionMockRecorder) GetUserWithLock(ctx, login interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockStorage)(nil).GetUser), ctx, login)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserWithLock", reflect.TypeOf((*MockTransaction)(nil).GetUserWithLock), ctx, login)
 }
 
-// StoreOrder mocks base method.
-func (m *MockStorage) StoreOrder(ctx context.Context, number, login string) error {
+// GetWebhookMessageToDeliver mocks base method.
+func (m *MockTransaction) GetWebhookMessageToDeliver(ctx context.Context) (storage.WebhookMessage, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "StoreOrder", ctx, number, login)
+	ret := m.ctrl.Call(m, "GetWebhookMessageToDeliver", ctx)
+	ret0, _ := ret[0].(storage.WebhookMessage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebhookMessageToDeliver indicates an expected call of GetWebhookMessageToDeliver.
+func (mr *MockTransactionMockRecorder) GetWebhookMessageToDeliver(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhookMessageToDeliver", reflect.TypeOf((*MockTransaction)(nil).GetWebhookMessageToDeliver), ctx)
+}
+
+// InsertOrder mocks base method.
+func (m *MockTransaction) InsertOrder(ctx context.Context, number, login string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertOrder", ctx, number, login)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// InsertOrder indicates an expected call of InsertOrder.
+func (mr *MockTransactionMockRecorder) InsertOrder(ctx, number, login interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertOrder", reflect.TypeOf((*MockTransaction)(nil).InsertOrder), ctx, number, login)
+}
+
+// RevokeAPIKey mocks base method.
+func (m *MockTransaction) RevokeAPIKey(ctx context.Context, id int64, login string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeAPIKey", ctx, id, login)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// StoreOrder indicates an expected call of StoreOrder.
-func (mr *MockStorageMockRecorder) StoreOrder(ctx, number, login interface{}) *gomock.Call {
+// RevokeAPIKey indicates an expected call of RevokeAPIKey.
+func (mr *MockTransactionMockRecorder) RevokeAPIKey(ctx, id, login interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StoreOrder", reflect.TypeOf((*MockStorage)(nil).StoreOrder), ctx, number, login)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeAPIKey", reflect.TypeOf((*MockTransaction)(nil).RevokeAPIKey), ctx, id, login)
 }
 
-// StoreUser mocks base method.
-func (m *MockStorage) StoreUser(ctx context.Context, login, passwordHash string) error {
+// RewardReferral mocks base method.
+func (m *MockTransaction) RewardReferral(ctx context.Context, referral storage.Referral) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "StoreUser", ctx, login, passwordHash)
+	ret := m.ctrl.Call(m, "RewardReferral", ctx, referral)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// StoreUser indicates an expected call of StoreUser.
-func (mr *MockStorageMockRecorder) StoreUser(ctx, login, passwordHash interface{}) *gomock.Call {
+// RewardReferral indicates an expected call of RewardReferral.
+func (mr *MockTransactionMockRecorder) RewardReferral(ctx, referral interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StoreUser", reflect.TypeOf((*MockStorage)(nil).StoreUser), ctx, login, passwordHash)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RewardReferral", reflect.TypeOf((*MockTransaction)(nil).RewardReferral), ctx, referral)
 }
 
-// MockTransaction is a mock of Transaction interface.
-type MockTransaction struct {
-	ctrl     *gomock.Controller
-	recorder *MockTransactionMockRecorder
+// Rollback mocks base method.
+func (m *MockTransaction) Rollback(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Rollback", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
 }
 
-// MockTransactionMockRecorder is the mock recorder for MockTransaction.
-type MockTransactionMockRecorder struct {
-	mock *MockTransaction
+// Rollback indicates an expected call of Rollback.
+func (mr *MockTransactionMockRecorder) Rollback(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Rollback", reflect.TypeOf((*MockTransaction)(nil).Rollback), ctx)
 }
 
-// NewMockTransaction creates a new mock instance.
-func NewMockTransaction(ctrl *gomock.Controller) *MockTransaction {
-	mock := &MockTransaction{ctrl: ctrl}
-	mock.recorder = &MockTransactionMockRecorder{mock}
-	return mock
+// SetUserReferral mocks base method.
+func (m *MockTransaction) SetUserReferral(ctx context.Context, login, referralCode, registrationIP string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserReferral", ctx, login, referralCode, registrationIP)
+	ret0, _ := ret[0].(error)
+	return ret0
 }
 
-// EXPECT returns an object that allows the caller to indicate expected use.
-func (m *MockTransaction) EXPECT() *MockTransactionMockRecorder {
-	return m.recorder
+// SetUserReferral indicates an expected call of SetUserReferral.
+func (mr *MockTransactionMockRecorder) SetUserReferral(ctx, login, referralCode, registrationIP interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserReferral", reflect.TypeOf((*MockTransaction)(nil).SetUserReferral), ctx, login, referralCode, registrationIP)
 }
 
-// AccrualUserBalance mocks base method.
-func (m *MockTransaction) AccrualUserBalance(ctx context.Context, accraul float32, login string) error {
+// StoreAPIKey mocks base method.
+func (m *MockTransaction) StoreAPIKey(ctx context.Context, key storage.APIKey) (int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "AccrualUserBalance", ctx, accraul, login)
+	ret := m.ctrl.Call(m, "StoreAPIKey", ctx, key)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StoreAPIKey indicates an expected call of StoreAPIKey.
+func (mr *MockTransactionMockRecorder) StoreAPIKey(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StoreAPIKey", reflect.TypeOf((*MockTransaction)(nil).StoreAPIKey), ctx, key)
+}
+
+// StoreAuditEvent mocks base method.
+func (m *MockTransaction) StoreAuditEvent(ctx context.Context, event storage.AuditEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StoreAuditEvent", ctx, event)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// AccrualUserBalance indicates an expected call of AccrualUserBalance.
-func (mr *MockTransactionMockRecorder) AccrualUserBalance(ctx, accraul, login interface{}) *gomock.Call {
+// StoreAuditEvent indicates an expected call of StoreAuditEvent.
+func (mr *MockTransactionMockRecorder) StoreAuditEvent(ctx, event interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AccrualUserBalance", reflect.TypeOf((*MockTransaction)(nil).AccrualUserBalance), ctx, accraul, login)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StoreAuditEvent", reflect.TypeOf((*MockTransaction)(nil).StoreAuditEvent), ctx, event)
 }
 
-// Commit mocks base method.
-func (m *MockTransaction) Commit(ctx context.Context) error {
+// StoreBalanceMovement mocks base method.
+func (m *MockTransaction) StoreBalanceMovement(ctx context.Context, movement storage.BalanceMovement) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Commit", ctx)
+	ret := m.ctrl.Call(m, "StoreBalanceMovement", ctx, movement)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// Commit indicates an expected call of Commit.
-func (mr *MockTransactionMockRecorder) Commit(ctx interface{}) *gomock.Call {
+// StoreBalanceMovement indicates an expected call of StoreBalanceMovement.
+func (mr *MockTransactionMockRecorder) StoreBalanceMovement(ctx, movement interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Commit", reflect.TypeOf((*MockTransaction)(nil).Commit), ctx)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StoreBalanceMovement", reflect.TypeOf((*MockTransaction)(nil).StoreBalanceMovement), ctx, movement)
 }
 
-// GetOrderToUpdateStatus mocks base method.
-func (m *MockTransaction) GetOrderToUpdateStatus(ctx context.Context) (storage.OrderToUpdate, error) {
+// StoreHold mocks base method.
+func (m *MockTransaction) StoreHold(ctx context.Context, hold storage.Hold) (int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetOrderToUpdateStatus", ctx)
-	ret0, _ := ret[0].(storage.OrderToUpdate)
+	ret := m.ctrl.Call(m, "StoreHold", ctx, hold)
+	ret0, _ := ret[0].(int64)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetOrderToUpdateStatus indicates an expected call of GetOrderToUpdateStatus.
-func (mr *MockTransactionMockRecorder) GetOrderToUpdateStatus(ctx interface{}) *gomock.Call {
+// StoreHold indicates an expected call of StoreHold.
+func (mr *MockTransactionMockRecorder) StoreHold(ctx, hold interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrderToUpdateStatus", reflect.TypeOf((*MockTransaction)(nil).GetOrderToUpdateStatus), ctx)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StoreHold", reflect.TypeOf((*MockTransaction)(nil).StoreHold), ctx, hold)
 }
 
-// GetOrderWithLock mocks base method.
-func (m *MockTransaction) GetOrderWithLock(ctx context.Context, number, login string) (storage.Order, error) {
+// StoreOrderChange mocks base method.
+func (m *MockTransaction) StoreOrderChange(ctx context.Context, change storage.OrderChange) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetOrderWithLock", ctx, number, login)
-	ret0, _ := ret[0].(storage.Order)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
+	ret := m.ctrl.Call(m, "StoreOrderChange", ctx, change)
+	ret0, _ := ret[0].(error)
+	return ret0
 }
 
-// GetOrderWithLock indicates an expected call of GetOrderWithLock.
-func (mr *MockTransactionMockRecorder) GetOrderWithLock(ctx, number, login interface{}) *gomock.Call {
+// StoreOrderChange indicates an expected call of StoreOrderChange.
+func (mr *MockTransactionMockRecorder) StoreOrderChange(ctx, change interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrderWithLock", reflect.TypeOf((*MockTransaction)(nil).GetOrderWithLock), ctx, number, login)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StoreOrderChange", reflect.TypeOf((*MockTransaction)(nil).StoreOrderChange), ctx, change)
 }
 
-// GetUserWithLock mocks base method.
-func (m *MockTransaction) GetUserWithLock(ctx context.Context, login string) (storage.User, error) {
+// StorePointLot mocks base method.
+func (m *MockTransaction) StorePointLot(ctx context.Context, lot storage.PointLot) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUserWithLock", ctx, login)
-	ret0, _ := ret[0].(storage.User)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
+	ret := m.ctrl.Call(m, "StorePointLot", ctx, lot)
+	ret0, _ := ret[0].(error)
+	return ret0
 }
 
-// GetUserWithLock indicates an expected call of GetUserWithLock.
-func (mr *MockTransactionMockRecorder) GetUserWithLock(ctx, login interface{}) *gomock.Call {
+// StorePointLot indicates an expected call of StorePointLot.
+func (mr *MockTransactionMockRecorder) StorePointLot(ctx, lot interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserWithLock", reflect.TypeOf((*MockTransaction)(nil).GetUserWithLock), ctx, login)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StorePointLot", reflect.TypeOf((*MockTransaction)(nil).StorePointLot), ctx, lot)
 }
 
-// Rollback mocks base method.
-func (m *MockTransaction) Rollback(ctx context.Context) error {
+// StoreProvisionalOrderStatus mocks base method.
+func (m *MockTransaction) StoreProvisionalOrderStatus(ctx context.Context, order storage.OrderStatus) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Rollback", ctx)
+	ret := m.ctrl.Call(m, "StoreProvisionalOrderStatus", ctx, order)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// Rollback indicates an expected call of Rollback.
-func (mr *MockTransactionMockRecorder) Rollback(ctx interface{}) *gomock.Call {
+// StoreProvisionalOrderStatus indicates an expected call of StoreProvisionalOrderStatus.
+func (mr *MockTransactionMockRecorder) StoreProvisionalOrderStatus(ctx, order interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Rollback", reflect.TypeOf((*MockTransaction)(nil).Rollback), ctx)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StoreProvisionalOrderStatus", reflect.TypeOf((*MockTransaction)(nil).StoreProvisionalOrderStatus), ctx, order)
+}
+
+// StorePushedOrderStatus mocks base method.
+func (m *MockTransaction) StorePushedOrderStatus(ctx context.Context, order storage.OrderStatus) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StorePushedOrderStatus", ctx, order)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StorePushedOrderStatus indicates an expected call of StorePushedOrderStatus.
+func (mr *MockTransactionMockRecorder) StorePushedOrderStatus(ctx, order interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StorePushedOrderStatus", reflect.TypeOf((*MockTransaction)(nil).StorePushedOrderStatus), ctx, order)
+}
+
+// StoreReferral mocks base method.
+func (m *MockTransaction) StoreReferral(ctx context.Context, referral storage.Referral) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StoreReferral", ctx, referral)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StoreReferral indicates an expected call of StoreReferral.
+func (mr *MockTransactionMockRecorder) StoreReferral(ctx, referral interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StoreReferral", reflect.TypeOf((*MockTransaction)(nil).StoreReferral), ctx, referral)
+}
+
+// StoreUser mocks base method.
+func (m *MockTransaction) StoreUser(ctx context.Context, login, passwordHash string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StoreUser", ctx, login, passwordHash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StoreUser indicates an expected call of StoreUser.
+func (mr *MockTransactionMockRecorder) StoreUser(ctx, login, passwordHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StoreUser", reflect.TypeOf((*MockTransaction)(nil).StoreUser), ctx, login, passwordHash)
+}
+
+// StoreWebhookDelivery mocks base method.
+func (m *MockTransaction) StoreWebhookDelivery(ctx context.Context, delivery storage.WebhookDelivery) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StoreWebhookDelivery", ctx, delivery)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StoreWebhookDelivery indicates an expected call of StoreWebhookDelivery.
+func (mr *MockTransactionMockRecorder) StoreWebhookDelivery(ctx, delivery interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StoreWebhookDelivery", reflect.TypeOf((*MockTransaction)(nil).StoreWebhookDelivery), ctx, delivery)
+}
+
+// StoreWebhookMessages mocks base method.
+func (m *MockTransaction) StoreWebhookMessages(ctx context.Context, login, event string, payload []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StoreWebhookMessages", ctx, login, event, payload)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StoreWebhookMessages indicates an expected call of StoreWebhookMessages.
+func (mr *MockTransactionMockRecorder) StoreWebhookMessages(ctx, login, event, payload interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StoreWebhookMessages", reflect.TypeOf((*MockTransaction)(nil).StoreWebhookMessages), ctx, login, event, payload)
 }
 
 // UpdateOrderStatus mocks base method.
@@ -274,6 +1283,20 @@ func (mr *MockTransactionMockRecorder) UpdateOrderStatus(ctx, order interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateOrderStatus", reflect.TypeOf((*MockTransaction)(nil).UpdateOrderStatus), ctx, order)
 }
 
+// UpdateWebhookMessage mocks base method.
+func (m *MockTransaction) UpdateWebhookMessage(ctx context.Context, message storage.WebhookMessage) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateWebhookMessage", ctx, message)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateWebhookMessage indicates an expected call of UpdateWebhookMessage.
+func (mr *MockTransactionMockRecorder) UpdateWebhookMessage(ctx, message interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateWebhookMessage", reflect.TypeOf((*MockTransaction)(nil).UpdateWebhookMessage), ctx, message)
+}
+
 // WithdrawOrderBalance mocks base method.
 func (m *MockTransaction) WithdrawOrderBalance(ctx context.Context, number string, withdraw float32) error {
 	m.ctrl.T.Helper()
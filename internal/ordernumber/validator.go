@@ -0,0 +1,95 @@
+package ordernumber
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ShiraazMoollatjie/goluhn"
+
+	"github.com/pisarevaa/gophermart/internal/configs"
+)
+
+// Коды ошибок проверки номера заказа, возвращаются в поле code ответа.
+const (
+	CodeEmpty      = "order_number_empty"
+	CodePrefix     = "order_number_prefix"
+	CodeCharacters = "order_number_characters"
+	CodeLength     = "order_number_length"
+	CodeChecksum   = "order_number_checksum"
+)
+
+const digits = "0123456789"
+
+// Error - нарушенное правило формата номера заказа.
+type Error struct {
+	Code    string
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Validator проверяет номера заказов по правилам партнеров.
+type Validator struct {
+	rules []configs.OrderNumberRule
+}
+
+func NewValidator(rules []configs.OrderNumberRule) *Validator {
+	return &Validator{rules: rules}
+}
+
+// Validate очищает номер от пробельных символов по краям и проверяет его правилом партнера,
+// к которому номер относится по префиксу. Возвращает очищенный номер или *Error.
+func (v *Validator) Validate(raw string) (string, error) {
+	number := strings.TrimSpace(raw)
+	if number == "" {
+		return number, &Error{Code: CodeEmpty, Message: "order number is empty"}
+	}
+	rule, ok := v.match(number)
+	if !ok {
+		return number, &Error{Code: CodePrefix, Message: "order number prefix does not match any partner"}
+	}
+	charset := rule.Charset
+	if charset == "" {
+		charset = digits
+	}
+	if strings.IndexFunc(number, func(r rune) bool { return !strings.ContainsRune(charset, r) }) >= 0 {
+		return number, &Error{
+			Code:    CodeCharacters,
+			Message: fmt.Sprintf("order number of %s contains not allowed characters", rule.Partner),
+		}
+	}
+	length := len([]rune(number))
+	if length < rule.MinLength || length > rule.MaxLength {
+		return number, &Error{
+			Code:    CodeLength,
+			Message: fmt.Sprintf("order number of %s length must be between %d and %d", rule.Partner, rule.MinLength, rule.MaxLength),
+		}
+	}
+	if !rule.SkipLuhn {
+		if err := goluhn.Validate(number); err != nil {
+			return number, &Error{Code: CodeChecksum, Message: "order number checksum is wrong"}
+		}
+	}
+	return number, nil
+}
+
+// match выбирает правило с самым длинным совпавшим префиксом, а без совпадений - правило без префиксов.
+func (v *Validator) match(number string) (configs.OrderNumberRule, bool) {
+	var matched configs.OrderNumberRule
+	matchedLength := -1
+	for _, rule := range v.rules {
+		if len(rule.Prefixes) == 0 && matchedLength < 0 {
+			matched = rule
+			matchedLength = 0
+		}
+		for _, prefix := range rule.Prefixes {
+			if strings.HasPrefix(number, prefix) && len(prefix) > matchedLength {
+				matched = rule
+				matchedLength = len(prefix)
+			}
+		}
+	}
+	return matched, matchedLength >= 0
+}
@@ -0,0 +1,67 @@
+package ordernumber_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/pisarevaa/gophermart/internal/configs"
+	"github.com/pisarevaa/gophermart/internal/ordernumber"
+)
+
+type ValidatorTestSuite struct {
+	suite.Suite
+	validator *ordernumber.Validator
+}
+
+func (suite *ValidatorTestSuite) SetupSuite() {
+	suite.validator = ordernumber.NewValidator([]configs.OrderNumberRule{
+		{Partner: "default", MinLength: 2, MaxLength: 16},
+		{Partner: "megamarket", Prefixes: []string{"99"}, MinLength: 11, MaxLength: 11},
+		{Partner: "coupons", Prefixes: []string{"CP-"}, MinLength: 8, MaxLength: 12, Charset: "CP-0123456789", SkipLuhn: true},
+	})
+}
+
+func TestValidatorSuite(t *testing.T) {
+	suite.Run(t, new(ValidatorTestSuite))
+}
+
+func (suite *ValidatorTestSuite) requireCode(raw string, code string) {
+	_, err := suite.validator.Validate(raw)
+	var numberErr *ordernumber.Error
+	suite.Require().ErrorAs(err, &numberErr, raw)
+	suite.Require().Equal(code, numberErr.Code, raw)
+}
+
+func (suite *ValidatorTestSuite) TestValid() {
+	number, err := suite.validator.Validate(" 12345678903\n")
+	suite.Require().NoError(err)
+	suite.Require().Equal("12345678903", number)
+
+	number, err = suite.validator.Validate("99000000002")
+	suite.Require().NoError(err)
+	suite.Require().Equal("99000000002", number)
+
+	number, err = suite.validator.Validate("CP-123456")
+	suite.Require().NoError(err)
+	suite.Require().Equal("CP-123456", number)
+}
+
+func (suite *ValidatorTestSuite) TestErrors() {
+	suite.requireCode(" \n", ordernumber.CodeEmpty)
+	suite.requireCode("1234 5678903", ordernumber.CodeCharacters)
+	suite.requireCode("CP-12a456", ordernumber.CodeCharacters)
+	suite.requireCode("4561261212345467000", ordernumber.CodeLength)
+	suite.requireCode("990000000008", ordernumber.CodeLength)
+	suite.requireCode("12345678900", ordernumber.CodeChecksum)
+}
+
+func (suite *ValidatorTestSuite) TestPrefix() {
+	validator := ordernumber.NewValidator([]configs.OrderNumberRule{
+		{Partner: "megamarket", Prefixes: []string{"99"}, MinLength: 11, MaxLength: 11},
+	})
+	_, err := validator.Validate("12345678903")
+	var numberErr *ordernumber.Error
+	suite.Require().ErrorAs(err, &numberErr)
+	suite.Require().Equal(ordernumber.CodePrefix, numberErr.Code)
+}
@@ -1,11 +1,15 @@
 package server
 
 import (
+	"time"
+
 	"github.com/gin-contrib/gzip"
 	"github.com/gin-gonic/gin"
 
 	docs "github.com/pisarevaa/gophermart/docs"
+	"github.com/pisarevaa/gophermart/internal/accrual"
 	"github.com/pisarevaa/gophermart/internal/configs"
+	"github.com/pisarevaa/gophermart/internal/events"
 	"github.com/pisarevaa/gophermart/internal/handlers"
 	"github.com/pisarevaa/gophermart/internal/storage"
 	"github.com/pisarevaa/gophermart/internal/utils"
@@ -14,30 +18,104 @@ import (
 	"go.uber.org/zap"
 )
 
-func NewRouter(cfg configs.Config, logger *zap.SugaredLogger, repo storage.Storage) *gin.Engine {
-	s := handlers.NewController(cfg, logger, repo)
+func NewRouter(
+	cfg configs.Config,
+	logger *zap.SugaredLogger,
+	repo storage.Storage,
+	broker *events.Broker,
+	accrualRouter *accrual.Router,
+) *gin.Engine {
+	s := handlers.NewController(cfg, logger, repo, broker, accrualRouter)
 	if cfg.GinMode == gin.ReleaseMode {
 		gin.SetMode(gin.ReleaseMode)
 	}
 	r := gin.Default()
-	r.Use(gzip.Gzip(gzip.DefaultCompression))
+	// Без доверенных прокси адрес клиента берется из соединения, а не из подделываемого X-Forwarded-For
+	if err := r.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		logger.Fatal("unable to set trusted proxies: ", err)
+	}
+	// Поток событий не сжимается, иначе gzip буферизует ответ и клиент не получает события сразу
+	r.Use(
+		gzip.Gzip(gzip.DefaultCompression, gzip.WithExcludedPaths([]string{"/api/user/orders/events"})),
+		utils.RequestID(),
+		utils.Problems(logger),
+	)
 	docs.SwaggerInfo.BasePath = "/"
+	apiKeyLimiter := utils.NewRateLimiter(time.Minute)
+
+	r.GET("/api/ready", s.Ready)
 
 	api := r.Group("/api/user")
 	{
 		api.POST("/register", s.RegisterUser)
 		api.POST("/login", s.LoginUser)
 		authorized := api.Group("/")
-		authorized.Use(utils.JWTAuth(cfg.SecretKey))
+		authorized.Use(utils.Auth(cfg.SecretKey, repo, apiKeyLimiter, int(cfg.APIKeyRateLimit)))
 		{
-			authorized.POST("/orders", s.AddOrder)
-			authorized.GET("/orders", s.GetOrders)
-			authorized.GET("/balance", s.GetBalance)
-			authorized.POST("/balance/withdraw", s.WithdrawBalance)
-			authorized.GET("/withdrawals", s.Withdrawls)
+			authorized.POST("/orders", utils.RequireScope(utils.ScopeOrdersWrite), s.AddOrder)
+			authorized.POST("/orders/batch", utils.RequireScope(utils.ScopeOrdersWrite), s.AddOrdersBatch)
+			authorized.GET("/orders", utils.RequireScope(utils.ScopeOrdersRead), s.GetOrders)
+			authorized.GET("/orders/events", utils.RequireScope(utils.ScopeOrdersRead), s.StreamEvents)
+			authorized.GET("/orders/:number", utils.RequireScope(utils.ScopeOrdersRead), s.GetOrder)
+			authorized.GET("/balance", utils.RequireScope(utils.ScopeBalanceRead), s.GetBalance)
+			authorized.POST("/balance/withdraw", utils.RequireScope(utils.ScopeBalanceWrite), s.WithdrawBalance)
+			authorized.POST("/balance/transfer", utils.RequireScope(utils.ScopeBalanceWrite), s.TransferBalance)
+			authorized.GET("/balance/history", utils.RequireScope(utils.ScopeBalanceRead), s.GetBalanceHistory)
+			authorized.POST("/balance/holds", utils.RequireScope(utils.ScopeBalanceWrite), s.CreateHold)
+			authorized.GET("/balance/holds", utils.RequireScope(utils.ScopeBalanceRead), s.GetHolds)
+			authorized.POST("/balance/holds/:id/capture", utils.RequireScope(utils.ScopeBalanceWrite), s.CaptureHold)
+			authorized.POST("/balance/holds/:id/void", utils.RequireScope(utils.ScopeBalanceWrite), s.VoidHold)
+			authorized.GET("/withdrawals", utils.RequireScope(utils.ScopeBalanceRead), s.Withdrawls)
+			authorized.GET("/profile", utils.RequireScope(utils.ScopeBalanceRead), s.GetProfile)
+			authorized.GET("/referrals", utils.RequireScope(utils.ScopeBalanceRead), s.GetReferrals)
+
+			apiKeys := authorized.Group("/api-keys")
+			apiKeys.Use(utils.RequireJWT())
+			{
+				apiKeys.POST("", s.CreateAPIKey)
+				apiKeys.GET("", s.GetAPIKeys)
+				apiKeys.DELETE("/:id", s.RevokeAPIKey)
+			}
+
+			webhooks := authorized.Group("/webhooks")
+			webhooks.Use(utils.RequireJWT())
+			{
+				webhooks.POST("", s.CreateWebhook)
+				webhooks.GET("", s.GetWebhooks)
+				webhooks.DELETE("/:id", s.DeleteWebhook)
+				webhooks.GET("/:id/deliveries", s.GetWebhookDeliveries)
+			}
 		}
 	}
 
+	admin := r.Group("/api/admin")
+	admin.Use(utils.JWTAuth(cfg.SecretKey), utils.RoleAuth(repo, storage.RoleSupport, storage.RoleAdmin), s.AdminAudit())
+	{
+		admin.GET("/users/:login", s.AdminGetUser)
+		admin.GET("/users/:login/orders", s.AdminGetUserOrders)
+		admin.GET("/users/:login/withdrawals", s.AdminGetUserWithdrawals)
+		admin.POST("/users/:login/balance", s.AdminAdjustBalance)
+		admin.PUT("/users/:login/role", utils.RoleAuth(repo, storage.RoleAdmin), s.AdminSetRole)
+		admin.POST("/orders/:number/repoll", s.AdminRepollOrder)
+		admin.PUT("/orders/:number/status", s.AdminOverrideOrderStatus)
+		admin.POST("/orders/:number/reversal", s.AdminReverseOrder)
+		admin.GET("/flagged-users", s.AdminGetFlaggedUsers)
+		admin.DELETE("/users/:login/flag", s.AdminClearUserFlag)
+		admin.GET("/audit", s.GetAuditEvents)
+		admin.GET("/campaigns", s.AdminGetCampaigns)
+		admin.POST("/campaigns", utils.RoleAuth(repo, storage.RoleAdmin), s.AdminCreateCampaign)
+		admin.PUT("/campaigns/:id", utils.RoleAuth(repo, storage.RoleAdmin), s.AdminUpdateCampaign)
+		admin.DELETE("/campaigns/:id", utils.RoleAuth(repo, storage.RoleAdmin), s.AdminDeleteCampaign)
+		admin.GET("/fraud-reviews", s.AdminGetFraudReviews)
+		admin.POST("/fraud-reviews/:id", s.AdminResolveFraudReview)
+	}
+
+	// Прием результатов от системы расчета начислений включается только при заданном общем секрете,
+	// фоновый опрос продолжает работать для заказов без присланного результата
+	if cfg.AccrualPushSecret != "" {
+		r.POST("/api/internal/accrual", utils.SignatureAuth(cfg.AccrualPushSecret), s.PushAccrual)
+	}
+
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 	return r
 }
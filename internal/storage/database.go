@@ -2,6 +2,8 @@ package storage
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/golang-migrate/migrate/v4"
@@ -12,6 +14,18 @@ import (
 	"go.uber.org/zap"
 )
 
+// Заказы, по которым недавно пришел промежуточный статус от системы расчета начислений,
+// не опрашиваются фоновой задачей, пока не истечет PushedStatusTTL.
+const ordersToUpdateSQL = `
+	(status = 'NEW' OR status = 'PROCESSING' OR status = 'REGISTERED')
+	AND (accrual_pushed_at IS NULL OR accrual_pushed_at < NOW() - make_interval(secs => $1))
+`
+
+const insertAuditEventSQL = `
+	INSERT INTO audit_events (actor, action, target, amount_before, amount_after, request_id)
+	VALUES ($1, $2, $3, $4, $5, $6)
+`
+
 type DBStorage struct {
 	*pgxpool.Pool
 }
@@ -38,16 +52,163 @@ func NewDB(databaseURI string, logger *zap.SugaredLogger) *DBStorage {
 	return db
 }
 
-func (dbpool *DBStorage) GetUser(ctx context.Context, login string) (User, error) {
+const selectUserSQL = `
+	SELECT login, password, balance, withdrawn, held, role, tier, flagged_at, flag_reason,
+		COALESCE(referral_code, ''), registration_ip, created_at
+	FROM users
+`
+
+func scanUser(row pgx.Row) (User, error) {
 	var user User
-	err := dbpool.QueryRow(ctx, "SELECT login, password, balance, withdrawn FROM users WHERE login = $1", login).
-		Scan(&user.Login, &user.Password, &user.Balance, &user.Withdrawn)
+	err := row.Scan(
+		&user.Login, &user.Password, &user.Balance, &user.Withdrawn, &user.Held, &user.Role, &user.Tier,
+		&user.FlaggedAt, &user.FlagReason, &user.ReferralCode, &user.RegistrationIP, &user.CreatedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return user, ErrUserNotFound
+	}
 	if err != nil {
 		return user, err
 	}
 	return user, nil
 }
 
+func (dbpool *DBStorage) GetUser(ctx context.Context, login string) (User, error) {
+	return scanUser(dbpool.QueryRow(ctx, selectUserSQL+"WHERE login = $1", login))
+}
+
+func (dbpool *DBStorage) GetUserByReferralCode(ctx context.Context, code string) (User, error) {
+	return scanUser(dbpool.QueryRow(ctx, selectUserSQL+"WHERE referral_code = $1", code))
+}
+
+func (dbpool *DBStorage) GetUploadedOrdersCount(ctx context.Context, login string, since time.Time) (int64, error) {
+	var count int64
+	err := dbpool.QueryRow(ctx, "SELECT COUNT(*) FROM orders WHERE login = $1 AND uploaded_at >= $2", login, since).
+		Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (dbpool *DBStorage) GetOrdersCountByStatus(ctx context.Context, login string) (map[string]int64, error) {
+	counts := make(map[string]int64)
+	rows, err := dbpool.Query(ctx, "SELECT status, COUNT(*) FROM orders WHERE login = $1 GROUP BY status", login)
+	if err != nil {
+		return counts, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var status string
+		var count int64
+		err = rows.Scan(&status, &count)
+		if err != nil {
+			return counts, err
+		}
+		counts[status] = count
+	}
+	return counts, nil
+}
+
+func (dbpool *DBStorage) StoreFraudReview(ctx context.Context, review FraudReview) (int64, error) {
+	var id int64
+	err := dbpool.QueryRow(ctx, `
+			INSERT INTO fraud_reviews (login, action, order_number, sum, score, decision, reasons, status)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id
+		`, review.Login, review.Action, review.OrderNumber, review.Sum, review.Score, review.Decision, review.Reasons,
+		FraudReviewPending).
+		Scan(&id)
+	if err != nil {
+		return id, err
+	}
+	return id, nil
+}
+
+func (dbpool *DBStorage) GetFraudReviews(ctx context.Context, status string, limit int) ([]FraudReview, error) {
+	var reviews []FraudReview
+	rows, err := dbpool.Query(ctx, `
+			SELECT id, login, action, order_number, sum, score, decision, reasons, status, COALESCE(operator, ''),
+				comment, created_at, resolved_at, used_at
+			FROM fraud_reviews WHERE status = $1 ORDER BY created_at ASC, id ASC LIMIT $2
+		`, status, limit)
+	if err != nil {
+		return []FraudReview{}, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var r FraudReview
+		err = rows.Scan(
+			&r.ID, &r.Login, &r.Action, &r.OrderNumber, &r.Sum, &r.Score, &r.Decision, &r.Reasons, &r.Status,
+			&r.Operator, &r.Comment, &r.CreatedAt, &r.ResolvedAt, &r.UsedAt,
+		)
+		if err != nil {
+			return []FraudReview{}, err
+		}
+		reviews = append(reviews, r)
+	}
+	return reviews, nil
+}
+
+func (dbpool *DBStorage) ResolveFraudReview(ctx context.Context, review FraudReview) error {
+	tag, err := dbpool.Exec(ctx, `
+			UPDATE fraud_reviews SET status = $1, operator = $2, comment = $3, resolved_at = NOW()
+			WHERE id = $4 AND status = $5
+		`, review.Status, review.Operator, review.Comment, review.ID, FraudReviewPending)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+func (dbpool *DBStorage) UseApprovedFraudReview(
+	ctx context.Context,
+	login string,
+	action string,
+	orderNumber string,
+	sum float32,
+) (bool, error) {
+	tag, err := dbpool.Exec(ctx, `
+			UPDATE fraud_reviews SET used_at = NOW()
+			WHERE id = (
+				SELECT id FROM fraud_reviews
+				WHERE login = $1 AND action = $2 AND order_number = $3 AND sum = $4 AND status = $5
+					AND used_at IS NULL
+				ORDER BY id LIMIT 1 FOR UPDATE SKIP LOCKED
+			)
+		`, login, action, orderNumber, sum, FraudReviewApproved)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+func (dbpool *DBStorage) GetReferrals(ctx context.Context, referrer string) ([]Referral, error) {
+	var referrals []Referral
+	rows, err := dbpool.Query(ctx, `
+			SELECT id, referrer, referee, status, reject_reason, referrer_bonus, referee_bonus, created_at, rewarded_at
+			FROM referrals WHERE referrer = $1 ORDER BY created_at DESC, id DESC
+		`, referrer)
+	if err != nil {
+		return []Referral{}, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var r Referral
+		err = rows.Scan(
+			&r.ID, &r.Referrer, &r.Referee, &r.Status, &r.RejectReason, &r.ReferrerBonus, &r.RefereeBonus,
+			&r.CreatedAt, &r.RewardedAt,
+		)
+		if err != nil {
+			return []Referral{}, err
+		}
+		referrals = append(referrals, r)
+	}
+	return referrals, nil
+}
+
 func (dbpool *DBStorage) StoreUser(ctx context.Context, login string, passwordHash string) error {
 	_, err := dbpool.Exec(ctx, `
 			INSERT INTO users (login, password, balance) VALUES ($1, $2, $3)
@@ -60,8 +221,8 @@ func (dbpool *DBStorage) StoreUser(ctx context.Context, login string, passwordHa
 
 func (dbpool *DBStorage) GetOrder(ctx context.Context, number string) (Order, error) {
 	var order Order
-	err := dbpool.QueryRow(ctx, "SELECT number, status, accrual, withdrawn, login, uploaded_at, processed_at FROM orders WHERE number = $1", number).
-		Scan(&order.Number, &order.Status, &order.Accrual, &order.Withdrawn, &order.Login, &order.UploadedAt, &order.ProcessedAt)
+	err := dbpool.QueryRow(ctx, "SELECT number, status, accrual, withdrawn, login, uploaded_at, processed_at, accrual_provider, tier_bonus, campaign_bonus FROM orders WHERE number = $1", number).
+		Scan(&order.Number, &order.Status, &order.Accrual, &order.Withdrawn, &order.Login, &order.UploadedAt, &order.ProcessedAt, &order.AccrualProvider, &order.TierBonus, &order.CampaignBonus)
 	if err != nil {
 		return order, err
 	}
@@ -69,7 +230,7 @@ func (dbpool *DBStorage) GetOrder(ctx context.Context, number string) (Order, er
 }
 
 func (dbpool *DBStorage) GetOrders(ctx context.Context, login string, onlyWithdrawn bool) ([]Order, error) {
-	sql := "SELECT number, status, accrual, withdrawn, login, uploaded_at, processed_at FROM orders WHERE login = $1 "
+	sql := "SELECT number, status, accrual, withdrawn, login, uploaded_at, processed_at, accrual_provider, tier_bonus, campaign_bonus FROM orders WHERE login = $1 "
 	if onlyWithdrawn {
 		sql += " AND withdrawn  >  0"
 	}
@@ -84,7 +245,50 @@ func (dbpool *DBStorage) GetOrders(ctx context.Context, login string, onlyWithdr
 	defer rows.Close()
 	for rows.Next() {
 		var o Order
-		err = rows.Scan(&o.Number, &o.Status, &o.Accrual, &o.Withdrawn, &o.Login, &o.UploadedAt, &o.ProcessedAt)
+		err = rows.Scan(&o.Number, &o.Status, &o.Accrual, &o.Withdrawn, &o.Login, &o.UploadedAt, &o.ProcessedAt, &o.AccrualProvider, &o.TierBonus, &o.CampaignBonus)
+		if err != nil {
+			return []Order{}, err
+		}
+		orders = append(orders, o)
+	}
+	return orders, nil
+}
+
+func (dbpool *DBStorage) GetOrdersPage(ctx context.Context, filter OrdersFilter) ([]Order, error) {
+	sql := "SELECT number, status, accrual, withdrawn, login, uploaded_at, processed_at, accrual_provider, tier_bonus, campaign_bonus FROM orders WHERE login = $1"
+	args := []any{filter.Login}
+	if len(filter.Statuses) > 0 {
+		args = append(args, filter.Statuses)
+		sql += fmt.Sprintf(" AND status = ANY($%d)", len(args))
+	}
+	if filter.From != nil {
+		args = append(args, *filter.From)
+		sql += fmt.Sprintf(" AND uploaded_at >= $%d", len(args))
+	}
+	if filter.To != nil {
+		args = append(args, *filter.To)
+		sql += fmt.Sprintf(" AND uploaded_at < $%d", len(args))
+	}
+	direction, comparison := "ASC", ">"
+	if filter.Descending {
+		direction, comparison = "DESC", "<"
+	}
+	if filter.Cursor != nil {
+		args = append(args, filter.Cursor.UploadedAt, filter.Cursor.Number)
+		sql += fmt.Sprintf(" AND (uploaded_at, number) %s ($%d, $%d)", comparison, len(args)-1, len(args))
+	}
+	args = append(args, filter.Limit)
+	sql += fmt.Sprintf(" ORDER BY uploaded_at %s, number %s LIMIT $%d", direction, direction, len(args))
+
+	var orders []Order
+	rows, err := dbpool.Query(ctx, sql, args...)
+	if err != nil {
+		return []Order{}, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var o Order
+		err = rows.Scan(&o.Number, &o.Status, &o.Accrual, &o.Withdrawn, &o.Login, &o.UploadedAt, &o.ProcessedAt, &o.AccrualProvider, &o.TierBonus, &o.CampaignBonus)
 		if err != nil {
 			return []Order{}, err
 		}
@@ -93,9 +297,29 @@ func (dbpool *DBStorage) GetOrders(ctx context.Context, login string, onlyWithdr
 	return orders, nil
 }
 
+func (dbpool *DBStorage) GetOrderStatusHistory(ctx context.Context, number string) ([]OrderStatusHistory, error) {
+	var history []OrderStatusHistory
+	rows, err := dbpool.Query(ctx, `
+			SELECT status, accrual, created_at FROM order_status_history WHERE order_number = $1 ORDER BY id ASC
+		`, number)
+	if err != nil {
+		return []OrderStatusHistory{}, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var h OrderStatusHistory
+		err = rows.Scan(&h.Status, &h.Accrual, &h.CreatedAt)
+		if err != nil {
+			return []OrderStatusHistory{}, err
+		}
+		history = append(history, h)
+	}
+	return history, nil
+}
+
 func (dbpool *DBStorage) GetOrdersCountToUpdate(ctx context.Context) (int64, error) {
 	var count int64
-	err := dbpool.QueryRow(ctx, "SELECT COUNT(*) AS count FROM orders WHERE status = 'NEW' OR status = 'PROCESSING' OR status = 'REGISTERED'").
+	err := dbpool.QueryRow(ctx, "SELECT COUNT(*) AS count FROM orders WHERE"+ordersToUpdateSQL, PushedStatusTTL.Seconds()).
 		Scan(&count)
 	if err != nil {
 		return count, err
@@ -109,7 +333,12 @@ func (dbpool *DBStorage) StoreOrder(ctx context.Context, number, login string) e
 		return err
 	}
 	_, err = dbpool.Exec(ctx, `
-			INSERT INTO orders (number, status, accrual, login, uploaded_at) VALUES ($1, $2, $3, $4, $5)
+			WITH inserted AS (
+				INSERT INTO orders (number, status, accrual, login, uploaded_at) VALUES ($1, $2, $3, $4, $5)
+				RETURNING number, status, accrual, uploaded_at
+			)
+			INSERT INTO order_status_history (order_number, status, accrual, created_at)
+			SELECT number, status, accrual, uploaded_at FROM inserted
 		`, number, "NEW", 0, login, time.Now().In(loc))
 	if err != nil {
 		return err
@@ -117,108 +346,1073 @@ func (dbpool *DBStorage) StoreOrder(ctx context.Context, number, login string) e
 	return nil
 }
 
-func (dbpool *DBStorage) CloseConnection() {
-	dbpool.Close()
+func (dbpool *DBStorage) SetUserRole(ctx context.Context, login string, role string) error {
+	tag, err := dbpool.Exec(ctx, `
+			UPDATE users SET role = $1 WHERE login = $2
+		`, role, login)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
 }
 
-func (dbpool *DBStorage) BeginTransaction(ctx context.Context) (Transaction, error) {
-	tx, err := dbpool.Begin(ctx)
+func (dbpool *DBStorage) StoreAdminAction(ctx context.Context, action AdminAction) error {
+	_, err := dbpool.Exec(ctx, `
+			INSERT INTO admin_actions (operator, method, path, target, status_code) VALUES ($1, $2, $3, $4, $5)
+		`, action.Operator, action.Method, action.Path, action.Target, action.StatusCode)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	dbTx := &DBTransaction{tx}
-	return dbTx, nil
+	return nil
 }
 
-func (tx *DBTransaction) GetOrderToUpdateStatus(ctx context.Context) (OrderToUpdate, error) {
-	var order OrderToUpdate
-	err := tx.QueryRow(ctx, "SELECT number, login FROM orders WHERE status = 'NEW' OR status = 'PROCESSING' OR status = 'REGISTERED' LIMIT 1 FOR UPDATE SKIP LOCKED").
-		Scan(&order.Number, &order.Login)
+func (dbpool *DBStorage) StoreAuditEvent(ctx context.Context, event AuditEvent) error {
+	_, err := dbpool.Exec(ctx, insertAuditEventSQL,
+		event.Actor, event.Action, event.Target, event.AmountBefore, event.AmountAfter, event.RequestID)
 	if err != nil {
-		return order, err
+		return err
 	}
-	return order, nil
+	return nil
 }
 
-func (tx *DBTransaction) UpdateOrderStatus(ctx context.Context, order OrderStatus) error {
-	loc, err := time.LoadLocation("Europe/Moscow")
+func (dbpool *DBStorage) GetAuditEvents(ctx context.Context, filter AuditFilter) ([]AuditEvent, error) {
+	sql := "SELECT id, actor, action, target, amount_before, amount_after, request_id, created_at FROM audit_events WHERE TRUE"
+	var args []any
+	if filter.Actor != "" {
+		args = append(args, filter.Actor)
+		sql += fmt.Sprintf(" AND actor = $%d", len(args))
+	}
+	if filter.Action != "" {
+		args = append(args, filter.Action)
+		sql += fmt.Sprintf(" AND action = $%d", len(args))
+	}
+	if filter.Target != "" {
+		args = append(args, filter.Target)
+		sql += fmt.Sprintf(" AND target = $%d", len(args))
+	}
+	if filter.From != nil {
+		args = append(args, *filter.From)
+		sql += fmt.Sprintf(" AND created_at >= $%d", len(args))
+	}
+	if filter.To != nil {
+		args = append(args, *filter.To)
+		sql += fmt.Sprintf(" AND created_at < $%d", len(args))
+	}
+	args = append(args, filter.Limit, filter.Offset)
+	sql += fmt.Sprintf(" ORDER BY id DESC LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+
+	var events []AuditEvent
+	rows, err := dbpool.Query(ctx, sql, args...)
 	if err != nil {
-		return err
+		return []AuditEvent{}, err
 	}
-	_, err = tx.Exec(ctx, `
-			UPDATE orders SET status = $1, accrual = $2, processed_at = $3 WHERE number = $4
-		`, order.Status, order.Accrual, time.Now().In(loc), order.Number)
+	defer rows.Close()
+	for rows.Next() {
+		var e AuditEvent
+		err = rows.Scan(&e.ID, &e.Actor, &e.Action, &e.Target, &e.AmountBefore, &e.AmountAfter, &e.RequestID, &e.CreatedAt)
+		if err != nil {
+			return []AuditEvent{}, err
+		}
+		events = append(events, e)
+	}
+	return events, nil
+}
+
+func (dbpool *DBStorage) GetAPIKeys(ctx context.Context, login string) ([]APIKey, error) {
+	var keys []APIKey
+	rows, err := dbpool.Query(ctx, `
+			SELECT id, login, name, prefix, key_hash, scopes, rate_limit, created_at, last_used_at, revoked_at
+			FROM api_keys WHERE login = $1 ORDER BY id ASC
+		`, login)
+	if err != nil {
+		return []APIKey{}, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var k APIKey
+		err = rows.Scan(&k.ID, &k.Login, &k.Name, &k.Prefix, &k.Hash, &k.Scopes, &k.RateLimit, &k.CreatedAt, &k.LastUsedAt, &k.RevokedAt)
+		if err != nil {
+			return []APIKey{}, err
+		}
+		keys = append(keys, k)
+	}
+	return keys, nil
+}
+
+func (dbpool *DBStorage) GetAPIKeyByHash(ctx context.Context, keyHash string) (APIKey, error) {
+	var k APIKey
+	err := dbpool.QueryRow(ctx, `
+			SELECT id, login, name, prefix, key_hash, scopes, rate_limit, created_at, last_used_at, revoked_at
+			FROM api_keys WHERE key_hash = $1
+		`, keyHash).
+		Scan(&k.ID, &k.Login, &k.Name, &k.Prefix, &k.Hash, &k.Scopes, &k.RateLimit, &k.CreatedAt, &k.LastUsedAt, &k.RevokedAt)
+	if err != nil {
+		return k, err
+	}
+	return k, nil
+}
+
+func (dbpool *DBStorage) TouchAPIKey(ctx context.Context, id int64) error {
+	_, err := dbpool.Exec(ctx, `
+			UPDATE api_keys SET last_used_at = NOW() WHERE id = $1
+		`, id)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func (tx *DBTransaction) AccrualUserBalance(ctx context.Context, accraul float32, login string) error {
-	_, err := tx.Exec(ctx, `
-			UPDATE users SET balance = balance + $1 WHERE login = $2
-		`, accraul, login)
+func (dbpool *DBStorage) StoreWebhook(ctx context.Context, webhook Webhook) (int64, error) {
+	var id int64
+	err := dbpool.QueryRow(ctx, `
+			INSERT INTO webhooks (login, url, secret) VALUES ($1, $2, $3) RETURNING id
+		`, webhook.Login, webhook.URL, webhook.Secret).
+		Scan(&id)
+	if err != nil {
+		return id, err
+	}
+	return id, nil
+}
+
+func (dbpool *DBStorage) GetWebhooks(ctx context.Context, login string) ([]Webhook, error) {
+	var webhooks []Webhook
+	rows, err := dbpool.Query(ctx, `
+			SELECT id, login, url, secret, created_at, deleted_at
+			FROM webhooks WHERE login = $1 AND deleted_at IS NULL ORDER BY id ASC
+		`, login)
+	if err != nil {
+		return []Webhook{}, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var w Webhook
+		err = rows.Scan(&w.ID, &w.Login, &w.URL, &w.Secret, &w.CreatedAt, &w.DeletedAt)
+		if err != nil {
+			return []Webhook{}, err
+		}
+		webhooks = append(webhooks, w)
+	}
+	return webhooks, nil
+}
+
+func (dbpool *DBStorage) DeleteWebhook(ctx context.Context, id int64, login string) error {
+	tag, err := dbpool.Exec(ctx, `
+			UPDATE webhooks SET deleted_at = NOW() WHERE id = $1 AND login = $2 AND deleted_at IS NULL
+		`, id, login)
 	if err != nil {
 		return err
 	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
 	return nil
 }
 
-func (tx *DBTransaction) GetUserWithLock(ctx context.Context, login string) (User, error) {
-	var user User
-	err := tx.QueryRow(ctx, "SELECT login, password, balance FROM users WHERE login = $1 FOR UPDATE", login).
-		Scan(&user.Login, &user.Password, &user.Balance)
+func (dbpool *DBStorage) GetWebhookDeliveries(ctx context.Context, webhookID int64, login string, limit int) ([]WebhookDelivery, error) {
+	var deliveries []WebhookDelivery
+	rows, err := dbpool.Query(ctx, `
+			SELECT d.id, d.message_id, d.webhook_id, o.event, d.attempt, d.status_code, d.error, d.duration_ms, d.created_at
+			FROM webhook_deliveries d
+			JOIN webhooks w ON w.id = d.webhook_id
+			JOIN webhook_outbox o ON o.id = d.message_id
+			WHERE d.webhook_id = $1 AND w.login = $2
+			ORDER BY d.id DESC LIMIT $3
+		`, webhookID, login, limit)
 	if err != nil {
-		return user, err
+		return []WebhookDelivery{}, err
 	}
-	return user, nil
+	defer rows.Close()
+	for rows.Next() {
+		var d WebhookDelivery
+		err = rows.Scan(&d.ID, &d.MessageID, &d.WebhookID, &d.Event, &d.Attempt, &d.StatusCode, &d.Error, &d.DurationMs, &d.CreatedAt)
+		if err != nil {
+			return []WebhookDelivery{}, err
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, nil
 }
 
-func (tx *DBTransaction) GetOrderWithLock(ctx context.Context, number string, login string) (Order, error) {
-	var order Order
-	err := tx.QueryRow(ctx, "SELECT number, status, accrual, withdrawn, login, uploaded_at, processed_at FROM orders WHERE number = $1", number).
-		Scan(&order.Number, &order.Status, &order.Accrual, &order.Withdrawn, &order.Login, &order.UploadedAt, &order.ProcessedAt)
+func (dbpool *DBStorage) GetWebhookMessagesCountToDeliver(ctx context.Context) (int64, error) {
+	var count int64
+	err := dbpool.QueryRow(ctx, `
+			SELECT COUNT(*) AS count FROM webhook_outbox o
+			JOIN webhooks w ON w.id = o.webhook_id
+			WHERE o.delivered_at IS NULL AND o.failed_at IS NULL AND o.next_attempt_at <= NOW()
+				AND w.deleted_at IS NULL
+		`).
+		Scan(&count)
 	if err != nil {
-		loc, errLoc := time.LoadLocation("Europe/Moscow")
-		if errLoc != nil {
-			return order, errLoc
-		}
-		now := time.Now().In(loc)
-		order = Order{
-			Number:      number,
-			Status:      "PROCESSED",
-			Accrual:     0,
-			Withdrawn:   0,
-			Login:       login,
-			UploadedAt:  now,
-			ProcessedAt: &now,
+		return count, err
+	}
+	return count, nil
+}
+
+func (dbpool *DBStorage) GetFlaggedUsers(ctx context.Context) ([]User, error) {
+	var users []User
+	rows, err := dbpool.Query(ctx, `
+			SELECT login, balance, withdrawn, role, flagged_at, flag_reason
+			FROM users WHERE flagged_at IS NOT NULL ORDER BY flagged_at ASC
+		`)
+	if err != nil {
+		return []User{}, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var u User
+		err = rows.Scan(&u.Login, &u.Balance, &u.Withdrawn, &u.Role, &u.FlaggedAt, &u.FlagReason)
+		if err != nil {
+			return []User{}, err
 		}
-		_, err = tx.Exec(ctx, `
-				INSERT INTO orders (number, status, accrual, withdrawn, login, uploaded_at, processed_at) VALUES ($1, $2, $3, $4, $5, $6, $7)
-			`, number, "PROCESSED", 0, 0, login, now, now)
+		users = append(users, u)
+	}
+	return users, nil
+}
+
+func (dbpool *DBStorage) ClearUserFlag(ctx context.Context, login string) error {
+	tag, err := dbpool.Exec(ctx, `
+			UPDATE users SET flagged_at = NULL, flag_reason = '' WHERE login = $1 AND flagged_at IS NOT NULL
+		`, login)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+const selectCampaignsSQL = `
+	SELECT id, name, starts_at, ends_at, first_order_only, min_accrual, tiers, bonus_type, bonus_value, created_at, deleted_at
+	FROM campaigns
+`
+
+func scanCampaigns(rows pgx.Rows) ([]Campaign, error) {
+	defer rows.Close()
+	var campaigns []Campaign
+	for rows.Next() {
+		var c Campaign
+		err := rows.Scan(
+			&c.ID, &c.Name, &c.StartsAt, &c.EndsAt, &c.FirstOrderOnly, &c.MinAccrual, &c.Tiers,
+			&c.BonusType, &c.BonusValue, &c.CreatedAt, &c.DeletedAt,
+		)
 		if err != nil {
-			return order, err
+			return []Campaign{}, err
 		}
+		campaigns = append(campaigns, c)
 	}
-	return order, nil
+	return campaigns, nil
 }
 
-func (tx *DBTransaction) WithdrawUserBalance(ctx context.Context, login string, withdraw float32) error {
-	_, err := tx.Exec(ctx, `
-			UPDATE users SET balance = balance - $1, withdrawn = withdrawn + $2 WHERE login = $3
-		`, withdraw, withdraw, login)
+func (dbpool *DBStorage) StoreCampaign(ctx context.Context, campaign Campaign) (int64, error) {
+	var id int64
+	err := dbpool.QueryRow(ctx, `
+			INSERT INTO campaigns (name, starts_at, ends_at, first_order_only, min_accrual, tiers, bonus_type, bonus_value)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id
+		`, campaign.Name, campaign.StartsAt, campaign.EndsAt, campaign.FirstOrderOnly, campaign.MinAccrual,
+		campaign.Tiers, campaign.BonusType, campaign.BonusValue).
+		Scan(&id)
+	if err != nil {
+		return id, err
+	}
+	return id, nil
+}
+
+func (dbpool *DBStorage) GetCampaigns(ctx context.Context) ([]Campaign, error) {
+	rows, err := dbpool.Query(ctx, selectCampaignsSQL+"WHERE deleted_at IS NULL ORDER BY starts_at DESC, id DESC")
+	if err != nil {
+		return []Campaign{}, err
+	}
+	return scanCampaigns(rows)
+}
+
+func (dbpool *DBStorage) GetCampaign(ctx context.Context, id int64) (Campaign, error) {
+	rows, err := dbpool.Query(ctx, selectCampaignsSQL+"WHERE id = $1 AND deleted_at IS NULL", id)
+	if err != nil {
+		return Campaign{}, err
+	}
+	campaigns, err := scanCampaigns(rows)
+	if err != nil {
+		return Campaign{}, err
+	}
+	if len(campaigns) == 0 {
+		return Campaign{}, pgx.ErrNoRows
+	}
+	return campaigns[0], nil
+}
+
+func (dbpool *DBStorage) UpdateCampaign(ctx context.Context, campaign Campaign) error {
+	tag, err := dbpool.Exec(ctx, `
+			UPDATE campaigns SET name = $2, starts_at = $3, ends_at = $4, first_order_only = $5, min_accrual = $6,
+				tiers = $7, bonus_type = $8, bonus_value = $9
+			WHERE id = $1 AND deleted_at IS NULL
+		`, campaign.ID, campaign.Name, campaign.StartsAt, campaign.EndsAt, campaign.FirstOrderOnly, campaign.MinAccrual,
+		campaign.Tiers, campaign.BonusType, campaign.BonusValue)
 	if err != nil {
 		return err
 	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
 	return nil
 }
 
-func (tx *DBTransaction) WithdrawOrderBalance(ctx context.Context, number string, withdraw float32) error {
-	_, err := tx.Exec(ctx, `
-			UPDATE orders SET withdrawn = withdrawn + $1 WHERE number = $2
-		`, withdraw, number)
+func (dbpool *DBStorage) DeleteCampaign(ctx context.Context, id int64) error {
+	tag, err := dbpool.Exec(ctx, `
+			UPDATE campaigns SET deleted_at = NOW() WHERE id = $1 AND deleted_at IS NULL
+		`, id)
 	if err != nil {
 		return err
 	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// accrualTotalKinds - движения, из которых складывается сумма начислений для уровня лояльности.
+const accrualTotalKinds = "('" + MovementAccrual + "', '" + MovementReversal + "')"
+
+func (dbpool *DBStorage) GetAccrualTotal(ctx context.Context, login string, since time.Time) (float32, error) {
+	var total float32
+	err := dbpool.QueryRow(ctx, `
+			SELECT COALESCE(SUM(amount), 0) FROM balance_movements
+			WHERE login = $1 AND created_at >= $2 AND kind IN `+accrualTotalKinds, login, since).
+		Scan(&total)
+	if err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+func (dbpool *DBStorage) GetAccrualTotals(ctx context.Context, since time.Time) ([]AccrualTotal, error) {
+	var totals []AccrualTotal
+	rows, err := dbpool.Query(ctx, `
+			SELECT u.login, u.tier, COALESCE(SUM(m.amount), 0)
+			FROM users u
+			LEFT JOIN balance_movements m ON m.login = u.login AND m.created_at >= $1 AND m.kind IN `+accrualTotalKinds+`
+			GROUP BY u.login, u.tier
+		`, since)
+	if err != nil {
+		return []AccrualTotal{}, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var t AccrualTotal
+		err = rows.Scan(&t.Login, &t.Tier, &t.Total)
+		if err != nil {
+			return []AccrualTotal{}, err
+		}
+		totals = append(totals, t)
+	}
+	return totals, nil
+}
+
+func (dbpool *DBStorage) SetUserTier(ctx context.Context, login string, tier string) error {
+	_, err := dbpool.Exec(ctx, "UPDATE users SET tier = $1 WHERE login = $2", tier, login)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (dbpool *DBStorage) GetBalanceMovements(ctx context.Context, login string, limit int) ([]BalanceMovement, error) {
+	var movements []BalanceMovement
+	rows, err := dbpool.Query(ctx, `
+			SELECT login, kind, amount, COALESCE(order_number, ''), reason, COALESCE(operator, ''),
+				COALESCE(counterparty, ''), campaign_id, created_at
+			FROM balance_movements
+			WHERE login = $1
+			ORDER BY created_at DESC, id DESC
+			LIMIT $2
+		`, login, limit)
+	if err != nil {
+		return []BalanceMovement{}, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var m BalanceMovement
+		err = rows.Scan(&m.Login, &m.Kind, &m.Amount, &m.OrderNumber, &m.Reason, &m.Operator, &m.Counterparty, &m.CampaignID, &m.CreatedAt)
+		if err != nil {
+			return []BalanceMovement{}, err
+		}
+		movements = append(movements, m)
+	}
+	return movements, nil
+}
+
+const selectHoldsSQL = `
+	SELECT id, login, order_number, amount, status, expires_at, closed_at, created_at FROM holds
+`
+
+func scanHolds(rows pgx.Rows) ([]Hold, error) {
+	defer rows.Close()
+	var holds []Hold
+	for rows.Next() {
+		var h Hold
+		err := rows.Scan(&h.ID, &h.Login, &h.OrderNumber, &h.Amount, &h.Status, &h.ExpiresAt, &h.ClosedAt, &h.CreatedAt)
+		if err != nil {
+			return []Hold{}, err
+		}
+		holds = append(holds, h)
+	}
+	return holds, nil
+}
+
+func (dbpool *DBStorage) GetHolds(ctx context.Context, login string) ([]Hold, error) {
+	rows, err := dbpool.Query(ctx, selectHoldsSQL+"WHERE login = $1 ORDER BY created_at DESC", login)
+	if err != nil {
+		return []Hold{}, err
+	}
+	return scanHolds(rows)
+}
+
+func (dbpool *DBStorage) GetExpiredHolds(ctx context.Context, limit int) ([]Hold, error) {
+	rows, err := dbpool.Query(ctx, selectHoldsSQL+"WHERE status = $1 AND expires_at <= NOW() LIMIT $2", HoldActive, limit)
+	if err != nil {
+		return []Hold{}, err
+	}
+	return scanHolds(rows)
+}
+
+func (dbpool *DBStorage) GetPendingBalance(ctx context.Context, login string) ([]PendingBalance, error) {
+	var pending []PendingBalance
+	rows, err := dbpool.Query(ctx, `
+			SELECT status, COUNT(*), COALESCE(SUM(provisional_accrual), 0)
+			FROM orders
+			WHERE login = $1 AND status IN ('NEW', 'REGISTERED', 'PROCESSING')
+			GROUP BY status
+			ORDER BY status
+		`, login)
+	if err != nil {
+		return []PendingBalance{}, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var p PendingBalance
+		err = rows.Scan(&p.Status, &p.Count, &p.Accrual)
+		if err != nil {
+			return []PendingBalance{}, err
+		}
+		pending = append(pending, p)
+	}
+	return pending, nil
+}
+
+func (dbpool *DBStorage) GetExpiringPointLots(ctx context.Context, login string, limit int) ([]PointLot, error) {
+	var lots []PointLot
+	rows, err := dbpool.Query(ctx, `
+			SELECT id, login, COALESCE(order_number, ''), amount, remaining, expires_at, created_at
+			FROM point_lots
+			WHERE login = $1 AND remaining > 0 AND expires_at > NOW()
+			ORDER BY expires_at ASC, id ASC
+			LIMIT $2
+		`, login, limit)
+	if err != nil {
+		return []PointLot{}, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var lot PointLot
+		err = rows.Scan(&lot.ID, &lot.Login, &lot.OrderNumber, &lot.Amount, &lot.Remaining, &lot.ExpiresAt, &lot.CreatedAt)
+		if err != nil {
+			return []PointLot{}, err
+		}
+		lots = append(lots, lot)
+	}
+	return lots, nil
+}
+
+func (dbpool *DBStorage) GetLoginsWithExpiredPoints(ctx context.Context, limit int) ([]string, error) {
+	var logins []string
+	rows, err := dbpool.Query(ctx, `
+			SELECT DISTINCT p.login FROM point_lots p
+			JOIN users u ON u.login = p.login
+			WHERE p.remaining > 0 AND p.expires_at <= NOW() AND u.balance > u.held
+			LIMIT $1
+		`, limit)
+	if err != nil {
+		return []string{}, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var login string
+		err = rows.Scan(&login)
+		if err != nil {
+			return []string{}, err
+		}
+		logins = append(logins, login)
+	}
+	return logins, nil
+}
+
+func (dbpool *DBStorage) CloseConnection() {
+	dbpool.Close()
+}
+
+func (dbpool *DBStorage) BeginTransaction(ctx context.Context) (Transaction, error) {
+	tx, err := dbpool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	dbTx := &DBTransaction{tx}
+	return dbTx, nil
+}
+
+func (tx *DBTransaction) GetOrderToUpdateStatus(ctx context.Context) (OrderToUpdate, error) {
+	var order OrderToUpdate
+	// Выбранный заказ уходит в конец очереди, чтобы заказы недоступной системы расчета не блокировали остальные
+	err := tx.QueryRow(ctx, `
+			UPDATE orders SET polled_at = NOW()
+			WHERE number = (
+				SELECT number FROM orders WHERE`+ordersToUpdateSQL+`
+				ORDER BY polled_at ASC NULLS FIRST, uploaded_at ASC LIMIT 1 FOR UPDATE SKIP LOCKED
+			)
+			RETURNING number, login, status
+		`, PushedStatusTTL.Seconds()).
+		Scan(&order.Number, &order.Login, &order.Status)
+	if err != nil {
+		return order, err
+	}
+	return order, nil
+}
+
+func (tx *DBTransaction) UpdateOrderStatus(ctx context.Context, order OrderStatus) error {
+	loc, err := time.LoadLocation("Europe/Moscow")
+	if err != nil {
+		return err
+	}
+	now := time.Now().In(loc)
+	_, err = tx.Exec(ctx, `
+			UPDATE orders SET status = $1, accrual = $2, processed_at = $3, provisional_accrual = NULL,
+				accrual_provider = COALESCE(NULLIF($5, ''), accrual_provider), tier_bonus = $6, campaign_bonus = $7
+			WHERE number = $4
+		`, order.Status, order.Accrual, now, order.Number, order.Provider, order.TierBonus, order.CampaignBonus)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx, `
+			INSERT INTO order_status_history (order_number, status, accrual, created_at) VALUES ($1, $2, $3, $4)
+		`, order.Number, order.Status, order.Accrual, now)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (tx *DBTransaction) StorePushedOrderStatus(ctx context.Context, order OrderStatus) error {
+	_, err := tx.Exec(ctx, `
+			WITH previous AS (
+				SELECT status FROM orders WHERE number = $1
+			), updated AS (
+				UPDATE orders SET status = $2, accrual_pushed_at = NOW(),
+					provisional_accrual = CASE WHEN $3 > 0 THEN $3 ELSE provisional_accrual END
+				WHERE number = $1
+				RETURNING number, status
+			)
+			INSERT INTO order_status_history (order_number, status, accrual)
+			SELECT updated.number, updated.status, 0 FROM updated, previous WHERE previous.status <> updated.status
+		`, order.Number, order.Status, order.Accrual)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// StoreProvisionalOrderStatus сохраняет промежуточный статус заказа, полученный при опросе
+// системы расчета, и предварительное начисление, если система его сообщила.
+func (tx *DBTransaction) StoreProvisionalOrderStatus(ctx context.Context, order OrderStatus) error {
+	_, err := tx.Exec(ctx, `
+			WITH previous AS (
+				SELECT status FROM orders WHERE number = $1
+			), updated AS (
+				UPDATE orders SET status = $2,
+					provisional_accrual = CASE WHEN $3 > 0 THEN $3 ELSE provisional_accrual END
+				WHERE number = $1
+				RETURNING number, status
+			)
+			INSERT INTO order_status_history (order_number, status, accrual)
+			SELECT updated.number, updated.status, 0 FROM updated, previous WHERE previous.status <> updated.status
+		`, order.Number, order.Status, order.Accrual)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (tx *DBTransaction) AccrualUserBalance(ctx context.Context, accraul float32, login string) error {
+	_, err := tx.Exec(ctx, `
+			UPDATE users SET balance = balance + $1 WHERE login = $2
+		`, accraul, login)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (tx *DBTransaction) GetUserWithLock(ctx context.Context, login string) (User, error) {
+	var user User
+	err := tx.QueryRow(ctx, "SELECT login, password, balance, held, role, tier FROM users WHERE login = $1 FOR UPDATE", login).
+		Scan(&user.Login, &user.Password, &user.Balance, &user.Held, &user.Role, &user.Tier)
+	if err != nil {
+		return user, err
+	}
+	return user, nil
+}
+
+func (tx *DBTransaction) GetOrderWithLock(ctx context.Context, number string, login string) (Order, error) {
+	var order Order
+	err := tx.QueryRow(ctx, "SELECT number, status, accrual, withdrawn, login, uploaded_at, processed_at, accrual_provider, tier_bonus, campaign_bonus FROM orders WHERE number = $1", number).
+		Scan(&order.Number, &order.Status, &order.Accrual, &order.Withdrawn, &order.Login, &order.UploadedAt, &order.ProcessedAt, &order.AccrualProvider, &order.TierBonus, &order.CampaignBonus)
+	if err != nil {
+		loc, errLoc := time.LoadLocation("Europe/Moscow")
+		if errLoc != nil {
+			return order, errLoc
+		}
+		now := time.Now().In(loc)
+		order = Order{
+			Number:      number,
+			Status:      "PROCESSED",
+			Accrual:     0,
+			Withdrawn:   0,
+			Login:       login,
+			UploadedAt:  now,
+			ProcessedAt: &now,
+		}
+		_, err = tx.Exec(ctx, `
+				WITH inserted AS (
+					INSERT INTO orders (number, status, accrual, withdrawn, login, uploaded_at, processed_at)
+					VALUES ($1, $2, $3, $4, $5, $6, $7)
+					RETURNING number, status, accrual, processed_at
+				)
+				INSERT INTO order_status_history (order_number, status, accrual, created_at)
+				SELECT number, status, accrual, processed_at FROM inserted
+			`, number, "PROCESSED", 0, 0, login, now, now)
+		if err != nil {
+			return order, err
+		}
+	}
+	return order, nil
+}
+
+func (tx *DBTransaction) WithdrawUserBalance(ctx context.Context, login string, withdraw float32) error {
+	_, err := tx.Exec(ctx, `
+			UPDATE users SET balance = balance - $1, withdrawn = withdrawn + $2 WHERE login = $3
+		`, withdraw, withdraw, login)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (tx *DBTransaction) WithdrawOrderBalance(ctx context.Context, number string, withdraw float32) error {
+	_, err := tx.Exec(ctx, `
+			UPDATE orders SET withdrawn = withdrawn + $1 WHERE number = $2
+		`, withdraw, number)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (tx *DBTransaction) GetOrderForUpdate(ctx context.Context, number string) (Order, error) {
+	var order Order
+	err := tx.QueryRow(ctx, "SELECT number, status, accrual, withdrawn, login, uploaded_at, processed_at, accrual_provider, tier_bonus, campaign_bonus FROM orders WHERE number = $1 FOR UPDATE", number).
+		Scan(&order.Number, &order.Status, &order.Accrual, &order.Withdrawn, &order.Login, &order.UploadedAt, &order.ProcessedAt, &order.AccrualProvider, &order.TierBonus, &order.CampaignBonus)
+	if err != nil {
+		return order, err
+	}
+	return order, nil
+}
+
+func (tx *DBTransaction) StoreBalanceMovement(ctx context.Context, movement BalanceMovement) error {
+	_, err := tx.Exec(ctx, `
+			INSERT INTO balance_movements (login, kind, amount, order_number, reason, operator, counterparty, campaign_id)
+			VALUES ($1, $2, $3, NULLIF($4, ''), $5, NULLIF($6, ''), NULLIF($7, ''), $8)
+		`, movement.Login, movement.Kind, movement.Amount, movement.OrderNumber, movement.Reason, movement.Operator,
+		movement.Counterparty, movement.CampaignID)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (tx *DBTransaction) StoreOrderChange(ctx context.Context, change OrderChange) error {
+	_, err := tx.Exec(ctx, `
+			INSERT INTO order_changes (order_number, operator, old_status, new_status, old_accrual, new_accrual, reason)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+		`, change.OrderNumber, change.Operator, change.OldStatus, change.NewStatus, change.OldAccrual, change.NewAccrual, change.Reason)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (tx *DBTransaction) StoreUser(ctx context.Context, login string, passwordHash string) error {
+	_, err := tx.Exec(ctx, `
+			INSERT INTO users (login, password, balance) VALUES ($1, $2, $3)
+		`, login, passwordHash, 0)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (tx *DBTransaction) StoreAuditEvent(ctx context.Context, event AuditEvent) error {
+	_, err := tx.Exec(ctx, insertAuditEventSQL,
+		event.Actor, event.Action, event.Target, event.AmountBefore, event.AmountAfter, event.RequestID)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (tx *DBTransaction) InsertOrder(ctx context.Context, number, login string) (bool, error) {
+	loc, err := time.LoadLocation("Europe/Moscow")
+	if err != nil {
+		return false, err
+	}
+	tag, err := tx.Exec(ctx, `
+			WITH inserted AS (
+				INSERT INTO orders (number, status, accrual, login, uploaded_at) VALUES ($1, $2, $3, $4, $5)
+				ON CONFLICT (number) DO NOTHING
+				RETURNING number, status, accrual, uploaded_at
+			)
+			INSERT INTO order_status_history (order_number, status, accrual, created_at)
+			SELECT number, status, accrual, uploaded_at FROM inserted
+		`, number, "NEW", 0, login, time.Now().In(loc))
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+func (tx *DBTransaction) FlagUser(ctx context.Context, login string, reason string) error {
+	_, err := tx.Exec(ctx, `
+			UPDATE users SET flagged_at = COALESCE(flagged_at, NOW()), flag_reason = $1 WHERE login = $2
+		`, reason, login)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (tx *DBTransaction) SetUserReferral(ctx context.Context, login string, referralCode string, registrationIP string) error {
+	_, err := tx.Exec(ctx, `
+			UPDATE users SET referral_code = $1, registration_ip = $2 WHERE login = $3
+		`, referralCode, registrationIP, login)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (tx *DBTransaction) StoreReferral(ctx context.Context, referral Referral) error {
+	_, err := tx.Exec(ctx, `
+			INSERT INTO referrals (referrer, referee, status, reject_reason) VALUES ($1, $2, $3, $4)
+		`, referral.Referrer, referral.Referee, referral.Status, referral.RejectReason)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (tx *DBTransaction) GetReferralsCount(ctx context.Context, referrer string) (int64, error) {
+	var count int64
+	err := tx.QueryRow(ctx, `
+			SELECT COUNT(*) FROM referrals WHERE referrer = $1 AND status <> $2
+		`, referrer, ReferralRejected).
+		Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (tx *DBTransaction) GetPendingReferralForUpdate(ctx context.Context, referee string) (Referral, error) {
+	var r Referral
+	err := tx.QueryRow(ctx, `
+			SELECT id, referrer, referee, status, reject_reason, referrer_bonus, referee_bonus, created_at, rewarded_at
+			FROM referrals WHERE referee = $1 AND status = $2 FOR UPDATE
+		`, referee, ReferralPending).
+		Scan(
+			&r.ID, &r.Referrer, &r.Referee, &r.Status, &r.RejectReason, &r.ReferrerBonus, &r.RefereeBonus,
+			&r.CreatedAt, &r.RewardedAt,
+		)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Referral{}, nil
+	}
+	if err != nil {
+		return r, err
+	}
+	return r, nil
+}
+
+func (tx *DBTransaction) RewardReferral(ctx context.Context, referral Referral) error {
+	_, err := tx.Exec(ctx, `
+			UPDATE referrals SET status = $1, referrer_bonus = $2, referee_bonus = $3, rewarded_at = NOW() WHERE id = $4
+		`, ReferralRewarded, referral.ReferrerBonus, referral.RefereeBonus, referral.ID)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (tx *DBTransaction) GetActiveCampaigns(ctx context.Context, at time.Time) ([]Campaign, error) {
+	rows, err := tx.Query(ctx, selectCampaignsSQL+`
+			WHERE deleted_at IS NULL AND starts_at <= $1 AND ends_at > $1 ORDER BY id ASC
+		`, at)
+	if err != nil {
+		return []Campaign{}, err
+	}
+	return scanCampaigns(rows)
+}
+
+func (tx *DBTransaction) GetProcessedOrdersCount(ctx context.Context, login string) (int64, error) {
+	var count int64
+	err := tx.QueryRow(ctx, "SELECT COUNT(*) FROM orders WHERE login = $1 AND status = 'PROCESSED'", login).
+		Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (tx *DBTransaction) GetMovementsCount(ctx context.Context, login string, kind string, since time.Time) (int64, error) {
+	var count int64
+	err := tx.QueryRow(ctx, `
+			SELECT COUNT(*) FROM balance_movements WHERE login = $1 AND kind = $2 AND created_at >= $3
+		`, login, kind, since).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (tx *DBTransaction) GetMovementsSum(ctx context.Context, login string, kind string, since time.Time) (float32, error) {
+	var sum float32
+	err := tx.QueryRow(ctx, `
+			SELECT COALESCE(SUM(amount), 0) FROM balance_movements WHERE login = $1 AND kind = $2 AND created_at >= $3
+		`, login, kind, since).Scan(&sum)
+	if err != nil {
+		return 0, err
+	}
+	return sum, nil
+}
+
+func (tx *DBTransaction) StoreHold(ctx context.Context, hold Hold) (int64, error) {
+	var id int64
+	err := tx.QueryRow(ctx, `
+			INSERT INTO holds (login, order_number, amount, status, expires_at)
+			VALUES ($1, $2, $3, $4, $5)
+			RETURNING id
+		`, hold.Login, hold.OrderNumber, hold.Amount, HoldActive, hold.ExpiresAt).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+	_, err = tx.Exec(ctx, "UPDATE users SET held = held + $1 WHERE login = $2", hold.Amount, hold.Login)
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+func (tx *DBTransaction) GetHoldForUpdate(ctx context.Context, id int64) (Hold, error) {
+	var h Hold
+	err := tx.QueryRow(ctx, selectHoldsSQL+"WHERE id = $1 FOR UPDATE", id).
+		Scan(&h.ID, &h.Login, &h.OrderNumber, &h.Amount, &h.Status, &h.ExpiresAt, &h.ClosedAt, &h.CreatedAt)
+	if err != nil {
+		return h, err
+	}
+	return h, nil
+}
+
+// CloseHold переводит активный холд в итоговый статус и освобождает зарезервированные баллы.
+func (tx *DBTransaction) CloseHold(ctx context.Context, hold Hold, status string) error {
+	_, err := tx.Exec(ctx, "UPDATE holds SET status = $1, closed_at = NOW() WHERE id = $2", status, hold.ID)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx, "UPDATE users SET held = held - $1 WHERE login = $2", hold.Amount, hold.Login)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (tx *DBTransaction) StorePointLot(ctx context.Context, lot PointLot) error {
+	_, err := tx.Exec(ctx, `
+			INSERT INTO point_lots (login, order_number, amount, remaining, expires_at)
+			VALUES ($1, NULLIF($2, ''), $3, $3, $4)
+		`, lot.Login, lot.OrderNumber, lot.Amount, lot.ExpiresAt)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// ConsumePointLots списывает amount с действующих партий пользователя, начиная с тех,
+// что сгорают раньше. Если партий не хватает, остаток списания ни к одной партии не относится.
+func (tx *DBTransaction) ConsumePointLots(ctx context.Context, login string, amount float32) ([]PointLot, error) {
+	rows, err := tx.Query(ctx, `
+			SELECT id, remaining, expires_at FROM point_lots
+			WHERE login = $1 AND remaining > 0 AND (expires_at IS NULL OR expires_at > NOW())
+			ORDER BY expires_at ASC NULLS LAST, id ASC
+			FOR UPDATE
+		`, login)
+	if err != nil {
+		return nil, err
+	}
+	var lots []PointLot
+	for rows.Next() {
+		var lot PointLot
+		err = rows.Scan(&lot.ID, &lot.Remaining, &lot.ExpiresAt)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		lots = append(lots, lot)
+	}
+	rows.Close()
+	var consumedLots []PointLot
+	for _, lot := range lots {
+		if amount <= 0 {
+			break
+		}
+		consumed := min(lot.Remaining, amount)
+		_, err = tx.Exec(ctx, "UPDATE point_lots SET remaining = remaining - $1 WHERE id = $2", consumed, lot.ID)
+		if err != nil {
+			return nil, err
+		}
+		amount -= consumed
+		consumedLots = append(consumedLots, PointLot{ID: lot.ID, Login: login, Amount: consumed, ExpiresAt: lot.ExpiresAt})
+	}
+	return consumedLots, nil
+}
+
+func (tx *DBTransaction) GetExpiredPoints(ctx context.Context, login string) (float32, error) {
+	var expired float32
+	err := tx.QueryRow(ctx, `
+			SELECT COALESCE(SUM(remaining), 0) FROM point_lots
+			WHERE login = $1 AND remaining > 0 AND expires_at <= NOW()
+		`, login).Scan(&expired)
+	if err != nil {
+		return 0, err
+	}
+	return expired, nil
+}
+
+func (tx *DBTransaction) ExpirePointLots(ctx context.Context, login string, amount float32) error {
+	rows, err := tx.Query(ctx, `
+			SELECT id, remaining FROM point_lots
+			WHERE login = $1 AND remaining > 0 AND expires_at <= NOW()
+			ORDER BY expires_at ASC, id ASC
+			FOR UPDATE
+		`, login)
+	if err != nil {
+		return err
+	}
+	var lots []PointLot
+	for rows.Next() {
+		var lot PointLot
+		err = rows.Scan(&lot.ID, &lot.Remaining)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		lots = append(lots, lot)
+	}
+	rows.Close()
+	for _, lot := range lots {
+		if amount <= 0 {
+			break
+		}
+		expired := min(lot.Remaining, amount)
+		_, err = tx.Exec(ctx, `
+				UPDATE point_lots SET remaining = remaining - $1, expired_at = NOW() WHERE id = $2
+			`, expired, lot.ID)
+		if err != nil {
+			return err
+		}
+		amount -= expired
+	}
+	return nil
+}
+
+func (tx *DBTransaction) StoreWebhookMessages(ctx context.Context, login string, event string, payload []byte) error {
+	_, err := tx.Exec(ctx, `
+			INSERT INTO webhook_outbox (webhook_id, event, payload)
+			SELECT id, $2, $3 FROM webhooks WHERE login = $1 AND deleted_at IS NULL
+		`, login, event, payload)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (tx *DBTransaction) GetWebhookMessageToDeliver(ctx context.Context) (WebhookMessage, error) {
+	var m WebhookMessage
+	err := tx.QueryRow(ctx, `
+			SELECT o.id, o.webhook_id, o.event, o.payload, o.attempts, o.next_attempt_at, o.created_at, w.url, w.secret
+			FROM webhook_outbox o
+			JOIN webhooks w ON w.id = o.webhook_id
+			WHERE o.delivered_at IS NULL AND o.failed_at IS NULL AND o.next_attempt_at <= NOW()
+				AND w.deleted_at IS NULL
+			ORDER BY o.next_attempt_at ASC
+			LIMIT 1 FOR UPDATE OF o SKIP LOCKED
+		`).
+		Scan(&m.ID, &m.WebhookID, &m.Event, &m.Payload, &m.Attempts, &m.NextAttemptAt, &m.CreatedAt, &m.URL, &m.Secret)
+	if err != nil {
+		return m, err
+	}
+	return m, nil
+}
+
+func (tx *DBTransaction) UpdateWebhookMessage(ctx context.Context, message WebhookMessage) error {
+	_, err := tx.Exec(ctx, `
+			UPDATE webhook_outbox SET attempts = $1, next_attempt_at = $2, delivered_at = $3, failed_at = $4 WHERE id = $5
+		`, message.Attempts, message.NextAttemptAt, message.DeliveredAt, message.FailedAt, message.ID)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (tx *DBTransaction) StoreWebhookDelivery(ctx context.Context, delivery WebhookDelivery) error {
+	_, err := tx.Exec(ctx, `
+			INSERT INTO webhook_deliveries (message_id, webhook_id, attempt, status_code, error, duration_ms)
+			VALUES ($1, $2, $3, $4, $5, $6)
+		`, delivery.MessageID, delivery.WebhookID, delivery.Attempt, delivery.StatusCode, delivery.Error, delivery.DurationMs)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (tx *DBTransaction) StoreAPIKey(ctx context.Context, key APIKey) (int64, error) {
+	var id int64
+	err := tx.QueryRow(ctx, `
+			INSERT INTO api_keys (login, name, prefix, key_hash, scopes, rate_limit) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id
+		`, key.Login, key.Name, key.Prefix, key.Hash, key.Scopes, key.RateLimit).
+		Scan(&id)
+	if err != nil {
+		return id, err
+	}
+	return id, nil
+}
+
+func (tx *DBTransaction) RevokeAPIKey(ctx context.Context, id int64, login string) error {
+	tag, err := tx.Exec(ctx, `
+			UPDATE api_keys SET revoked_at = NOW() WHERE id = $1 AND login = $2 AND revoked_at IS NULL
+		`, id, login)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
 	return nil
 }
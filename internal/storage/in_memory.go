@@ -3,12 +3,31 @@ package storage
 import (
 	"context"
 	"errors"
+	"slices"
+	"strings"
 	"time"
 )
 
 type MemoryStorage struct {
-	Users  map[string]User
-	Orders map[string]Order
+	Users         map[string]User
+	Orders        map[string]Order
+	StatusHistory map[string][]OrderStatusHistory
+	PushedAt      map[string]time.Time
+	PolledAt      map[string]time.Time
+	Provisional   map[string]float32
+	AdminActions  []AdminAction
+	Movements     []BalanceMovement
+	OrderChanges  []OrderChange
+	AuditEvents   []AuditEvent
+	APIKeys       []APIKey
+	Webhooks      []Webhook
+	WebhookOutbox []WebhookMessage
+	Deliveries    []WebhookDelivery
+	PointLots     []PointLot
+	Holds         []Hold
+	Campaigns     []Campaign
+	Referrals     []Referral
+	FraudReviews  []FraudReview
 }
 
 type MemoryTransaction struct {
@@ -17,25 +36,175 @@ type MemoryTransaction struct {
 
 func NewMemory() *MemoryStorage {
 	return &MemoryStorage{
-		Users:  make(map[string]User),
-		Orders: make(map[string]Order),
+		Users:         make(map[string]User),
+		Orders:        make(map[string]Order),
+		StatusHistory: make(map[string][]OrderStatusHistory),
+		PushedAt:      make(map[string]time.Time),
+		PolledAt:      make(map[string]time.Time),
+		Provisional:   make(map[string]float32),
 	}
 }
 
 func (m *MemoryStorage) GetUser(_ context.Context, login string) (User, error) {
 	user, ok := m.Users[login]
 	if !ok {
-		return user, errors.New("user not found")
+		return user, ErrUserNotFound
 	}
 	return user, nil
 }
 
+func (m *MemoryStorage) GetUserByReferralCode(_ context.Context, code string) (User, error) {
+	for _, user := range m.Users {
+		if user.ReferralCode != "" && user.ReferralCode == code {
+			return user, nil
+		}
+	}
+	return User{}, ErrUserNotFound
+}
+
+func (m *MemoryStorage) GetUploadedOrdersCount(_ context.Context, login string, since time.Time) (int64, error) {
+	var count int64
+	for _, order := range m.Orders {
+		if order.Login == login && !order.UploadedAt.Before(since) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (m *MemoryStorage) GetOrdersCountByStatus(_ context.Context, login string) (map[string]int64, error) {
+	counts := make(map[string]int64)
+	for _, order := range m.Orders {
+		if order.Login == login {
+			counts[order.Status]++
+		}
+	}
+	return counts, nil
+}
+
+func (m *MemoryStorage) StoreFraudReview(_ context.Context, review FraudReview) (int64, error) {
+	review.ID = int64(len(m.FraudReviews) + 1)
+	review.Status = FraudReviewPending
+	review.CreatedAt = time.Now()
+	m.FraudReviews = append(m.FraudReviews, review)
+	return review.ID, nil
+}
+
+func (m *MemoryStorage) GetFraudReviews(_ context.Context, status string, limit int) ([]FraudReview, error) {
+	var reviews []FraudReview
+	for _, review := range m.FraudReviews {
+		if review.Status != status {
+			continue
+		}
+		reviews = append(reviews, review)
+		if len(reviews) == limit {
+			break
+		}
+	}
+	return reviews, nil
+}
+
+func (m *MemoryStorage) ResolveFraudReview(_ context.Context, review FraudReview) error {
+	if review.ID < 1 || review.ID > int64(len(m.FraudReviews)) || m.FraudReviews[review.ID-1].Status != FraudReviewPending {
+		return errors.New("pending fraud review not found")
+	}
+	now := time.Now()
+	current := &m.FraudReviews[review.ID-1]
+	current.Status = review.Status
+	current.Operator = review.Operator
+	current.Comment = review.Comment
+	current.ResolvedAt = &now
+	return nil
+}
+
+func (m *MemoryStorage) UseApprovedFraudReview(
+	_ context.Context,
+	login string,
+	action string,
+	orderNumber string,
+	sum float32,
+) (bool, error) {
+	for i, r := range m.FraudReviews {
+		if r.Login == login && r.Action == action && r.OrderNumber == orderNumber && r.Sum == sum &&
+			r.Status == FraudReviewApproved && r.UsedAt == nil {
+			now := time.Now()
+			m.FraudReviews[i].UsedAt = &now
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *MemoryStorage) GetReferrals(_ context.Context, referrer string) ([]Referral, error) {
+	var referrals []Referral
+	for i := len(m.Referrals) - 1; i >= 0; i-- {
+		if m.Referrals[i].Referrer == referrer {
+			referrals = append(referrals, m.Referrals[i])
+		}
+	}
+	return referrals, nil
+}
+
+func (m *MemoryStorage) SetUserReferral(_ context.Context, login string, referralCode string, registrationIP string) error {
+	if currentUser, ok := m.Users[login]; ok {
+		currentUser.ReferralCode = referralCode
+		currentUser.RegistrationIP = registrationIP
+		m.Users[login] = currentUser
+		return nil
+	}
+	return errors.New("user not found")
+}
+
+func (m *MemoryStorage) StoreReferral(_ context.Context, referral Referral) error {
+	if slices.ContainsFunc(m.Referrals, func(r Referral) bool { return r.Referee == referral.Referee }) {
+		return errors.New("referral already exists")
+	}
+	referral.ID = int64(len(m.Referrals) + 1)
+	referral.CreatedAt = time.Now()
+	m.Referrals = append(m.Referrals, referral)
+	return nil
+}
+
+func (m *MemoryStorage) GetReferralsCount(_ context.Context, referrer string) (int64, error) {
+	var count int64
+	for _, referral := range m.Referrals {
+		if referral.Referrer == referrer && referral.Status != ReferralRejected {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (m *MemoryStorage) GetPendingReferralForUpdate(_ context.Context, referee string) (Referral, error) {
+	for _, referral := range m.Referrals {
+		if referral.Referee == referee && referral.Status == ReferralPending {
+			return referral, nil
+		}
+	}
+	return Referral{}, nil
+}
+
+func (m *MemoryStorage) RewardReferral(_ context.Context, referral Referral) error {
+	if referral.ID < 1 || referral.ID > int64(len(m.Referrals)) {
+		return errors.New("referral not found")
+	}
+	now := time.Now()
+	current := &m.Referrals[referral.ID-1]
+	current.Status = ReferralRewarded
+	current.ReferrerBonus = referral.ReferrerBonus
+	current.RefereeBonus = referral.RefereeBonus
+	current.RewardedAt = &now
+	return nil
+}
+
 func (m *MemoryStorage) StoreUser(_ context.Context, login string, passwordHash string) error {
 	m.Users[login] = User{
 		Login:     login,
 		Password:  passwordHash,
 		Balance:   0,
 		Withdrawn: 0,
+		Role:      RoleUser,
+		CreatedAt: time.Now(),
 	}
 	return nil
 }
@@ -62,29 +231,443 @@ func (m *MemoryStorage) GetOrders(_ context.Context, login string, onlyWithdrawn
 	return orders, nil
 }
 
+func (m *MemoryStorage) GetOrdersPage(_ context.Context, filter OrdersFilter) ([]Order, error) {
+	var orders []Order
+	for _, order := range m.Orders {
+		if order.Login != filter.Login {
+			continue
+		}
+		if len(filter.Statuses) > 0 && !slices.Contains(filter.Statuses, order.Status) {
+			continue
+		}
+		if filter.From != nil && order.UploadedAt.Before(*filter.From) {
+			continue
+		}
+		if filter.To != nil && !order.UploadedAt.Before(*filter.To) {
+			continue
+		}
+		if filter.Cursor != nil {
+			cmp := compareOrderPosition(order, filter.Cursor.UploadedAt, filter.Cursor.Number)
+			if (!filter.Descending && cmp <= 0) || (filter.Descending && cmp >= 0) {
+				continue
+			}
+		}
+		orders = append(orders, order)
+	}
+	slices.SortFunc(orders, func(a, b Order) int {
+		cmp := compareOrderPosition(a, b.UploadedAt, b.Number)
+		if filter.Descending {
+			return -cmp
+		}
+		return cmp
+	})
+	if filter.Limit > 0 && filter.Limit < len(orders) {
+		orders = orders[:filter.Limit]
+	}
+	return orders, nil
+}
+
+func compareOrderPosition(order Order, uploadedAt time.Time, number string) int {
+	if cmp := order.UploadedAt.Compare(uploadedAt); cmp != 0 {
+		return cmp
+	}
+	return strings.Compare(order.Number, number)
+}
+
 func (m *MemoryStorage) GetOrdersCountToUpdate(_ context.Context) (int64, error) {
 	var count int64
 	for _, order := range m.Orders {
-		if order.Status == "NEW" || order.Status == "PROCESSING" || order.Status == "REGISTERED" {
+		if m.isOrderToUpdate(order) {
 			count++
 		}
 	}
 	return count, nil
 }
 
+func (m *MemoryStorage) isOrderToUpdate(order Order) bool {
+	if order.Status != "NEW" && order.Status != "PROCESSING" && order.Status != "REGISTERED" {
+		return false
+	}
+	pushedAt, ok := m.PushedAt[order.Number]
+	return !ok || time.Since(pushedAt) > PushedStatusTTL
+}
+
 func (m *MemoryStorage) StoreOrder(_ context.Context, number, login string) error {
 	loc, err := time.LoadLocation("Europe/Moscow")
 	if err != nil {
 		return err
 	}
+	now := time.Now().In(loc)
 	m.Orders[number] = Order{
 		Number:     number,
 		Status:     "NEW",
 		Accrual:    0,
 		Withdrawn:  0,
 		Login:      login,
-		UploadedAt: time.Now().In(loc),
+		UploadedAt: now,
 	}
+	m.StatusHistory[number] = append(m.StatusHistory[number], OrderStatusHistory{
+		Status:    "NEW",
+		CreatedAt: now,
+	})
+	return nil
+}
+
+func (m *MemoryStorage) GetOrderStatusHistory(_ context.Context, number string) ([]OrderStatusHistory, error) {
+	return m.StatusHistory[number], nil
+}
+
+func (m *MemoryStorage) SetUserRole(_ context.Context, login string, role string) error {
+	if currentUser, ok := m.Users[login]; ok {
+		currentUser.Role = role
+		m.Users[login] = currentUser
+		return nil
+	}
+	return errors.New("user not found")
+}
+
+func (m *MemoryStorage) StoreAdminAction(_ context.Context, action AdminAction) error {
+	action.CreatedAt = time.Now()
+	m.AdminActions = append(m.AdminActions, action)
+	return nil
+}
+
+func (m *MemoryStorage) StoreAuditEvent(_ context.Context, event AuditEvent) error {
+	event.ID = int64(len(m.AuditEvents) + 1)
+	event.CreatedAt = time.Now()
+	m.AuditEvents = append(m.AuditEvents, event)
+	return nil
+}
+
+func (m *MemoryStorage) GetAuditEvents(_ context.Context, filter AuditFilter) ([]AuditEvent, error) {
+	var events []AuditEvent
+	for i := len(m.AuditEvents) - 1; i >= 0; i-- {
+		event := m.AuditEvents[i]
+		if filter.Actor != "" && event.Actor != filter.Actor {
+			continue
+		}
+		if filter.Action != "" && event.Action != filter.Action {
+			continue
+		}
+		if filter.Target != "" && event.Target != filter.Target {
+			continue
+		}
+		if filter.From != nil && event.CreatedAt.Before(*filter.From) {
+			continue
+		}
+		if filter.To != nil && !event.CreatedAt.Before(*filter.To) {
+			continue
+		}
+		events = append(events, event)
+	}
+	if filter.Offset >= len(events) {
+		return []AuditEvent{}, nil
+	}
+	events = events[filter.Offset:]
+	if filter.Limit > 0 && filter.Limit < len(events) {
+		events = events[:filter.Limit]
+	}
+	return events, nil
+}
+
+func (m *MemoryStorage) StoreAPIKey(_ context.Context, key APIKey) (int64, error) {
+	key.ID = int64(len(m.APIKeys) + 1)
+	key.CreatedAt = time.Now()
+	m.APIKeys = append(m.APIKeys, key)
+	return key.ID, nil
+}
+
+func (m *MemoryStorage) GetAPIKeys(_ context.Context, login string) ([]APIKey, error) {
+	var keys []APIKey
+	for _, key := range m.APIKeys {
+		if key.Login == login {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+func (m *MemoryStorage) GetAPIKeyByHash(_ context.Context, keyHash string) (APIKey, error) {
+	for _, key := range m.APIKeys {
+		if key.Hash == keyHash {
+			return key, nil
+		}
+	}
+	return APIKey{}, errors.New("api key not found")
+}
+
+func (m *MemoryStorage) RevokeAPIKey(_ context.Context, id int64, login string) error {
+	for i, key := range m.APIKeys {
+		if key.ID == id && key.Login == login && key.RevokedAt == nil {
+			now := time.Now()
+			m.APIKeys[i].RevokedAt = &now
+			return nil
+		}
+	}
+	return errors.New("api key not found")
+}
+
+func (m *MemoryStorage) TouchAPIKey(_ context.Context, id int64) error {
+	for i, key := range m.APIKeys {
+		if key.ID == id {
+			now := time.Now()
+			m.APIKeys[i].LastUsedAt = &now
+			return nil
+		}
+	}
+	return errors.New("api key not found")
+}
+
+func (m *MemoryStorage) StoreWebhook(_ context.Context, webhook Webhook) (int64, error) {
+	webhook.ID = int64(len(m.Webhooks) + 1)
+	webhook.CreatedAt = time.Now()
+	m.Webhooks = append(m.Webhooks, webhook)
+	return webhook.ID, nil
+}
+
+func (m *MemoryStorage) GetWebhooks(_ context.Context, login string) ([]Webhook, error) {
+	var webhooks []Webhook
+	for _, webhook := range m.Webhooks {
+		if webhook.Login == login && webhook.DeletedAt == nil {
+			webhooks = append(webhooks, webhook)
+		}
+	}
+	return webhooks, nil
+}
+
+func (m *MemoryStorage) DeleteWebhook(_ context.Context, id int64, login string) error {
+	for i, webhook := range m.Webhooks {
+		if webhook.ID == id && webhook.Login == login && webhook.DeletedAt == nil {
+			now := time.Now()
+			m.Webhooks[i].DeletedAt = &now
+			return nil
+		}
+	}
+	return errors.New("webhook not found")
+}
+
+func (m *MemoryStorage) GetWebhookDeliveries(_ context.Context, webhookID int64, login string, limit int) ([]WebhookDelivery, error) {
+	var deliveries []WebhookDelivery
+	if !slices.ContainsFunc(m.Webhooks, func(w Webhook) bool { return w.ID == webhookID && w.Login == login }) {
+		return deliveries, nil
+	}
+	for i := len(m.Deliveries) - 1; i >= 0; i-- {
+		delivery := m.Deliveries[i]
+		if delivery.WebhookID != webhookID {
+			continue
+		}
+		deliveries = append(deliveries, delivery)
+		if limit > 0 && len(deliveries) == limit {
+			break
+		}
+	}
+	return deliveries, nil
+}
+
+func (m *MemoryStorage) GetWebhookMessagesCountToDeliver(_ context.Context) (int64, error) {
+	var count int64
+	now := time.Now()
+	for _, message := range m.WebhookOutbox {
+		if message.DeliveredAt == nil && message.FailedAt == nil && !message.NextAttemptAt.After(now) &&
+			m.Webhooks[message.WebhookID-1].DeletedAt == nil {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (m *MemoryStorage) GetFlaggedUsers(_ context.Context) ([]User, error) {
+	var users []User
+	for _, user := range m.Users {
+		if user.FlaggedAt != nil {
+			users = append(users, user)
+		}
+	}
+	slices.SortFunc(users, func(a, b User) int {
+		return a.FlaggedAt.Compare(*b.FlaggedAt)
+	})
+	return users, nil
+}
+
+func (m *MemoryStorage) ClearUserFlag(_ context.Context, login string) error {
+	if currentUser, ok := m.Users[login]; ok && currentUser.FlaggedAt != nil {
+		currentUser.FlaggedAt = nil
+		currentUser.FlagReason = ""
+		m.Users[login] = currentUser
+		return nil
+	}
+	return errors.New("flagged user not found")
+}
+
+func (m *MemoryStorage) StoreCampaign(_ context.Context, campaign Campaign) (int64, error) {
+	campaign.ID = int64(len(m.Campaigns) + 1)
+	campaign.CreatedAt = time.Now()
+	m.Campaigns = append(m.Campaigns, campaign)
+	return campaign.ID, nil
+}
+
+func (m *MemoryStorage) GetCampaigns(_ context.Context) ([]Campaign, error) {
+	var campaigns []Campaign
+	for i := len(m.Campaigns) - 1; i >= 0; i-- {
+		if m.Campaigns[i].DeletedAt == nil {
+			campaigns = append(campaigns, m.Campaigns[i])
+		}
+	}
+	slices.SortStableFunc(campaigns, func(a, b Campaign) int {
+		return b.StartsAt.Compare(a.StartsAt)
+	})
+	return campaigns, nil
+}
+
+func (m *MemoryStorage) GetCampaign(_ context.Context, id int64) (Campaign, error) {
+	if id < 1 || id > int64(len(m.Campaigns)) || m.Campaigns[id-1].DeletedAt != nil {
+		return Campaign{}, errors.New("campaign not found")
+	}
+	return m.Campaigns[id-1], nil
+}
+
+func (m *MemoryStorage) UpdateCampaign(_ context.Context, campaign Campaign) error {
+	if campaign.ID < 1 || campaign.ID > int64(len(m.Campaigns)) || m.Campaigns[campaign.ID-1].DeletedAt != nil {
+		return errors.New("campaign not found")
+	}
+	campaign.CreatedAt = m.Campaigns[campaign.ID-1].CreatedAt
+	m.Campaigns[campaign.ID-1] = campaign
+	return nil
+}
+
+func (m *MemoryStorage) DeleteCampaign(_ context.Context, id int64) error {
+	if id < 1 || id > int64(len(m.Campaigns)) || m.Campaigns[id-1].DeletedAt != nil {
+		return errors.New("campaign not found")
+	}
+	now := time.Now()
+	m.Campaigns[id-1].DeletedAt = &now
+	return nil
+}
+
+func (m *MemoryStorage) GetAccrualTotal(_ context.Context, login string, since time.Time) (float32, error) {
+	var total float32
+	for _, movement := range m.Movements {
+		if movement.Login == login && isAccrualTotalMovement(movement, since) {
+			total += movement.Amount
+		}
+	}
+	return total, nil
+}
+
+func (m *MemoryStorage) GetAccrualTotals(_ context.Context, since time.Time) ([]AccrualTotal, error) {
+	totals := make([]AccrualTotal, 0, len(m.Users))
+	for _, user := range m.Users {
+		total := AccrualTotal{Login: user.Login, Tier: user.Tier}
+		for _, movement := range m.Movements {
+			if movement.Login == user.Login && isAccrualTotalMovement(movement, since) {
+				total.Total += movement.Amount
+			}
+		}
+		totals = append(totals, total)
+	}
+	return totals, nil
+}
+
+func isAccrualTotalMovement(movement BalanceMovement, since time.Time) bool {
+	return (movement.Kind == MovementAccrual || movement.Kind == MovementReversal) && !movement.CreatedAt.Before(since)
+}
+
+func (m *MemoryStorage) SetUserTier(_ context.Context, login string, tier string) error {
+	if currentUser, ok := m.Users[login]; ok {
+		currentUser.Tier = tier
+		m.Users[login] = currentUser
+		return nil
+	}
+	return errors.New("user not found")
+}
+
+func (m *MemoryStorage) GetBalanceMovements(_ context.Context, login string, limit int) ([]BalanceMovement, error) {
+	var movements []BalanceMovement
+	for i := len(m.Movements) - 1; i >= 0 && len(movements) < limit; i-- {
+		if m.Movements[i].Login == login {
+			movements = append(movements, m.Movements[i])
+		}
+	}
+	return movements, nil
+}
+
+func (m *MemoryStorage) GetHolds(_ context.Context, login string) ([]Hold, error) {
+	var holds []Hold
+	for i := len(m.Holds) - 1; i >= 0; i-- {
+		if m.Holds[i].Login == login {
+			holds = append(holds, m.Holds[i])
+		}
+	}
+	return holds, nil
+}
+
+func (m *MemoryStorage) GetExpiredHolds(_ context.Context, limit int) ([]Hold, error) {
+	var holds []Hold
+	now := time.Now()
+	for _, hold := range m.Holds {
+		if hold.Status == HoldActive && !hold.ExpiresAt.After(now) && len(holds) < limit {
+			holds = append(holds, hold)
+		}
+	}
+	return holds, nil
+}
+
+func (m *MemoryStorage) GetPendingBalance(_ context.Context, login string) ([]PendingBalance, error) {
+	var pending []PendingBalance
+	for _, order := range m.Orders {
+		if order.Login != login || !slices.Contains([]string{"NEW", "REGISTERED", "PROCESSING"}, order.Status) {
+			continue
+		}
+		i := slices.IndexFunc(pending, func(p PendingBalance) bool { return p.Status == order.Status })
+		if i == -1 {
+			pending = append(pending, PendingBalance{Status: order.Status})
+			i = len(pending) - 1
+		}
+		pending[i].Count++
+		pending[i].Accrual += m.Provisional[order.Number]
+	}
+	slices.SortFunc(pending, func(a, b PendingBalance) int {
+		return strings.Compare(a.Status, b.Status)
+	})
+	return pending, nil
+}
+
+func (m *MemoryStorage) GetExpiringPointLots(_ context.Context, login string, limit int) ([]PointLot, error) {
+	var lots []PointLot
+	now := time.Now()
+	for _, lot := range m.PointLots {
+		if lot.Login == login && lot.Remaining > 0 && lot.ExpiresAt != nil && lot.ExpiresAt.After(now) {
+			lots = append(lots, lot)
+		}
+	}
+	slices.SortStableFunc(lots, func(a, b PointLot) int {
+		return a.ExpiresAt.Compare(*b.ExpiresAt)
+	})
+	if len(lots) > limit {
+		lots = lots[:limit]
+	}
+	return lots, nil
+}
+
+func (m *MemoryStorage) GetLoginsWithExpiredPoints(_ context.Context, limit int) ([]string, error) {
+	var logins []string
+	now := time.Now()
+	for _, lot := range m.PointLots {
+		if lot.Remaining > 0 && lot.ExpiresAt != nil && !lot.ExpiresAt.After(now) && !slices.Contains(logins, lot.Login) {
+			// Баллы, целиком зарезервированные холдами, сгорят после закрытия холда
+			if user, ok := m.Users[lot.Login]; ok && user.Balance <= user.Held {
+				continue
+			}
+			logins = append(logins, lot.Login)
+		}
+	}
+	if len(logins) > limit {
+		logins = logins[:limit]
+	}
+	return logins, nil
+}
+
+func (m *MemoryStorage) Ping(_ context.Context) error {
 	return nil
 }
 
@@ -104,15 +687,25 @@ func (m *MemoryStorage) Rollback(_ context.Context) error {
 }
 
 func (m *MemoryStorage) GetOrderToUpdateStatus(_ context.Context) (OrderToUpdate, error) {
+	var next *Order
 	for _, order := range m.Orders {
-		if order.Status == "NEW" || order.Status == "PROCESSING" || order.Status == "REGISTERED" {
-			return OrderToUpdate{
-				Number: order.Number,
-				Login:  order.Login,
-			}, nil
+		if !m.isOrderToUpdate(order) {
+			continue
 		}
+		if next == nil || m.PolledAt[order.Number].Before(m.PolledAt[next.Number]) ||
+			m.PolledAt[order.Number].Equal(m.PolledAt[next.Number]) && order.UploadedAt.Before(next.UploadedAt) {
+			next = &order
+		}
+	}
+	if next == nil {
+		return OrderToUpdate{}, nil
 	}
-	return OrderToUpdate{}, nil
+	m.PolledAt[next.Number] = time.Now()
+	return OrderToUpdate{
+		Number: next.Number,
+		Login:  next.Login,
+		Status: next.Status,
+	}, nil
 }
 
 func (m *MemoryStorage) UpdateOrderStatus(_ context.Context, order OrderStatus) error {
@@ -123,14 +716,60 @@ func (m *MemoryStorage) UpdateOrderStatus(_ context.Context, order OrderStatus)
 	if currentOrder, ok := m.Orders[order.Number]; ok {
 		currentOrder.Status = order.Status
 		currentOrder.Accrual = order.Accrual
+		currentOrder.TierBonus = order.TierBonus
+		currentOrder.CampaignBonus = order.CampaignBonus
 		now := time.Now().In(loc)
 		currentOrder.ProcessedAt = &now
+		if order.Provider != "" {
+			currentOrder.AccrualProvider = &order.Provider
+		}
 		m.Orders[order.Number] = currentOrder
+		delete(m.Provisional, order.Number)
+		m.StatusHistory[order.Number] = append(m.StatusHistory[order.Number], OrderStatusHistory{
+			Status:    order.Status,
+			Accrual:   order.Accrual,
+			CreatedAt: now,
+		})
 		return nil
 	}
 	return errors.New("order not found")
 }
 
+func (m *MemoryStorage) StorePushedOrderStatus(_ context.Context, order OrderStatus) error {
+	currentOrder, ok := m.Orders[order.Number]
+	if !ok {
+		return errors.New("order not found")
+	}
+	now := time.Now()
+	if currentOrder.Status != order.Status {
+		currentOrder.Status = order.Status
+		m.Orders[order.Number] = currentOrder
+		m.StatusHistory[order.Number] = append(m.StatusHistory[order.Number], OrderStatusHistory{
+			Status:    order.Status,
+			CreatedAt: now,
+		})
+	}
+	if order.Accrual > 0 {
+		m.Provisional[order.Number] = order.Accrual
+	}
+	m.PushedAt[order.Number] = now
+	return nil
+}
+
+func (m *MemoryStorage) StoreProvisionalOrderStatus(ctx context.Context, order OrderStatus) error {
+	pushedAt, pushed := m.PushedAt[order.Number]
+	err := m.StorePushedOrderStatus(ctx, order)
+	if err != nil {
+		return err
+	}
+	if pushed {
+		m.PushedAt[order.Number] = pushedAt
+	} else {
+		delete(m.PushedAt, order.Number)
+	}
+	return nil
+}
+
 func (m *MemoryStorage) AccrualUserBalance(_ context.Context, accraul float32, login string) error {
 	if currentUser, ok := m.Users[login]; ok {
 		currentUser.Balance += accraul
@@ -148,10 +787,18 @@ func (m *MemoryStorage) GetUserWithLock(_ context.Context, login string) (User,
 	return user, nil
 }
 
-func (m *MemoryStorage) GetOrderWithLock(_ context.Context, number string, _ string) (Order, error) {
+func (m *MemoryStorage) GetOrderWithLock(_ context.Context, number string, login string) (Order, error) {
 	order, ok := m.Orders[number]
 	if !ok {
-		return order, errors.New("order not found")
+		now := time.Now()
+		order = Order{
+			Number:      number,
+			Status:      "PROCESSED",
+			Login:       login,
+			UploadedAt:  now,
+			ProcessedAt: &now,
+		}
+		m.Orders[number] = order
 	}
 	return order, nil
 }
@@ -174,3 +821,254 @@ func (m *MemoryStorage) WithdrawOrderBalance(_ context.Context, number string, w
 	}
 	return errors.New("order not found")
 }
+
+func (m *MemoryStorage) GetOrderForUpdate(_ context.Context, number string) (Order, error) {
+	order, ok := m.Orders[number]
+	if !ok {
+		return order, errors.New("order not found")
+	}
+	return order, nil
+}
+
+func (m *MemoryStorage) StoreBalanceMovement(_ context.Context, movement BalanceMovement) error {
+	movement.CreatedAt = time.Now()
+	m.Movements = append(m.Movements, movement)
+	return nil
+}
+
+func (m *MemoryStorage) StoreOrderChange(_ context.Context, change OrderChange) error {
+	change.CreatedAt = time.Now()
+	m.OrderChanges = append(m.OrderChanges, change)
+	return nil
+}
+
+func (m *MemoryStorage) InsertOrder(ctx context.Context, number, login string) (bool, error) {
+	if _, ok := m.Orders[number]; ok {
+		return false, nil
+	}
+	err := m.StoreOrder(ctx, number, login)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (m *MemoryStorage) FlagUser(_ context.Context, login string, reason string) error {
+	if currentUser, ok := m.Users[login]; ok {
+		if currentUser.FlaggedAt == nil {
+			now := time.Now()
+			currentUser.FlaggedAt = &now
+		}
+		currentUser.FlagReason = reason
+		m.Users[login] = currentUser
+		return nil
+	}
+	return errors.New("user not found")
+}
+
+func (m *MemoryStorage) GetActiveCampaigns(_ context.Context, at time.Time) ([]Campaign, error) {
+	var campaigns []Campaign
+	for _, campaign := range m.Campaigns {
+		if campaign.DeletedAt == nil && !campaign.StartsAt.After(at) && campaign.EndsAt.After(at) {
+			campaigns = append(campaigns, campaign)
+		}
+	}
+	return campaigns, nil
+}
+
+func (m *MemoryStorage) GetProcessedOrdersCount(_ context.Context, login string) (int64, error) {
+	var count int64
+	for _, order := range m.Orders {
+		if order.Login == login && order.Status == "PROCESSED" {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (m *MemoryStorage) GetMovementsCount(_ context.Context, login string, kind string, since time.Time) (int64, error) {
+	var count int64
+	for _, movement := range m.Movements {
+		if movement.Login == login && movement.Kind == kind && !movement.CreatedAt.Before(since) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (m *MemoryStorage) GetMovementsSum(_ context.Context, login string, kind string, since time.Time) (float32, error) {
+	var sum float32
+	for _, movement := range m.Movements {
+		if movement.Login == login && movement.Kind == kind && !movement.CreatedAt.Before(since) {
+			sum += movement.Amount
+		}
+	}
+	return sum, nil
+}
+
+func (m *MemoryStorage) StoreHold(_ context.Context, hold Hold) (int64, error) {
+	currentUser, ok := m.Users[hold.Login]
+	if !ok {
+		return 0, errors.New("user not found")
+	}
+	currentUser.Held += hold.Amount
+	m.Users[hold.Login] = currentUser
+	hold.ID = int64(len(m.Holds) + 1)
+	hold.Status = HoldActive
+	hold.CreatedAt = time.Now()
+	m.Holds = append(m.Holds, hold)
+	return hold.ID, nil
+}
+
+func (m *MemoryStorage) GetHoldForUpdate(_ context.Context, id int64) (Hold, error) {
+	if id < 1 || id > int64(len(m.Holds)) {
+		return Hold{}, errors.New("hold not found")
+	}
+	return m.Holds[id-1], nil
+}
+
+func (m *MemoryStorage) CloseHold(_ context.Context, hold Hold, status string) error {
+	if hold.ID < 1 || hold.ID > int64(len(m.Holds)) {
+		return errors.New("hold not found")
+	}
+	now := time.Now()
+	m.Holds[hold.ID-1].Status = status
+	m.Holds[hold.ID-1].ClosedAt = &now
+	if currentUser, ok := m.Users[hold.Login]; ok {
+		currentUser.Held -= hold.Amount
+		m.Users[hold.Login] = currentUser
+	}
+	return nil
+}
+
+func (m *MemoryStorage) StorePointLot(_ context.Context, lot PointLot) error {
+	lot.ID = int64(len(m.PointLots) + 1)
+	lot.Remaining = lot.Amount
+	lot.CreatedAt = time.Now()
+	m.PointLots = append(m.PointLots, lot)
+	return nil
+}
+
+func (m *MemoryStorage) ConsumePointLots(_ context.Context, login string, amount float32) ([]PointLot, error) {
+	now := time.Now()
+	var active []int
+	for i, lot := range m.PointLots {
+		if lot.Login == login && lot.Remaining > 0 && (lot.ExpiresAt == nil || lot.ExpiresAt.After(now)) {
+			active = append(active, i)
+		}
+	}
+	// Партии без срока расходуются последними
+	slices.SortStableFunc(active, func(a, b int) int {
+		expiresA, expiresB := m.PointLots[a].ExpiresAt, m.PointLots[b].ExpiresAt
+		switch {
+		case expiresA == nil && expiresB == nil:
+			return 0
+		case expiresA == nil:
+			return 1
+		case expiresB == nil:
+			return -1
+		}
+		return expiresA.Compare(*expiresB)
+	})
+	var consumedLots []PointLot
+	for _, i := range active {
+		if amount <= 0 {
+			break
+		}
+		consumed := min(m.PointLots[i].Remaining, amount)
+		m.PointLots[i].Remaining -= consumed
+		amount -= consumed
+		lot := m.PointLots[i]
+		consumedLots = append(consumedLots, PointLot{ID: lot.ID, Login: login, Amount: consumed, ExpiresAt: lot.ExpiresAt})
+	}
+	return consumedLots, nil
+}
+
+func (m *MemoryStorage) GetExpiredPoints(_ context.Context, login string) (float32, error) {
+	var expired float32
+	now := time.Now()
+	for _, lot := range m.PointLots {
+		if lot.Login == login && lot.Remaining > 0 && lot.ExpiresAt != nil && !lot.ExpiresAt.After(now) {
+			expired += lot.Remaining
+		}
+	}
+	return expired, nil
+}
+
+func (m *MemoryStorage) ExpirePointLots(_ context.Context, login string, amount float32) error {
+	now := time.Now()
+	var expired []int
+	for i, lot := range m.PointLots {
+		if lot.Login == login && lot.Remaining > 0 && lot.ExpiresAt != nil && !lot.ExpiresAt.After(now) {
+			expired = append(expired, i)
+		}
+	}
+	slices.SortStableFunc(expired, func(a, b int) int {
+		return m.PointLots[a].ExpiresAt.Compare(*m.PointLots[b].ExpiresAt)
+	})
+	for _, i := range expired {
+		if amount <= 0 {
+			break
+		}
+		consumed := min(m.PointLots[i].Remaining, amount)
+		m.PointLots[i].Remaining -= consumed
+		amount -= consumed
+	}
+	return nil
+}
+
+func (m *MemoryStorage) StoreWebhookMessages(_ context.Context, login string, event string, payload []byte) error {
+	now := time.Now()
+	for _, webhook := range m.Webhooks {
+		if webhook.Login != login || webhook.DeletedAt != nil {
+			continue
+		}
+		m.WebhookOutbox = append(m.WebhookOutbox, WebhookMessage{
+			ID:            int64(len(m.WebhookOutbox) + 1),
+			WebhookID:     webhook.ID,
+			Event:         event,
+			Payload:       payload,
+			NextAttemptAt: now,
+			CreatedAt:     now,
+		})
+	}
+	return nil
+}
+
+func (m *MemoryStorage) GetWebhookMessageToDeliver(_ context.Context) (WebhookMessage, error) {
+	now := time.Now()
+	for _, message := range m.WebhookOutbox {
+		if message.DeliveredAt != nil || message.FailedAt != nil || message.NextAttemptAt.After(now) {
+			continue
+		}
+		webhook := m.Webhooks[message.WebhookID-1]
+		if webhook.DeletedAt != nil {
+			continue
+		}
+		message.URL = webhook.URL
+		message.Secret = webhook.Secret
+		return message, nil
+	}
+	return WebhookMessage{}, errors.New("webhook message not found")
+}
+
+func (m *MemoryStorage) UpdateWebhookMessage(_ context.Context, message WebhookMessage) error {
+	for i, current := range m.WebhookOutbox {
+		if current.ID == message.ID {
+			m.WebhookOutbox[i].Attempts = message.Attempts
+			m.WebhookOutbox[i].NextAttemptAt = message.NextAttemptAt
+			m.WebhookOutbox[i].DeliveredAt = message.DeliveredAt
+			m.WebhookOutbox[i].FailedAt = message.FailedAt
+			return nil
+		}
+	}
+	return errors.New("webhook message not found")
+}
+
+func (m *MemoryStorage) StoreWebhookDelivery(_ context.Context, delivery WebhookDelivery) error {
+	delivery.ID = int64(len(m.Deliveries) + 1)
+	delivery.Event = m.WebhookOutbox[delivery.MessageID-1].Event
+	delivery.CreatedAt = time.Now()
+	m.Deliveries = append(m.Deliveries, delivery)
+	return nil
+}
@@ -2,43 +2,173 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"time"
 )
 
+// ErrUserNotFound возвращается при поиске пользователя, которого нет в хранилище.
+var ErrUserNotFound = errors.New("user not found")
+
 type Storage interface {
 	GetUser(ctx context.Context, login string) (user User, err error)
+	GetUserByReferralCode(ctx context.Context, code string) (user User, err error)
+	GetReferrals(ctx context.Context, referrer string) (referrals []Referral, err error)
+	GetUploadedOrdersCount(ctx context.Context, login string, since time.Time) (count int64, err error)
+	GetOrdersCountByStatus(ctx context.Context, login string) (counts map[string]int64, err error)
+	StoreFraudReview(ctx context.Context, review FraudReview) (id int64, err error)
+	GetFraudReviews(ctx context.Context, status string, limit int) (reviews []FraudReview, err error)
+	ResolveFraudReview(ctx context.Context, review FraudReview) (err error)
+	UseApprovedFraudReview(
+		ctx context.Context,
+		login string,
+		action string,
+		orderNumber string,
+		sum float32,
+	) (used bool, err error)
 	StoreUser(ctx context.Context, login string, passwordHash string) (err error)
 	GetOrder(ctx context.Context, number string) (order Order, err error)
 	GetOrders(ctx context.Context, login string, onlyWithdrawn bool) (orders []Order, err error)
+	GetOrdersPage(ctx context.Context, filter OrdersFilter) (orders []Order, err error)
+	GetOrderStatusHistory(ctx context.Context, number string) (history []OrderStatusHistory, err error)
 	GetOrdersCountToUpdate(ctx context.Context) (count int64, err error)
 	StoreOrder(ctx context.Context, number, login string) (err error)
+	SetUserRole(ctx context.Context, login string, role string) (err error)
+	StoreAdminAction(ctx context.Context, action AdminAction) (err error)
+	StoreAuditEvent(ctx context.Context, event AuditEvent) (err error)
+	GetAuditEvents(ctx context.Context, filter AuditFilter) (events []AuditEvent, err error)
+	GetAPIKeys(ctx context.Context, login string) (keys []APIKey, err error)
+	GetAPIKeyByHash(ctx context.Context, keyHash string) (key APIKey, err error)
+	TouchAPIKey(ctx context.Context, id int64) (err error)
+	StoreWebhook(ctx context.Context, webhook Webhook) (id int64, err error)
+	GetWebhooks(ctx context.Context, login string) (webhooks []Webhook, err error)
+	DeleteWebhook(ctx context.Context, id int64, login string) (err error)
+	GetWebhookDeliveries(ctx context.Context, webhookID int64, login string, limit int) (deliveries []WebhookDelivery, err error)
+	GetWebhookMessagesCountToDeliver(ctx context.Context) (count int64, err error)
+	GetFlaggedUsers(ctx context.Context) (users []User, err error)
+	GetAccrualTotal(ctx context.Context, login string, since time.Time) (total float32, err error)
+	GetAccrualTotals(ctx context.Context, since time.Time) (totals []AccrualTotal, err error)
+	SetUserTier(ctx context.Context, login string, tier string) (err error)
+	GetBalanceMovements(ctx context.Context, login string, limit int) (movements []BalanceMovement, err error)
+	GetHolds(ctx context.Context, login string) (holds []Hold, err error)
+	GetExpiredHolds(ctx context.Context, limit int) (holds []Hold, err error)
+	GetPendingBalance(ctx context.Context, login string) (pending []PendingBalance, err error)
+	GetExpiringPointLots(ctx context.Context, login string, limit int) (lots []PointLot, err error)
+	GetLoginsWithExpiredPoints(ctx context.Context, limit int) (logins []string, err error)
+	ClearUserFlag(ctx context.Context, login string) (err error)
+	StoreCampaign(ctx context.Context, campaign Campaign) (id int64, err error)
+	GetCampaigns(ctx context.Context) (campaigns []Campaign, err error)
+	GetCampaign(ctx context.Context, id int64) (campaign Campaign, err error)
+	UpdateCampaign(ctx context.Context, campaign Campaign) (err error)
+	DeleteCampaign(ctx context.Context, id int64) (err error)
 	BeginTransaction(ctx context.Context) (tx Transaction, err error)
+	Ping(ctx context.Context) (err error)
 	CloseConnection()
 }
 
 type Transaction interface {
 	GetOrderToUpdateStatus(ctx context.Context) (orderToUpdate OrderToUpdate, err error)
 	UpdateOrderStatus(ctx context.Context, order OrderStatus) (err error)
+	StorePushedOrderStatus(ctx context.Context, order OrderStatus) (err error)
+	StoreProvisionalOrderStatus(ctx context.Context, order OrderStatus) (err error)
 	AccrualUserBalance(ctx context.Context, accraul float32, login string) (err error)
 	GetUserWithLock(ctx context.Context, login string) (user User, err error)
 	GetOrderWithLock(ctx context.Context, number string, login string) (order Order, err error)
 	WithdrawUserBalance(ctx context.Context, login string, withdraw float32) (err error)
 	WithdrawOrderBalance(ctx context.Context, number string, withdraw float32) (err error)
+	GetOrderForUpdate(ctx context.Context, number string) (order Order, err error)
+	StoreBalanceMovement(ctx context.Context, movement BalanceMovement) (err error)
+	StoreOrderChange(ctx context.Context, change OrderChange) (err error)
+	StoreUser(ctx context.Context, login string, passwordHash string) (err error)
+	SetUserReferral(ctx context.Context, login string, referralCode string, registrationIP string) (err error)
+	StoreReferral(ctx context.Context, referral Referral) (err error)
+	GetReferralsCount(ctx context.Context, referrer string) (count int64, err error)
+	// GetPendingReferralForUpdate возвращает приглашение с нулевым ID, если ожидающего приглашения нет
+	GetPendingReferralForUpdate(ctx context.Context, referee string) (referral Referral, err error)
+	RewardReferral(ctx context.Context, referral Referral) (err error)
+	StoreAuditEvent(ctx context.Context, event AuditEvent) (err error)
+	InsertOrder(ctx context.Context, number, login string) (inserted bool, err error)
+	FlagUser(ctx context.Context, login string, reason string) (err error)
+	GetActiveCampaigns(ctx context.Context, at time.Time) (campaigns []Campaign, err error)
+	GetProcessedOrdersCount(ctx context.Context, login string) (count int64, err error)
+	GetMovementsSum(ctx context.Context, login string, kind string, since time.Time) (sum float32, err error)
+	GetMovementsCount(ctx context.Context, login string, kind string, since time.Time) (count int64, err error)
+	StoreHold(ctx context.Context, hold Hold) (id int64, err error)
+	GetHoldForUpdate(ctx context.Context, id int64) (hold Hold, err error)
+	CloseHold(ctx context.Context, hold Hold, status string) (err error)
+	StorePointLot(ctx context.Context, lot PointLot) (err error)
+	// ConsumePointLots возвращает израсходованные части партий: Amount - сколько списано с партии
+	ConsumePointLots(ctx context.Context, login string, amount float32) (consumed []PointLot, err error)
+	GetExpiredPoints(ctx context.Context, login string) (expired float32, err error)
+	// ExpirePointLots гасит сгоревшие партии, начиная с самых ранних, но не больше amount
+	ExpirePointLots(ctx context.Context, login string, amount float32) (err error)
+	StoreWebhookMessages(ctx context.Context, login string, event string, payload []byte) (err error)
+	GetWebhookMessageToDeliver(ctx context.Context) (message WebhookMessage, err error)
+	UpdateWebhookMessage(ctx context.Context, message WebhookMessage) (err error)
+	StoreWebhookDelivery(ctx context.Context, delivery WebhookDelivery) (err error)
+	StoreAPIKey(ctx context.Context, key APIKey) (id int64, err error)
+	RevokeAPIKey(ctx context.Context, id int64, login string) (err error)
 	Commit(ctx context.Context) (err error)
 	Rollback(ctx context.Context) (err error)
 }
 
+// PushedStatusTTL - время, в течение которого заказ с присланным промежуточным статусом
+// не опрашивается фоновой задачей.
+const PushedStatusTTL = 5 * time.Minute
+
+const (
+	RoleUser    = "user"
+	RoleSupport = "support"
+	RoleAdmin   = "admin"
+)
+
+const (
+	MovementAccrual        = "accrual"
+	MovementWithdrawal     = "withdrawal"
+	MovementAdjustment     = "adjustment"
+	MovementReaccrual      = "reaccrual"
+	MovementStatusOverride = "status_override"
+	MovementReversal       = "reversal"
+	MovementExpiry         = "expiry"
+	MovementTransferOut    = "transfer_out"
+	MovementTransferIn     = "transfer_in"
+	MovementTierBonus      = "tier_bonus"
+	MovementCampaignBonus  = "campaign_bonus"
+	MovementReferralBonus  = "referral_bonus"
+)
+
+const (
+	AuditUserRegistered     = "user.registered"
+	AuditUserLogin          = "user.login"
+	AuditUserLoginFailed    = "user.login_failed"
+	AuditUserRoleChanged    = "user.role_changed"
+	AuditUserReviewed       = "user.reviewed"
+	AuditBalanceWithdrawn   = "balance.withdrawn"
+	AuditBalanceAccrued     = "balance.accrued"
+	AuditBalanceAdjusted    = "balance.adjusted"
+	AuditBalanceReversed    = "balance.reversed"
+	AuditBalanceExpired     = "balance.expired"
+	AuditBalanceHeld        = "balance.held"
+	AuditHoldReleased       = "balance.hold_released"
+	AuditBalanceTransferred = "balance.transferred"
+	AuditReferralRewarded   = "referral.rewarded"
+	AuditOrderStatusChanged = "order.status_changed"
+	AuditAPIKeyCreated      = "api_key.created"
+	AuditAPIKeyRevoked      = "api_key.revoked"
+)
+
+const (
+	WebhookOrderFinalized = "order.finalized"
+)
+
 type Success struct {
 	Success bool `json:"success" binding:"required"`
 }
 
-type Error struct {
-	Error string `json:"error" binding:"required"`
-}
-
 type RegisterUser struct {
 	Login    string `json:"login"    binding:"required"`
 	Password string `json:"password" binding:"required"`
+	// ReferralCode - код пригласившего пользователя, учитывается только при регистрации
+	ReferralCode string `json:"referralCode"`
 }
 
 type User struct {
@@ -46,6 +176,65 @@ type User struct {
 	Password  string  `json:"password"  binding:"required"`
 	Balance   float32 `json:"balance"   binding:"required"`
 	Withdrawn float32 `json:"withdrawn" binding:"required"`
+	Role      string  `json:"role"      binding:"required"`
+	// Held - баллы, зарезервированные активными холдами. Доступно для списания Balance - Held
+	Held float32 `json:"held"`
+	// Tier - уровень лояльности, пустой означает начальный уровень
+	Tier string `json:"tier"`
+	// FlaggedAt заполняется, когда пользователь требует проверки оператором
+	FlaggedAt  *time.Time `json:"flaggedAt"`
+	FlagReason string     `json:"flagReason"`
+	// ReferralCode - код, по которому пользователь приглашает других пользователей
+	ReferralCode   string    `json:"referralCode"`
+	RegistrationIP string    `json:"registrationIp"`
+	CreatedAt      time.Time `json:"createdAt"`
+}
+
+const (
+	FraudReviewPending  = "pending"
+	FraudReviewApproved = "approved"
+	FraudReviewRejected = "rejected"
+)
+
+// FraudReview - действие пользователя, которое правила антифрода заблокировали или отправили
+// на проверку. Одобренное оператором заблокированное действие можно повторить.
+type FraudReview struct {
+	ID          int64      `json:"id"          binding:"required"`
+	Login       string     `json:"login"       binding:"required"`
+	Action      string     `json:"action"      binding:"required"`
+	OrderNumber string     `json:"orderNumber" binding:"required"`
+	Sum         float32    `json:"sum"`
+	Score       int64      `json:"score"       binding:"required"`
+	Decision    string     `json:"decision"    binding:"required"`
+	Reasons     []string   `json:"reasons"     binding:"required"`
+	Status      string     `json:"status"      binding:"required"`
+	Operator    string     `json:"operator"`
+	Comment     string     `json:"comment"`
+	CreatedAt   time.Time  `json:"createdAt"   binding:"required"`
+	ResolvedAt  *time.Time `json:"resolvedAt"`
+	// UsedAt - время повторного выполнения одобренного действия, одобрение действует один раз
+	UsedAt *time.Time `json:"usedAt"`
+}
+
+const (
+	ReferralPending  = "pending"
+	ReferralRewarded = "rewarded"
+	ReferralRejected = "rejected"
+)
+
+// Referral - приглашение пользователя по реферальному коду. Бонусы обоим участникам
+// зачисляются, когда первый заказ приглашенного получает статус PROCESSED.
+// Приглашение, не прошедшее проверки на мошенничество, сохраняется со статусом rejected.
+type Referral struct {
+	ID            int64      `json:"id"            binding:"required"`
+	Referrer      string     `json:"referrer"      binding:"required"`
+	Referee       string     `json:"referee"       binding:"required"`
+	Status        string     `json:"status"        binding:"required"`
+	RejectReason  string     `json:"rejectReason"`
+	ReferrerBonus float32    `json:"referrerBonus"`
+	RefereeBonus  float32    `json:"refereeBonus"`
+	CreatedAt     time.Time  `json:"createdAt"     binding:"required"`
+	RewardedAt    *time.Time `json:"rewardedAt"`
 }
 
 type Order struct {
@@ -56,15 +245,223 @@ type Order struct {
 	Login       string     `json:"login"       binding:"required"`
 	UploadedAt  time.Time  `json:"uploadedAt"  binding:"required"`
 	ProcessedAt *time.Time `json:"processedAt" binding:"required"`
+	// AccrualProvider - система расчета начислений, по ответу которой был обновлен статус заказа
+	AccrualProvider *string `json:"accrualProvider"`
+	// TierBonus - баллы, зачисленные сверх начисления по множителю уровня лояльности
+	TierBonus float32 `json:"tierBonus"`
+	// CampaignBonus - баллы, зачисленные по заказу сверх начисления по промо-акциям
+	CampaignBonus float32 `json:"campaignBonus"`
+}
+
+type OrderStatusHistory struct {
+	Status    string    `json:"status"    binding:"required"`
+	Accrual   float32   `json:"accrual"   binding:"required"`
+	CreatedAt time.Time `json:"createdAt" binding:"required"`
+}
+
+// OrdersCursor указывает на последний заказ предыдущей страницы.
+type OrdersCursor struct {
+	UploadedAt time.Time `json:"u"`
+	Number     string    `json:"n"`
+}
+
+type OrdersFilter struct {
+	Login      string
+	Statuses   []string
+	From       *time.Time
+	To         *time.Time
+	Cursor     *OrdersCursor
+	Descending bool
+	Limit      int
+}
+
+// PendingBalance - заказы пользователя в одном из промежуточных статусов и сумма
+// предварительного начисления, о которой сообщила система расчета.
+type PendingBalance struct {
+	Status  string  `json:"status"  binding:"required"`
+	Count   int64   `json:"count"   binding:"required"`
+	Accrual float32 `json:"accrual" binding:"required"`
 }
 
 type OrderToUpdate struct {
 	Number string `json:"number" binding:"required"`
 	Login  string `json:"login"  binding:"required"`
+	Status string `json:"status" binding:"required"`
 }
 
 type OrderStatus struct {
-	Number  string  `json:"order"   binding:"required"`
-	Status  string  `json:"status"  binding:"required"`
-	Accrual float32 `json:"accrual" binding:"required"`
+	Number   string  `json:"order"   binding:"required"`
+	Status   string  `json:"status"  binding:"required"`
+	Accrual  float32 `json:"accrual" binding:"required"`
+	Provider string  `json:"-"`
+	// TierBonus сохраняется вместе с итоговым статусом, чтобы его можно было списать при отмене
+	TierBonus     float32 `json:"-"`
+	CampaignBonus float32 `json:"-"`
+}
+
+// AccrualTotal - сумма начислений пользователя за период, по которой определяется уровень лояльности.
+type AccrualTotal struct {
+	Login string  `json:"login" binding:"required"`
+	Tier  string  `json:"tier"  binding:"required"`
+	Total float32 `json:"total" binding:"required"`
+}
+
+type AdminAction struct {
+	Operator   string    `json:"operator"   binding:"required"`
+	Method     string    `json:"method"     binding:"required"`
+	Path       string    `json:"path"       binding:"required"`
+	Target     string    `json:"target"     binding:"required"`
+	StatusCode int       `json:"statusCode" binding:"required"`
+	CreatedAt  time.Time `json:"createdAt"  binding:"required"`
+}
+
+type BalanceMovement struct {
+	Login       string  `json:"login"       binding:"required"`
+	Kind        string  `json:"kind"        binding:"required"`
+	Amount      float32 `json:"amount"      binding:"required"`
+	OrderNumber string  `json:"orderNumber"`
+	Reason      string  `json:"reason"`
+	Operator    string  `json:"operator"`
+	// Counterparty - второй участник перевода баллов
+	Counterparty string `json:"counterparty"`
+	// CampaignID - промо-акция, по которой зачислен бонус
+	CampaignID *int64    `json:"campaignId"`
+	CreatedAt  time.Time `json:"createdAt"   binding:"required"`
+}
+
+const (
+	CampaignBonusMultiplier = "multiplier"
+	CampaignBonusFixed      = "fixed"
+)
+
+// Campaign - промо-акция, начисляющая бонусные баллы по заказам, которые получили итоговый
+// статус в период с StartsAt по EndsAt и подходят под условия акции.
+type Campaign struct {
+	ID       int64     `json:"id"         binding:"required"`
+	Name     string    `json:"name"       binding:"required"`
+	StartsAt time.Time `json:"startsAt"   binding:"required"`
+	EndsAt   time.Time `json:"endsAt"     binding:"required"`
+	// FirstOrderOnly ограничивает акцию первым начисленным заказом пользователя
+	FirstOrderOnly bool    `json:"firstOrderOnly"`
+	MinAccrual     float32 `json:"minAccrual"`
+	// Tiers - уровни лояльности, на которые распространяется акция, пустой - на все
+	Tiers []string `json:"tiers"`
+	// BonusType - multiplier (бонус = начисление * (BonusValue - 1)) или fixed (бонус = BonusValue)
+	BonusType  string     `json:"bonusType"  binding:"required"`
+	BonusValue float32    `json:"bonusValue" binding:"required"`
+	CreatedAt  time.Time  `json:"createdAt"  binding:"required"`
+	DeletedAt  *time.Time `json:"deletedAt"`
+}
+
+const (
+	HoldActive   = "active"
+	HoldCaptured = "captured"
+	HoldVoided   = "voided"
+	HoldExpired  = "expired"
+)
+
+// Hold - резерв баллов под заказ, который затем списывается (capture) или снимается (void).
+// Активный холд уменьшает доступный для списания баланс до ExpiresAt.
+type Hold struct {
+	ID          int64      `json:"id"          binding:"required"`
+	Login       string     `json:"login"       binding:"required"`
+	OrderNumber string     `json:"orderNumber" binding:"required"`
+	Amount      float32    `json:"amount"      binding:"required"`
+	Status      string     `json:"status"      binding:"required"`
+	ExpiresAt   time.Time  `json:"expiresAt"   binding:"required"`
+	ClosedAt    *time.Time `json:"closedAt"`
+	CreatedAt   time.Time  `json:"createdAt"   binding:"required"`
+}
+
+// PointLot - партия начисленных баллов. Списания расходуют самые старые партии первыми,
+// остаток партии сгорает в ExpiresAt. Партия без ExpiresAt не сгорает.
+type PointLot struct {
+	ID          int64      `json:"id"          binding:"required"`
+	Login       string     `json:"login"       binding:"required"`
+	OrderNumber string     `json:"orderNumber"`
+	Amount      float32    `json:"amount"      binding:"required"`
+	Remaining   float32    `json:"remaining"   binding:"required"`
+	ExpiresAt   *time.Time `json:"expiresAt"`
+	CreatedAt   time.Time  `json:"createdAt"   binding:"required"`
+}
+
+type OrderChange struct {
+	OrderNumber string    `json:"orderNumber" binding:"required"`
+	Operator    string    `json:"operator"    binding:"required"`
+	OldStatus   string    `json:"oldStatus"   binding:"required"`
+	NewStatus   string    `json:"newStatus"   binding:"required"`
+	OldAccrual  float32   `json:"oldAccrual"  binding:"required"`
+	NewAccrual  float32   `json:"newAccrual"  binding:"required"`
+	Reason      string    `json:"reason"      binding:"required"`
+	CreatedAt   time.Time `json:"createdAt"   binding:"required"`
+}
+
+type AuditEvent struct {
+	ID           int64     `json:"id"           binding:"required"`
+	Actor        string    `json:"actor"        binding:"required"`
+	Action       string    `json:"action"       binding:"required"`
+	Target       string    `json:"target"       binding:"required"`
+	AmountBefore *float32  `json:"amountBefore"`
+	AmountAfter  *float32  `json:"amountAfter"`
+	RequestID    string    `json:"requestId"    binding:"required"`
+	CreatedAt    time.Time `json:"createdAt"    binding:"required"`
+}
+
+type AuditFilter struct {
+	Actor  string
+	Action string
+	Target string
+	From   *time.Time
+	To     *time.Time
+	Limit  int
+	Offset int
+}
+
+type APIKey struct {
+	ID         int64      `json:"id"         binding:"required"`
+	Login      string     `json:"login"      binding:"required"`
+	Name       string     `json:"name"       binding:"required"`
+	Prefix     string     `json:"prefix"     binding:"required"`
+	Hash       string     `json:"hash"       binding:"required"`
+	Scopes     []string   `json:"scopes"     binding:"required"`
+	RateLimit  int        `json:"rateLimit"  binding:"required"`
+	CreatedAt  time.Time  `json:"createdAt"  binding:"required"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+	RevokedAt  *time.Time `json:"revokedAt"`
+}
+
+type Webhook struct {
+	ID        int64      `json:"id"        binding:"required"`
+	Login     string     `json:"login"     binding:"required"`
+	URL       string     `json:"url"       binding:"required"`
+	Secret    string     `json:"secret"    binding:"required"`
+	CreatedAt time.Time  `json:"createdAt" binding:"required"`
+	DeletedAt *time.Time `json:"deletedAt"`
+}
+
+// WebhookMessage - сообщение из outbox вместе с адресом и секретом вебхука для подписи.
+type WebhookMessage struct {
+	ID            int64      `json:"id"            binding:"required"`
+	WebhookID     int64      `json:"webhookId"     binding:"required"`
+	Event         string     `json:"event"         binding:"required"`
+	Payload       []byte     `json:"payload"       binding:"required"`
+	Attempts      int        `json:"attempts"      binding:"required"`
+	NextAttemptAt time.Time  `json:"nextAttemptAt" binding:"required"`
+	DeliveredAt   *time.Time `json:"deliveredAt"`
+	FailedAt      *time.Time `json:"failedAt"`
+	CreatedAt     time.Time  `json:"createdAt"     binding:"required"`
+	URL           string     `json:"url"           binding:"required"`
+	Secret        string     `json:"secret"        binding:"required"`
+}
+
+type WebhookDelivery struct {
+	ID         int64     `json:"id"         binding:"required"`
+	MessageID  int64     `json:"messageId"  binding:"required"`
+	WebhookID  int64     `json:"webhookId"  binding:"required"`
+	Event      string    `json:"event"      binding:"required"`
+	Attempt    int       `json:"attempt"    binding:"required"`
+	StatusCode int       `json:"statusCode" binding:"required"`
+	Error      string    `json:"error"      binding:"required"`
+	DurationMs int64     `json:"durationMs" binding:"required"`
+	CreatedAt  time.Time `json:"createdAt"  binding:"required"`
 }
@@ -0,0 +1,320 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/pisarevaa/gophermart/internal/configs"
+	"github.com/pisarevaa/gophermart/internal/events"
+	"github.com/pisarevaa/gophermart/internal/loyalty"
+	"github.com/pisarevaa/gophermart/internal/storage"
+)
+
+var (
+	ErrUnknownStatus = errors.New("unknown order status")
+	ErrNotReversible = errors.New("only processed order's accrual can be decreased by reversal")
+)
+
+var accrualStatuses = []string{"REGISTERED", "INVALID", "PROCESSING", "PROCESSED"}
+
+// IsFinalStatus сообщает, что система расчета начислений больше не изменит статус заказа.
+func IsFinalStatus(status string) bool {
+	return status == "PROCESSED" || status == "INVALID"
+}
+
+// ValidateOrderStatus проверяет ответ системы расчета начислений.
+func ValidateOrderStatus(status storage.OrderStatus) error {
+	if !slices.Contains(accrualStatuses, status.Status) {
+		return ErrUnknownStatus
+	}
+	if status.Accrual < 0 {
+		return errors.New("accrual can't be negative")
+	}
+	return nil
+}
+
+// PointsExpiresAt возвращает срок сгорания баллов, начисленных в момент from.
+// При months <= 0 баллы не сгорают.
+func PointsExpiresAt(months int64, from time.Time) *time.Time {
+	if months <= 0 {
+		return nil
+	}
+	expiresAt := from.AddDate(0, int(months), 0)
+	return &expiresAt
+}
+
+// AccrualPolicy - правила зачисления баллов по итоговому статусу заказа.
+type AccrualPolicy struct {
+	// Now - момент начисления, по которому выбираются действующие промо-акции
+	Now time.Time
+	// ExpiresAt - срок сгорания начисленных баллов, nil - баллы не сгорают
+	ExpiresAt *time.Time
+	// Tiers определяют множитель начисления по уровню лояльности пользователя
+	Tiers loyalty.Tiers
+	// ReferrerBonus и RefereeBonus зачисляются участникам приглашения по первому начисленному заказу
+	ReferrerBonus float32
+	RefereeBonus  float32
+}
+
+func NewAccrualPolicy(config configs.Config, now time.Time) AccrualPolicy {
+	return AccrualPolicy{
+		Now:           now,
+		ExpiresAt:     PointsExpiresAt(config.PointsExpiryMonths, now),
+		Tiers:         loyalty.NewTiers(config.LoyaltyTiers),
+		ReferrerBonus: float32(config.ReferrerBonus),
+		RefereeBonus:  float32(config.RefereeBonus),
+	}
+}
+
+// ApplyOrderStatus фиксирует итоговый статус заказа, начисляет баллы пользователю
+// с учетом множителя его уровня лояльности и действующих промо-акций и записывает движения
+// по балансу, партию баллов, событие аудита и сообщения вебхуков. По первому начисленному
+// заказу приглашенного пользователя зачисляются реферальные бонусы.
+// Заказ должен быть заблокирован в транзакции tx, пользователь блокируется здесь.
+// Возвращает баланс пользователя после начисления.
+func ApplyOrderStatus(
+	ctx context.Context,
+	tx storage.Transaction,
+	login string,
+	status storage.OrderStatus,
+	policy AccrualPolicy,
+	requestID string,
+) (float32, error) {
+	user, err := tx.GetUserWithLock(ctx, login)
+	if err != nil {
+		return 0, err
+	}
+	status.TierBonus = policy.Tiers.Bonus(user.Tier, creditedAccrual(status))
+	// Промо-акции оцениваются до обновления заказа, чтобы он не считался уже начисленным
+	campaignBonuses, err := orderCampaignBonuses(ctx, tx, user, status, policy)
+	if err != nil {
+		return 0, err
+	}
+	status.CampaignBonus = 0
+	for _, bonus := range campaignBonuses {
+		status.CampaignBonus += bonus.Amount
+	}
+	err = tx.UpdateOrderStatus(ctx, status)
+	if err != nil {
+		return 0, err
+	}
+	credited := status.Accrual + status.TierBonus + status.CampaignBonus
+	err = tx.AccrualUserBalance(ctx, credited, login)
+	if err != nil {
+		return 0, err
+	}
+	err = tx.StoreBalanceMovement(ctx, storage.BalanceMovement{
+		Login:       login,
+		Kind:        storage.MovementAccrual,
+		Amount:      status.Accrual,
+		OrderNumber: status.Number,
+	})
+	if err != nil {
+		return 0, err
+	}
+	if status.TierBonus > 0 {
+		err = tx.StoreBalanceMovement(ctx, storage.BalanceMovement{
+			Login:       login,
+			Kind:        storage.MovementTierBonus,
+			Amount:      status.TierBonus,
+			OrderNumber: status.Number,
+			Reason:      "tier " + policy.Tiers.ByName(user.Tier).Name,
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+	for _, bonus := range campaignBonuses {
+		err = tx.StoreBalanceMovement(ctx, storage.BalanceMovement{
+			Login:       login,
+			Kind:        storage.MovementCampaignBonus,
+			Amount:      bonus.Amount,
+			OrderNumber: status.Number,
+			Reason:      "campaign " + bonus.Campaign.Name,
+			CampaignID:  &bonus.Campaign.ID,
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+	if credited > 0 {
+		err = tx.StorePointLot(ctx, storage.PointLot{
+			Login:       login,
+			OrderNumber: status.Number,
+			Amount:      credited,
+			ExpiresAt:   policy.ExpiresAt,
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+	balanceAfter := user.Balance + credited
+	err = tx.StoreAuditEvent(ctx, storage.AuditEvent{
+		Actor:        "system",
+		Action:       storage.AuditBalanceAccrued,
+		Target:       status.Number,
+		AmountBefore: &user.Balance,
+		AmountAfter:  &balanceAfter,
+		RequestID:    requestID,
+	})
+	if err != nil {
+		return 0, err
+	}
+	err = storeOrderFinalized(ctx, tx, login, status)
+	if err != nil {
+		return 0, err
+	}
+	user.Balance = balanceAfter
+	refereeBonus, err := rewardReferral(ctx, tx, user, status, policy, requestID)
+	if err != nil {
+		return 0, err
+	}
+	return balanceAfter + refereeBonus, nil
+}
+
+// IsReversal сообщает, что новый результат расчета уменьшает ранее зачисленные по заказу баллы.
+func IsReversal(order storage.Order, status storage.OrderStatus) bool {
+	return order.Status == "PROCESSED" && creditedAccrual(status) < order.Accrual
+}
+
+// ReverseOrderAccrual списывает с баланса пользователя разницу между ранее зачисленными
+// по заказу баллами и новым результатом расчета (INVALID или меньшая сумма).
+// Баланс может стать отрицательным, в этом случае пользователь помечается для проверки.
+// Заказ должен быть заблокирован в транзакции tx, пользователь блокируется здесь.
+// Возвращает баланс пользователя после списания.
+func ReverseOrderAccrual(
+	ctx context.Context,
+	tx storage.Transaction,
+	order storage.Order,
+	status storage.OrderStatus,
+	operator string,
+	reason string,
+	requestID string,
+) (float32, error) {
+	if !IsReversal(order, status) {
+		return 0, ErrNotReversible
+	}
+	// Бонусы уровня лояльности и промо-акций уменьшаются пропорционально начислению
+	status.TierBonus = proportionalBonus(order.TierBonus, creditedAccrual(status), order.Accrual)
+	status.CampaignBonus = proportionalBonus(order.CampaignBonus, creditedAccrual(status), order.Accrual)
+	err := tx.UpdateOrderStatus(ctx, status)
+	if err != nil {
+		return 0, err
+	}
+	user, err := tx.GetUserWithLock(ctx, order.Login)
+	if err != nil {
+		return 0, err
+	}
+	accrualDelta := creditedAccrual(status) - order.Accrual
+	bonusDelta := status.TierBonus - order.TierBonus
+	campaignDelta := status.CampaignBonus - order.CampaignBonus
+	delta := accrualDelta + bonusDelta + campaignDelta
+	err = tx.AccrualUserBalance(ctx, delta, order.Login)
+	if err != nil {
+		return 0, err
+	}
+	_, err = tx.ConsumePointLots(ctx, order.Login, -delta)
+	if err != nil {
+		return 0, err
+	}
+	err = tx.StoreBalanceMovement(ctx, storage.BalanceMovement{
+		Login:       order.Login,
+		Kind:        storage.MovementReversal,
+		Amount:      accrualDelta,
+		OrderNumber: order.Number,
+		Reason:      reason,
+		Operator:    operator,
+	})
+	if err != nil {
+		return 0, err
+	}
+	if bonusDelta != 0 {
+		err = tx.StoreBalanceMovement(ctx, storage.BalanceMovement{
+			Login:       order.Login,
+			Kind:        storage.MovementTierBonus,
+			Amount:      bonusDelta,
+			OrderNumber: order.Number,
+			Reason:      reason,
+			Operator:    operator,
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+	if campaignDelta != 0 {
+		err = tx.StoreBalanceMovement(ctx, storage.BalanceMovement{
+			Login:       order.Login,
+			Kind:        storage.MovementCampaignBonus,
+			Amount:      campaignDelta,
+			OrderNumber: order.Number,
+			Reason:      reason,
+			Operator:    operator,
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+	err = tx.StoreOrderChange(ctx, storage.OrderChange{
+		OrderNumber: order.Number,
+		Operator:    operator,
+		OldStatus:   order.Status,
+		NewStatus:   status.Status,
+		OldAccrual:  order.Accrual,
+		NewAccrual:  status.Accrual,
+		Reason:      reason,
+	})
+	if err != nil {
+		return 0, err
+	}
+	balanceAfter := user.Balance + delta
+	err = tx.StoreAuditEvent(ctx, storage.AuditEvent{
+		Actor:        operator,
+		Action:       storage.AuditBalanceReversed,
+		Target:       order.Number,
+		AmountBefore: &user.Balance,
+		AmountAfter:  &balanceAfter,
+		RequestID:    requestID,
+	})
+	if err != nil {
+		return 0, err
+	}
+	if balanceAfter < 0 {
+		err = tx.FlagUser(ctx, order.Login, fmt.Sprintf("negative balance after reversal of order %s", order.Number))
+		if err != nil {
+			return 0, err
+		}
+	}
+	return balanceAfter, nil
+}
+
+// proportionalBonus уменьшает бонус по заказу пропорционально новому начислению, с точностью до копеек.
+func proportionalBonus(bonus float32, accrual float32, previousAccrual float32) float32 {
+	if bonus == 0 || previousAccrual == 0 {
+		return 0
+	}
+	return float32(math.Round(float64(bonus*accrual/previousAccrual)*100) / 100)
+}
+
+// creditedAccrual возвращает сумму, зачисленную на баланс по заказу в данном статусе.
+func creditedAccrual(status storage.OrderStatus) float32 {
+	if status.Status == "PROCESSED" {
+		return status.Accrual
+	}
+	return 0
+}
+
+// PublishOrderStatus отправляет подписчикам пользователя события после фиксации транзакции.
+func PublishOrderStatus(broker *events.Broker, login string, status storage.OrderStatus, balanceAfter float32) {
+	broker.Publish(login, events.OrderStatusChanged, events.OrderEvent{
+		Number:  status.Number,
+		Status:  status.Status,
+		Accrual: status.Accrual,
+	})
+	broker.Publish(login, events.BalanceChanged, events.BalanceEvent{
+		Current: balanceAfter,
+	})
+}
@@ -0,0 +1,103 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"math"
+	"slices"
+
+	"github.com/pisarevaa/gophermart/internal/storage"
+)
+
+var ErrUnknownBonusType = errors.New("unknown campaign bonus type")
+
+// CampaignBonus - бонус по одной промо-акции, зачисляемый отдельным движением по балансу.
+type CampaignBonus struct {
+	Campaign storage.Campaign
+	Amount   float32
+}
+
+// ValidateCampaign проверяет период и формулу бонуса промо-акции.
+func ValidateCampaign(campaign storage.Campaign) error {
+	if !campaign.EndsAt.After(campaign.StartsAt) {
+		return errors.New("campaign must end after it starts")
+	}
+	if campaign.MinAccrual < 0 {
+		return errors.New("min accrual can't be negative")
+	}
+	switch campaign.BonusType {
+	case storage.CampaignBonusMultiplier:
+		if campaign.BonusValue <= 1 {
+			return errors.New("multiplier must be greater than 1")
+		}
+	case storage.CampaignBonusFixed:
+		if campaign.BonusValue <= 0 {
+			return errors.New("fixed bonus must be positive")
+		}
+	default:
+		return ErrUnknownBonusType
+	}
+	return nil
+}
+
+// EvaluateCampaigns возвращает бонусы по промо-акциям, условиям которых соответствует
+// начисление accrual пользователю с уровнем лояльности tier.
+// firstOrder сообщает, что заказ - первый начисленный заказ пользователя.
+func EvaluateCampaigns(campaigns []storage.Campaign, tier string, accrual float32, firstOrder bool) []CampaignBonus {
+	var bonuses []CampaignBonus
+	for _, campaign := range campaigns {
+		if campaign.FirstOrderOnly && !firstOrder {
+			continue
+		}
+		if accrual < campaign.MinAccrual {
+			continue
+		}
+		if len(campaign.Tiers) > 0 && !slices.Contains(campaign.Tiers, tier) {
+			continue
+		}
+		amount := campaignBonusAmount(campaign, accrual)
+		if amount <= 0 {
+			continue
+		}
+		bonuses = append(bonuses, CampaignBonus{Campaign: campaign, Amount: amount})
+	}
+	return bonuses
+}
+
+func campaignBonusAmount(campaign storage.Campaign, accrual float32) float32 {
+	switch campaign.BonusType {
+	case storage.CampaignBonusMultiplier:
+		return float32(math.Round(float64(accrual*(campaign.BonusValue-1))*100) / 100)
+	case storage.CampaignBonusFixed:
+		return campaign.BonusValue
+	}
+	return 0
+}
+
+// orderCampaignBonuses находит промо-акции, действующие в момент policy.Now, и рассчитывает
+// бонусы по итоговому статусу заказа. Бонусы начисляются только по заказам в статусе PROCESSED.
+func orderCampaignBonuses(
+	ctx context.Context,
+	tx storage.Transaction,
+	user storage.User,
+	status storage.OrderStatus,
+	policy AccrualPolicy,
+) ([]CampaignBonus, error) {
+	if status.Status != "PROCESSED" {
+		return nil, nil
+	}
+	campaigns, err := tx.GetActiveCampaigns(ctx, policy.Now)
+	if err != nil || len(campaigns) == 0 {
+		return nil, err
+	}
+	firstOrder := false
+	if slices.ContainsFunc(campaigns, func(c storage.Campaign) bool { return c.FirstOrderOnly }) {
+		processed, err := tx.GetProcessedOrdersCount(ctx, user.Login)
+		if err != nil {
+			return nil, err
+		}
+		firstOrder = processed == 0
+	}
+	tier := policy.Tiers.ByName(user.Tier).Name
+	return EvaluateCampaigns(campaigns, tier, status.Accrual, firstOrder), nil
+}
@@ -0,0 +1,112 @@
+package tasks
+
+import (
+	"context"
+	"time"
+
+	"github.com/pisarevaa/gophermart/internal/events"
+	"github.com/pisarevaa/gophermart/internal/storage"
+	"github.com/pisarevaa/gophermart/internal/utils"
+)
+
+const expiryBatchSize = 100
+
+func (s *Task) RunExpirePoints(ctx context.Context) {
+	ticker := time.NewTicker(time.Duration(s.Config.TaskInterval) * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			err := s.ExpirePoints(ctx)
+			if err != nil {
+				s.Logger.Error("error to expire points:", err)
+			}
+		case <-ctx.Done():
+			s.Logger.Info("ctx.Done -> exit RunExpirePoints")
+			return
+		}
+	}
+}
+
+// ExpirePoints списывает сгоревшие партии баллов. Каждый пользователь обрабатывается
+// в отдельной транзакции, чтобы не держать блокировки многих пользователей одновременно.
+func (s *Task) ExpirePoints(ctx context.Context) error {
+	logins, err := s.Repo.GetLoginsWithExpiredPoints(ctx, expiryBatchSize)
+	if err != nil {
+		return err
+	}
+	for _, login := range logins {
+		err = s.expireUserPoints(ctx, login)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Task) expireUserPoints(ctx context.Context, login string) error {
+	tx, err := s.Repo.BeginTransaction(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck // ignore check
+
+	// Пользователь блокируется раньше партий, в том же порядке, что и при списании баллов
+	user, err := tx.GetUserWithLock(ctx, login)
+	if err != nil {
+		return err
+	}
+	expired, err := tx.GetExpiredPoints(ctx, login)
+	if err != nil {
+		return err
+	}
+	// Баланс мог уменьшиться без расхода партий (например, ручной корректировкой),
+	// поэтому сгорает не больше текущего баланса. Баллы, зарезервированные холдами, не сгорают,
+	// иначе подтверждение холда увело бы баланс в минус
+	amount := min(expired, max(user.Balance-user.Held, 0))
+	// Зарезервированная часть остается в партиях и сгорит после отмены холда.
+	// Партии сверх баланса гасятся сразу, им больше нечего списывать
+	held := min(expired, max(user.Balance, 0)) - amount
+	err = tx.ExpirePointLots(ctx, login, expired-held)
+	if err != nil {
+		return err
+	}
+	if amount > 0 {
+		err = tx.AccrualUserBalance(ctx, -amount, login)
+		if err != nil {
+			return err
+		}
+		err = tx.StoreBalanceMovement(ctx, storage.BalanceMovement{
+			Login:  login,
+			Kind:   storage.MovementExpiry,
+			Amount: -amount,
+			Reason: "points expired",
+		})
+		if err != nil {
+			return err
+		}
+		balanceAfter := user.Balance - amount
+		err = tx.StoreAuditEvent(ctx, storage.AuditEvent{
+			Actor:        "system",
+			Action:       storage.AuditBalanceExpired,
+			Target:       login,
+			AmountBefore: &user.Balance,
+			AmountAfter:  &balanceAfter,
+			RequestID:    utils.NewRequestID(),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return err
+	}
+	if amount > 0 {
+		s.Broker.Publish(login, events.BalanceChanged, events.BalanceEvent{
+			Current: user.Balance - amount,
+		})
+		s.Logger.Info("expired ", amount, " points of user ", login)
+	}
+	return nil
+}
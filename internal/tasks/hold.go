@@ -0,0 +1,88 @@
+package tasks
+
+import (
+	"context"
+	"time"
+
+	"github.com/pisarevaa/gophermart/internal/storage"
+	"github.com/pisarevaa/gophermart/internal/utils"
+)
+
+const holdBatchSize = 100
+
+func (s *Task) RunReleaseHolds(ctx context.Context) {
+	ticker := time.NewTicker(time.Duration(s.Config.TaskInterval) * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			err := s.ReleaseExpiredHolds(ctx)
+			if err != nil {
+				s.Logger.Error("error to release expired holds:", err)
+			}
+		case <-ctx.Done():
+			s.Logger.Info("ctx.Done -> exit RunReleaseHolds")
+			return
+		}
+	}
+}
+
+// ReleaseExpiredHolds снимает холды, которые не были списаны или отменены до истечения срока.
+func (s *Task) ReleaseExpiredHolds(ctx context.Context) error {
+	holds, err := s.Repo.GetExpiredHolds(ctx, holdBatchSize)
+	if err != nil {
+		return err
+	}
+	for _, hold := range holds {
+		err = s.releaseHold(ctx, hold)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Task) releaseHold(ctx context.Context, expired storage.Hold) error {
+	tx, err := s.Repo.BeginTransaction(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck // ignore check
+
+	// Пользователь блокируется раньше холда, в том же порядке, что и в обработчиках
+	user, err := tx.GetUserWithLock(ctx, expired.Login)
+	if err != nil {
+		return err
+	}
+	hold, err := tx.GetHoldForUpdate(ctx, expired.ID)
+	if err != nil {
+		return err
+	}
+	// Холд мог быть списан или отменен после выборки
+	if hold.Status != storage.HoldActive {
+		return nil
+	}
+	err = tx.CloseHold(ctx, hold, storage.HoldExpired)
+	if err != nil {
+		return err
+	}
+	available := user.Balance - user.Held
+	availableAfter := available + hold.Amount
+	err = tx.StoreAuditEvent(ctx, storage.AuditEvent{
+		Actor:        "system",
+		Action:       storage.AuditHoldReleased,
+		Target:       hold.OrderNumber,
+		AmountBefore: &available,
+		AmountAfter:  &availableAfter,
+		RequestID:    utils.NewRequestID(),
+	})
+	if err != nil {
+		return err
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return err
+	}
+	s.Logger.Info("hold ", hold.ID, " is expired")
+	return nil
+}
@@ -0,0 +1,98 @@
+package tasks
+
+import (
+	"context"
+
+	"github.com/pisarevaa/gophermart/internal/storage"
+)
+
+// rewardReferral зачисляет бонусы пригласившему и приглашенному пользователям, если заказ
+// приглашенного получил статус PROCESSED и его приглашение еще ожидает вознаграждения.
+// Приглашенный пользователь должен быть заблокирован в транзакции tx, пригласивший блокируется здесь.
+// Возвращает бонус, зачисленный приглашенному пользователю.
+func rewardReferral(
+	ctx context.Context,
+	tx storage.Transaction,
+	referee storage.User,
+	status storage.OrderStatus,
+	policy AccrualPolicy,
+	requestID string,
+) (float32, error) {
+	if status.Status != "PROCESSED" {
+		return 0, nil
+	}
+	referral, err := tx.GetPendingReferralForUpdate(ctx, referee.Login)
+	if err != nil || referral.ID == 0 {
+		return 0, err
+	}
+	referral.ReferrerBonus = policy.ReferrerBonus
+	referral.RefereeBonus = policy.RefereeBonus
+	err = tx.RewardReferral(ctx, referral)
+	if err != nil {
+		return 0, err
+	}
+	err = creditReferralBonus(ctx, tx, referee, referral.Referrer, referral.RefereeBonus, status.Number, policy, requestID)
+	if err != nil {
+		return 0, err
+	}
+	if referral.ReferrerBonus > 0 {
+		referrer, err := tx.GetUserWithLock(ctx, referral.Referrer)
+		if err != nil {
+			return 0, err
+		}
+		err = creditReferralBonus(ctx, tx, referrer, referee.Login, referral.ReferrerBonus, status.Number, policy, requestID)
+		if err != nil {
+			return 0, err
+		}
+	}
+	return referral.RefereeBonus, nil
+}
+
+// creditReferralBonus зачисляет реферальный бонус на баланс пользователя отдельным движением
+// с партией баллов и событием аудита. Баланс user должен быть актуален на момент зачисления.
+func creditReferralBonus(
+	ctx context.Context,
+	tx storage.Transaction,
+	user storage.User,
+	counterparty string,
+	amount float32,
+	orderNumber string,
+	policy AccrualPolicy,
+	requestID string,
+) error {
+	if amount <= 0 {
+		return nil
+	}
+	err := tx.AccrualUserBalance(ctx, amount, user.Login)
+	if err != nil {
+		return err
+	}
+	err = tx.StoreBalanceMovement(ctx, storage.BalanceMovement{
+		Login:        user.Login,
+		Kind:         storage.MovementReferralBonus,
+		Amount:       amount,
+		OrderNumber:  orderNumber,
+		Counterparty: counterparty,
+	})
+	if err != nil {
+		return err
+	}
+	err = tx.StorePointLot(ctx, storage.PointLot{
+		Login:       user.Login,
+		OrderNumber: orderNumber,
+		Amount:      amount,
+		ExpiresAt:   policy.ExpiresAt,
+	})
+	if err != nil {
+		return err
+	}
+	balanceAfter := user.Balance + amount
+	return tx.StoreAuditEvent(ctx, storage.AuditEvent{
+		Actor:        "system",
+		Action:       storage.AuditReferralRewarded,
+		Target:       user.Login,
+		AmountBefore: &user.Balance,
+		AmountAfter:  &balanceAfter,
+		RequestID:    requestID,
+	})
+}
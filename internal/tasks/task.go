@@ -2,34 +2,57 @@ package tasks
 
 import (
 	"context"
-	"fmt"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
 	"time"
 
 	"github.com/go-resty/resty/v2"
 
+	"github.com/pisarevaa/gophermart/internal/accrual"
 	"github.com/pisarevaa/gophermart/internal/configs"
+	"github.com/pisarevaa/gophermart/internal/events"
 	"github.com/pisarevaa/gophermart/internal/storage"
+	"github.com/pisarevaa/gophermart/internal/utils"
 	"go.uber.org/zap"
 )
 
 type Task struct {
-	Config configs.Config
-	Logger *zap.SugaredLogger
-	Repo   storage.Storage
-	Client *resty.Client
+	Config  configs.Config
+	Logger  *zap.SugaredLogger
+	Repo    storage.Storage
+	Accrual *accrual.Router
+	Broker  *events.Broker
+
+	webhookClient *resty.Client
+	// retryAt - время, до которого система расчета просила не отправлять запросы (429)
+	retryMu sync.Mutex
+	retryAt map[string]time.Time
 }
 
 func NewTask(
 	config configs.Config,
 	logger *zap.SugaredLogger,
 	repo storage.Storage,
-	client *resty.Client,
+	accrualRouter *accrual.Router,
+	broker *events.Broker,
 ) *Task {
+	webhookClient := resty.New().SetTimeout(webhookTimeout)
+	if !config.WebhookAllowPrivate {
+		webhookClient.SetTransport(&http.Transport{
+			DialContext: (&net.Dialer{Timeout: webhookTimeout, Control: utils.WebhookDialControl}).DialContext,
+		})
+	}
 	return &Task{
-		Config: config,
-		Logger: logger,
-		Repo:   repo,
-		Client: client,
+		Config:  config,
+		Logger:  logger,
+		Repo:    repo,
+		Accrual: accrualRouter,
+		Broker:  broker,
+
+		webhookClient: webhookClient,
+		retryAt:       make(map[string]time.Time),
 	}
 }
 
@@ -53,6 +76,12 @@ func (s *Task) RunUpdateOrderStatuses(ctx context.Context) {
 func (s *Task) UpdateOrderStatuses(ctx context.Context) error {
 	s.Logger.Info("UpdateOrderStatuses....")
 
+	// Пока все системы расчета недоступны, не открываем транзакцию и не блокируем заказы
+	if s.Accrual.State() == accrual.StateOpen {
+		s.Logger.Info("accrual circuit breaker is open, skip update")
+		return nil
+	}
+
 	count, err := s.Repo.GetOrdersCountToUpdate(ctx)
 	if err != nil {
 		return err
@@ -70,19 +99,37 @@ func (s *Task) UpdateOrderStatuses(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
-	status, err := s.GetOrderStatus(orderToUpdate.Number)
-	if err != nil {
-		return err
+	// Заказ, который сейчас нельзя опросить, откладывается: фиксация транзакции сохраняет
+	// время опроса, и следующий тик выберет заказ, который дольше всех не опрашивался
+	provider := s.Accrual.Route(orderToUpdate.Number, orderToUpdate.Login)
+	if retryAt := s.getRetryAt(provider.Name); time.Now().Before(retryAt) {
+		s.Logger.Info("accrual system ", provider.Name, " asked to wait until ", retryAt)
+		return tx.Commit(ctx)
 	}
-	if status.Status == "NEW" || status.Status == "PROCESSING" || status.Status == "REGISTERED" {
-		s.Logger.Info("order is not ready")
-		return nil
+	status, err := provider.Client.GetOrderStatus(ctx, orderToUpdate.Number)
+	var tooManyRequests *accrual.TooManyRequestsError
+	if errors.As(err, &tooManyRequests) {
+		s.setRetryAt(provider.Name, time.Now().Add(tooManyRequests.RetryAfter))
+		return errors.Join(err, tx.Commit(ctx))
+	}
+	if errors.Is(err, accrual.ErrOrderNotRegistered) {
+		s.Logger.Info("order is not registered in accrual system ", orderToUpdate.Number)
+		return tx.Commit(ctx)
 	}
-	err = tx.UpdateOrderStatus(ctx, status)
 	if err != nil {
 		return err
 	}
-	err = tx.AccrualUserBalance(ctx, status.Accrual, orderToUpdate.Login)
+	if err = ValidateOrderStatus(status); err != nil {
+		s.Logger.Error("invalid status of order ", orderToUpdate.Number, " from accrual system ", provider.Name, ": ", err)
+		return tx.Commit(ctx)
+	}
+	status.Provider = provider.Name
+	if !IsFinalStatus(status.Status) {
+		s.Logger.Info("order is not ready")
+		return s.storeProvisionalStatus(ctx, tx, orderToUpdate, status)
+	}
+	policy := NewAccrualPolicy(s.Config, time.Now())
+	balanceAfter, err := ApplyOrderStatus(ctx, tx, orderToUpdate.Login, status, policy, utils.NewRequestID())
 	if err != nil {
 		return err
 	}
@@ -90,19 +137,47 @@ func (s *Task) UpdateOrderStatuses(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
+	PublishOrderStatus(s.Broker, orderToUpdate.Login, status, balanceAfter)
 	s.Logger.Info("order is updated successfully ", orderToUpdate.Number)
 	return nil
 }
 
-func (s *Task) GetOrderStatus(number string) (storage.OrderStatus, error) {
-	var orderStatus storage.OrderStatus
-	requestURL := fmt.Sprintf("%v/api/orders/%v", s.Config.AccrualSystemAddress, number)
-	resp, err := s.Client.R().SetResult(&orderStatus).SetHeader("Content-Type", "application/json").Get(requestURL)
+func (s *Task) getRetryAt(provider string) time.Time {
+	s.retryMu.Lock()
+	defer s.retryMu.Unlock()
+	return s.retryAt[provider]
+}
+
+func (s *Task) setRetryAt(provider string, retryAt time.Time) {
+	s.retryMu.Lock()
+	defer s.retryMu.Unlock()
+	s.retryAt[provider] = retryAt
+}
+
+// storeProvisionalStatus сохраняет промежуточный статус заказа и предварительное начисление,
+// которые показываются пользователю как ожидаемые баллы.
+func (s *Task) storeProvisionalStatus(
+	ctx context.Context,
+	tx storage.Transaction,
+	orderToUpdate storage.OrderToUpdate,
+	status storage.OrderStatus,
+) error {
+	if status.Status == orderToUpdate.Status && status.Accrual == 0 {
+		return nil
+	}
+	err := tx.StoreProvisionalOrderStatus(ctx, status)
 	if err != nil {
-		s.Logger.Info("Request to ", requestURL, " with Error: ", err)
-		return orderStatus, err
+		return err
 	}
-	s.Logger.Info("Request to ", requestURL, " with status code:  ", resp.StatusCode())
-	s.Logger.Info("Request to ", requestURL, " with resp.RawResponse:  ", resp.RawResponse)
-	return orderStatus, nil
+	err = tx.Commit(ctx)
+	if err != nil {
+		return err
+	}
+	if status.Status != orderToUpdate.Status {
+		s.Broker.Publish(orderToUpdate.Login, events.OrderStatusChanged, events.OrderEvent{
+			Number: status.Number,
+			Status: status.Status,
+		})
+	}
+	return nil
 }
@@ -0,0 +1,51 @@
+package tasks
+
+import (
+	"context"
+	"time"
+
+	"github.com/pisarevaa/gophermart/internal/loyalty"
+)
+
+// tierRecalculationInterval - уровни зависят от начислений за 12 месяцев,
+// поэтому их достаточно пересчитывать раз в час.
+const tierRecalculationInterval = time.Hour
+
+func (s *Task) RunRecalculateTiers(ctx context.Context) {
+	ticker := time.NewTicker(tierRecalculationInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			err := s.RecalculateTiers(ctx)
+			if err != nil {
+				s.Logger.Error("error to recalculate loyalty tiers:", err)
+			}
+		case <-ctx.Done():
+			s.Logger.Info("ctx.Done -> exit RunRecalculateTiers")
+			return
+		}
+	}
+}
+
+// RecalculateTiers назначает пользователям уровни лояльности по сумме начислений
+// за последние 12 месяцев. Обновляются только пользователи, уровень которых изменился.
+func (s *Task) RecalculateTiers(ctx context.Context) error {
+	tiers := loyalty.NewTiers(s.Config.LoyaltyTiers)
+	totals, err := s.Repo.GetAccrualTotals(ctx, loyalty.WindowStart(time.Now()))
+	if err != nil {
+		return err
+	}
+	for _, total := range totals {
+		tier := tiers.ByTotal(total.Total)
+		if tier.Name == total.Tier {
+			continue
+		}
+		err = s.Repo.SetUserTier(ctx, total.Login, tier.Name)
+		if err != nil {
+			return err
+		}
+		s.Logger.Info("user ", total.Login, " tier is changed from ", total.Tier, " to ", tier.Name)
+	}
+	return nil
+}
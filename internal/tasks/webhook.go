@@ -0,0 +1,158 @@
+package tasks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/pisarevaa/gophermart/internal/storage"
+	"github.com/pisarevaa/gophermart/internal/utils"
+)
+
+const (
+	webhookTimeout     = 10 * time.Second
+	webhookBatchSize   = 100
+	webhookMaxAttempts = 8
+	webhookBaseBackoff = 30 * time.Second
+	webhookMaxBackoff  = 6 * time.Hour
+)
+
+type OrderFinalizedPayload struct {
+	Event       string    `json:"event"       binding:"required"`
+	Number      string    `json:"number"      binding:"required"`
+	Status      string    `json:"status"      binding:"required"`
+	Accrual     float32   `json:"accrual"     binding:"required"`
+	ProcessedAt time.Time `json:"processedAt" binding:"required"`
+}
+
+// storeOrderFinalized записывает сообщения для вебхуков пользователя в outbox
+// в той же транзакции, что и изменение статуса заказа.
+func storeOrderFinalized(ctx context.Context, tx storage.Transaction, login string, status storage.OrderStatus) error {
+	payload, err := json.Marshal(OrderFinalizedPayload{
+		Event:       storage.WebhookOrderFinalized,
+		Number:      status.Number,
+		Status:      status.Status,
+		Accrual:     status.Accrual,
+		ProcessedAt: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	return tx.StoreWebhookMessages(ctx, login, storage.WebhookOrderFinalized, payload)
+}
+
+func (s *Task) RunDeliverWebhooks(ctx context.Context) {
+	ticker := time.NewTicker(time.Duration(s.Config.TaskInterval) * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			err := s.DeliverWebhooks(ctx)
+			if err != nil {
+				s.Logger.Error("error to deliver webhooks:", err)
+			}
+		case <-ctx.Done():
+			s.Logger.Info("ctx.Done -> exit RunDeliverWebhooks")
+			return
+		}
+	}
+}
+
+func (s *Task) DeliverWebhooks(ctx context.Context) error {
+	count, err := s.Repo.GetWebhookMessagesCountToDeliver(ctx)
+	if err != nil {
+		return err
+	}
+	if count > webhookBatchSize {
+		count = webhookBatchSize
+	}
+	for range count {
+		err = s.deliverWebhookMessage(ctx)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deliverWebhookMessage отправляет одно сообщение из outbox и записывает попытку в журнал доставки.
+// При ошибке следующая попытка откладывается с экспоненциальной задержкой,
+// после webhookMaxAttempts попыток сообщение помечается как недоставленное.
+func (s *Task) deliverWebhookMessage(ctx context.Context) error {
+	tx, err := s.Repo.BeginTransaction(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck // ignore check
+	message, err := tx.GetWebhookMessageToDeliver(ctx)
+	if err != nil {
+		return err
+	}
+
+	message.Attempts++
+	start := time.Now()
+	statusCode, errSend := s.sendWebhook(ctx, message)
+	delivery := storage.WebhookDelivery{
+		MessageID:  message.ID,
+		WebhookID:  message.WebhookID,
+		Attempt:    message.Attempts,
+		StatusCode: statusCode,
+		DurationMs: time.Since(start).Milliseconds(),
+	}
+	now := time.Now()
+	switch {
+	case errSend == nil:
+		message.DeliveredAt = &now
+	case message.Attempts >= webhookMaxAttempts:
+		delivery.Error = errSend.Error()
+		message.FailedAt = &now
+	default:
+		delivery.Error = errSend.Error()
+		message.NextAttemptAt = now.Add(webhookBackoff(message.Attempts))
+	}
+
+	err = tx.StoreWebhookDelivery(ctx, delivery)
+	if err != nil {
+		return err
+	}
+	err = tx.UpdateWebhookMessage(ctx, message)
+	if err != nil {
+		return err
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return err
+	}
+	s.Logger.Info("webhook message ", message.ID, " attempt ", message.Attempts, " status code ", statusCode)
+	return nil
+}
+
+func (s *Task) sendWebhook(ctx context.Context, message storage.WebhookMessage) (int, error) {
+	timestamp := time.Now().Unix()
+	resp, err := s.webhookClient.R().
+		SetContext(ctx).
+		SetHeader("Content-Type", "application/json").
+		SetHeader(utils.WebhookEventHeader, message.Event).
+		SetHeader(utils.WebhookDeliveryHeader, strconv.FormatInt(message.ID, 10)).
+		SetHeader(utils.WebhookTimestampHeader, strconv.FormatInt(timestamp, 10)).
+		SetHeader(utils.WebhookSignatureHeader, utils.SignWebhookPayload(message.Secret, timestamp, message.Payload)).
+		SetBody(message.Payload).
+		Post(message.URL)
+	if err != nil {
+		return 0, err
+	}
+	if resp.IsError() {
+		return resp.StatusCode(), fmt.Errorf("unexpected status code %d", resp.StatusCode())
+	}
+	return resp.StatusCode(), nil
+}
+
+func webhookBackoff(attempt int) time.Duration {
+	backoff := webhookBaseBackoff << (attempt - 1)
+	if backoff <= 0 || backoff > webhookMaxBackoff {
+		return webhookMaxBackoff
+	}
+	return backoff
+}
@@ -0,0 +1,48 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	AccrualTimestampHeader = "X-Accrual-Timestamp"
+	AccrualSignatureHeader = "X-Accrual-Signature"
+)
+
+const maxSignatureAge = 5 * time.Minute
+
+// SignatureAuth пропускает запрос системы расчета начислений, подписанный общим секретом
+// так же, как подписываются исходящие вебхуки. Запросы со старой меткой времени отклоняются.
+func SignatureAuth(secret string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		timestamp, err := strconv.ParseInt(c.GetHeader(AccrualTimestampHeader), 10, 64)
+		if err != nil {
+			Abort(c, NewProblem(http.StatusUnauthorized, "timestamp is not provided"))
+			return
+		}
+		age := time.Since(time.Unix(timestamp, 0))
+		if age > maxSignatureAge || age < -maxSignatureAge {
+			Abort(c, NewProblem(http.StatusUnauthorized, "timestamp is expired"))
+			return
+		}
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			Abort(c, NewProblem(http.StatusBadRequest, err.Error()))
+			return
+		}
+		expected := SignWebhookPayload(secret, timestamp, body)
+		if !hmac.Equal([]byte(expected), []byte(c.GetHeader(AccrualSignatureHeader))) {
+			Abort(c, NewProblem(http.StatusUnauthorized, "signature is invalid"))
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		c.Next()
+	}
+}
@@ -0,0 +1,116 @@
+package utils
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/pisarevaa/gophermart/internal/storage"
+)
+
+const APIKeyHeader = "X-API-Key"
+
+const (
+	ScopeOrdersRead   = "orders:read"
+	ScopeOrdersWrite  = "orders:write"
+	ScopeBalanceRead  = "balance:read"
+	ScopeBalanceWrite = "balance:write"
+)
+
+const (
+	apiKeyPrefixBytes = 4
+	apiKeySecretBytes = 24
+)
+
+func APIKeyScopes() []string {
+	return []string{ScopeOrdersRead, ScopeOrdersWrite, ScopeBalanceRead, ScopeBalanceWrite}
+}
+
+// GenerateAPIKey возвращает новый ключ вида gm_<prefix>_<secret> и его публичный префикс,
+// по которому пользователь может отличать ключи в списке.
+func GenerateAPIKey() (string, string, error) {
+	prefix := make([]byte, apiKeyPrefixBytes)
+	if _, err := rand.Read(prefix); err != nil {
+		return "", "", err
+	}
+	secret := make([]byte, apiKeySecretBytes)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", err
+	}
+	prefixHex := hex.EncodeToString(prefix)
+	return "gm_" + prefixHex + "_" + hex.EncodeToString(secret), prefixHex, nil
+}
+
+// Auth пропускает запрос с JWT токеном или с API ключом в заголовке X-API-Key.
+// Для ключа проверяются отзыв и лимит запросов, а его области доступа сохраняются в контексте.
+func Auth(secretKey string, repo storage.Storage, limiter *RateLimiter, defaultRateLimit int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		apiKey := c.GetHeader(APIKeyHeader)
+		if apiKey == "" {
+			if !authorizeJWT(c, secretKey) {
+				return
+			}
+			c.Set("AuthMethod", "jwt")
+			c.Next()
+			return
+		}
+
+		keyHash, err := GetPasswordHash(apiKey, secretKey)
+		if err != nil {
+			Abort(c, err)
+			return
+		}
+		key, err := repo.GetAPIKeyByHash(c, keyHash)
+		if err != nil || key.RevokedAt != nil {
+			Abort(c, NewProblem(http.StatusUnauthorized, "API key is wrong or revoked"))
+			return
+		}
+
+		rateLimit := key.RateLimit
+		if rateLimit == 0 {
+			rateLimit = defaultRateLimit
+		}
+		if !limiter.Allow(key.Prefix, rateLimit) {
+			Abort(c, NewProblem(http.StatusTooManyRequests, "API key rate limit is exceeded"))
+			return
+		}
+
+		err = repo.TouchAPIKey(c, key.ID)
+		if err != nil {
+			Abort(c, err)
+			return
+		}
+
+		c.Set("Login", key.Login)
+		c.Set("Role", storage.RoleUser)
+		c.Set("AuthMethod", "api_key")
+		c.Set("Scopes", key.Scopes)
+		c.Next()
+	}
+}
+
+// RequireScope ограничивает доступ по API ключу областью scope. Запросы с JWT токеном
+// имеют полный доступ к API пользователя.
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString("AuthMethod") == "api_key" && !slices.Contains(c.GetStringSlice("Scopes"), scope) {
+			Abort(c, NewProblem(http.StatusForbidden, "API key has no scope "+scope))
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireJWT запрещает доступ по API ключу, например к управлению самими ключами.
+func RequireJWT() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString("AuthMethod") != "jwt" {
+			Abort(c, NewProblem(http.StatusForbidden, "endpoint requires user token"))
+			return
+		}
+		c.Next()
+	}
+}
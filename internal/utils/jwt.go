@@ -1,25 +1,31 @@
 package utils
 
 import (
+	"errors"
 	"net/http"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v4"
+
+	"github.com/pisarevaa/gophermart/internal/storage"
 )
 
 type Claims struct {
 	jwt.RegisteredClaims
 	Login string
+	Role  string
 }
 
-func GenerateJWTString(tokenExpSec int64, secretKey string, login string) (string, error) {
+func GenerateJWTString(tokenExpSec int64, secretKey string, login string, role string) (string, error) {
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, Claims{
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Second * time.Duration(tokenExpSec))),
 		},
 		Login: login,
+		Role:  role,
 	})
 	tokenString, err := token.SignedString([]byte(secretKey))
 	if err != nil {
@@ -29,52 +35,93 @@ func GenerateJWTString(tokenExpSec int64, secretKey string, login string) (strin
 }
 
 func GetUserLogin(token string, secretKey string) (string, error) {
+	claims, err := GetUserClaims(token, secretKey)
+	if err != nil {
+		return "", err
+	}
+	return claims.Login, nil
+}
+
+func GetUserClaims(token string, secretKey string) (*Claims, error) {
 	claims := &Claims{}
 	_, err := jwt.ParseWithClaims(token, claims, func(_ *jwt.Token) (interface{}, error) {
 		return []byte(secretKey), nil
 	})
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	return claims.Login, nil
+	return claims, nil
 }
 
 func JWTAuth(secretKey string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		authorization := c.Request.Header["Authorization"]
-
-		if len(authorization) != 1 {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authorization token is not set"})
+		if !authorizeJWT(c, secretKey) {
 			return
 		}
+		c.Next()
+	}
+}
 
-		authHeader := authorization[0]
-		if authHeader == "" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authorization token is not set"})
-			return
-		}
+// authorizeJWT проверяет токен из заголовка Authorization и сохраняет логин и роль в контексте.
+// При ошибке запрос прерывается со статусом 401.
+func authorizeJWT(c *gin.Context, secretKey string) bool {
+	authorization := c.Request.Header["Authorization"]
 
-		parts := strings.Split(authHeader, " ")
-		var headersPartsCount = 2
-		if len(parts) > headersPartsCount {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authorization token is not set"})
+	if len(authorization) != 1 {
+		Abort(c, NewProblem(http.StatusUnauthorized, "Authorization token is not set"))
+		return false
+	}
+
+	authHeader := authorization[0]
+	if authHeader == "" {
+		Abort(c, NewProblem(http.StatusUnauthorized, "Authorization token is not set"))
+		return false
+	}
+
+	parts := strings.Split(authHeader, " ")
+	var headersPartsCount = 2
+	if len(parts) > headersPartsCount {
+		Abort(c, NewProblem(http.StatusUnauthorized, "Authorization token is not set"))
+		return false
+	}
+	var token string
+	if len(parts) == headersPartsCount {
+		// Моя реализация Bearer токена
+		token = parts[1]
+	} else {
+		// Чтобы тесты прошли
+		token = authHeader
+	}
+
+	claims, err := GetUserClaims(token, secretKey)
+	if err != nil {
+		Abort(c, NewProblem(http.StatusUnauthorized, "Authorization token is wrong"))
+		return false
+	}
+	c.Set("Login", claims.Login)
+	c.Set("Role", claims.Role)
+	return true
+}
+
+// RoleAuth пропускает запрос только если текущая роль пользователя входит в список разрешенных.
+// Роль читается из БД, а не из токена, чтобы понижение роли действовало сразу, а не после истечения токена.
+// Должен подключаться после JWTAuth.
+func RoleAuth(repo storage.Storage, roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, err := repo.GetUser(c, c.GetString("Login"))
+		if errors.Is(err, storage.ErrUserNotFound) {
+			Abort(c, NewProblem(http.StatusUnauthorized, "user is not found"))
 			return
 		}
-		var token string
-		if len(parts) == headersPartsCount {
-			// Моя реализация Bearer токена
-			token = parts[1]
-		} else {
-			// Чтобы тесты прошли
-			token = authHeader
-		}
-
-		login, err := GetUserLogin(token, secretKey)
 		if err != nil {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authorization token is wrong"})
+			Abort(c, err)
+			return
+		}
+		c.Set("Role", user.Role)
+		if !slices.Contains(roles, user.Role) {
+			Abort(c, NewProblem(http.StatusForbidden, "access is forbidden for role"))
 			return
 		}
-		c.Set("Login", login)
 		c.Next()
 	}
 }
@@ -0,0 +1,121 @@
+package utils
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const ProblemContentType = "application/problem+json"
+
+// Коды ошибок по умолчанию для статусов ответа. Обработчик может задать более точный код.
+const (
+	CodeBadRequest         = "bad_request"
+	CodeUnauthorized       = "unauthorized"
+	CodePaymentRequired    = "payment_required"
+	CodeForbidden          = "forbidden"
+	CodeNotFound           = "not_found"
+	CodeConflict           = "conflict"
+	CodePayloadTooLarge    = "payload_too_large"
+	CodeUnprocessable      = "unprocessable_entity"
+	CodeTooManyRequests    = "too_many_requests"
+	CodeInternal           = "internal_error"
+	CodeServiceUnavailable = "service_unavailable"
+)
+
+//nolint:gochecknoglobals // read-only map
+var statusCodes = map[int]string{
+	http.StatusBadRequest:            CodeBadRequest,
+	http.StatusUnauthorized:          CodeUnauthorized,
+	http.StatusPaymentRequired:       CodePaymentRequired,
+	http.StatusForbidden:             CodeForbidden,
+	http.StatusNotFound:              CodeNotFound,
+	http.StatusConflict:              CodeConflict,
+	http.StatusRequestEntityTooLarge: CodePayloadTooLarge,
+	http.StatusUnprocessableEntity:   CodeUnprocessable,
+	http.StatusTooManyRequests:       CodeTooManyRequests,
+	http.StatusInternalServerError:   CodeInternal,
+	http.StatusServiceUnavailable:    CodeServiceUnavailable,
+}
+
+const internalErrorDetail = "internal error, report request id to support"
+
+// Problem - ошибка API в формате RFC 7807. Code - стабильный машиночитаемый код ошибки,
+// RequestID позволяет найти в логах подробности внутренней ошибки.
+type Problem struct {
+	Type      string `json:"type"                binding:"required" example:"about:blank"`
+	Title     string `json:"title"               binding:"required" example:"Not Found"`
+	Status    int    `json:"status"              binding:"required" example:"404"`
+	Code      string `json:"code"                binding:"required" example:"not_found"`
+	Detail    string `json:"detail,omitempty"                       example:"order is not found"`
+	Instance  string `json:"instance,omitempty"                     example:"/api/user/orders/12345678903"`
+	RequestID string `json:"requestId,omitempty"                    example:"9f86d081884c7d659a2feaa0c55ad015"`
+}
+
+// NewProblem создает ошибку с кодом по умолчанию для статуса.
+func NewProblem(status int, detail string) *Problem {
+	code, ok := statusCodes[status]
+	if !ok {
+		code = CodeInternal
+	}
+	return &Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Code:   code,
+		Detail: detail,
+	}
+}
+
+// WithCode задает код ошибки точнее кода по умолчанию для статуса.
+func (p *Problem) WithCode(code string) *Problem {
+	p.Code = code
+	return p
+}
+
+func (p *Problem) Error() string {
+	return p.Detail
+}
+
+// Abort прерывает обработку запроса ошибкой err, ответ формирует middleware Problems.
+// Ошибка, не являющаяся *Problem, считается внутренней. Статус выставляется сразу,
+// чтобы его видели middleware, которые выполняются раньше Problems.
+func Abort(c *gin.Context, err error) {
+	status := http.StatusInternalServerError
+	var problem *Problem
+	if errors.As(err, &problem) {
+		status = problem.Status
+	}
+	_ = c.Error(err)
+	c.Status(status)
+	c.Abort()
+}
+
+// Problems отдает ошибку, переданную в Abort, в формате application/problem+json.
+// Подробности внутренних ошибок пишутся в лог с идентификатором запроса и не отдаются клиенту.
+func Problems(logger *zap.SugaredLogger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+		err := c.Errors.Last().Err
+		var problem *Problem
+		if errors.As(err, &problem) {
+			response := *problem
+			problem = &response
+		} else {
+			problem = NewProblem(http.StatusInternalServerError, "")
+		}
+		problem.Instance = c.Request.URL.Path
+		problem.RequestID = c.GetString("RequestID")
+		if problem.Status >= http.StatusInternalServerError {
+			logger.Errorw("request failed", "requestId", problem.RequestID, "path", problem.Instance, "error", err)
+			problem.Detail = internalErrorDetail
+		}
+		c.Header("Content-Type", ProblemContentType)
+		c.JSON(problem.Status, problem)
+	}
+}
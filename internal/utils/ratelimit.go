@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"sync"
+	"time"
+)
+
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+// RateLimiter ограничивает количество событий по ключу в пределах фиксированного окна.
+type RateLimiter struct {
+	mu      sync.Mutex
+	period  time.Duration
+	windows map[string]*rateWindow
+}
+
+func NewRateLimiter(period time.Duration) *RateLimiter {
+	return &RateLimiter{
+		period:  period,
+		windows: make(map[string]*rateWindow),
+	}
+}
+
+// Allow учитывает событие для ключа и возвращает false, если лимит в текущем окне исчерпан.
+// Лимит меньше или равный нулю означает отсутствие ограничений.
+func (l *RateLimiter) Allow(key string, limit int) bool {
+	if limit <= 0 {
+		return true
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	window, ok := l.windows[key]
+	if !ok || now.Sub(window.start) >= l.period {
+		l.windows[key] = &rateWindow{start: now, count: 1}
+		return true
+	}
+	if window.count >= limit {
+		return false
+	}
+	window.count++
+	return true
+}
+
+// RetryAfter возвращает время до начала следующего окна для ключа.
+func (l *RateLimiter) RetryAfter(key string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	window, ok := l.windows[key]
+	if !ok {
+		return 0
+	}
+	return max(l.period-time.Since(window.start), 0)
+}
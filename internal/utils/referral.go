@@ -0,0 +1,23 @@
+package utils
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+)
+
+const referralCodeBytes = 4
+
+// GenerateReferralCode возвращает случайный код приглашения из 8 шестнадцатеричных символов в верхнем регистре.
+func GenerateReferralCode() (string, error) {
+	code := make([]byte, referralCodeBytes)
+	if _, err := rand.Read(code); err != nil {
+		return "", err
+	}
+	return strings.ToUpper(hex.EncodeToString(code)), nil
+}
+
+// NormalizeReferralCode приводит введенный пользователем код к виду, в котором он хранится.
+func NormalizeReferralCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
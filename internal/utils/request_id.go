@@ -0,0 +1,37 @@
+package utils
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/gin-gonic/gin"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+const requestIDBytes = 16
+
+const maxRequestIDLength = 64
+
+func NewRequestID() string {
+	b := make([]byte, requestIDBytes)
+	_, err := rand.Read(b)
+	if err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
+
+// RequestID берет идентификатор запроса из заголовка X-Request-ID или генерирует новый
+// и возвращает его в ответе, чтобы события аудита можно было связать с логами клиента.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if requestID == "" || len(requestID) > maxRequestIDLength {
+			requestID = NewRequestID()
+		}
+		c.Set("RequestID", requestID)
+		c.Header(RequestIDHeader, requestID)
+		c.Next()
+	}
+}
@@ -0,0 +1,85 @@
+package utils
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net"
+	"strconv"
+	"syscall"
+)
+
+const (
+	WebhookEventHeader     = "X-Gophermart-Event"
+	WebhookDeliveryHeader  = "X-Gophermart-Delivery"
+	WebhookTimestampHeader = "X-Gophermart-Timestamp"
+	WebhookSignatureHeader = "X-Gophermart-Signature"
+)
+
+const webhookSecretBytes = 32
+
+func GenerateWebhookSecret() (string, error) {
+	secret := make([]byte, webhookSecretBytes)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(secret), nil
+}
+
+// SignWebhookPayload возвращает подпись вида sha256=<hex> от строки "<timestamp>.<payload>".
+// Метка времени входит в подпись, чтобы получатель мог отбросить повторно отправленный старый запрос.
+func SignWebhookPayload(secret string, timestamp int64, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// ErrWebhookAddress - адрес вебхука указывает во внутреннюю сеть сервиса.
+var ErrWebhookAddress = errors.New("webhook address must be public")
+
+// IsPublicIP сообщает, что адрес не относится к loopback, частным и link-local сетям
+// (в том числе к адресу метаданных облака 169.254.169.254) и не является multicast.
+func IsPublicIP(ip net.IP) bool {
+	return !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() &&
+		!ip.IsInterfaceLocalMulticast() && !ip.IsMulticast() && !ip.IsUnspecified()
+}
+
+// CheckWebhookHost проверяет, что все адреса хоста вебхука публичные.
+func CheckWebhookHost(ctx context.Context, host string) error {
+	if ip := net.ParseIP(host); ip != nil {
+		if !IsPublicIP(ip) {
+			return ErrWebhookAddress
+		}
+		return nil
+	}
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return err
+	}
+	for _, addr := range addrs {
+		if !IsPublicIP(addr.IP) {
+			return ErrWebhookAddress
+		}
+	}
+	return nil
+}
+
+// WebhookDialControl - функция Control для net.Dialer, запрещающая соединения с непубличными адресами.
+// Проверяется адрес, к которому действительно идет подключение, поэтому смена DNS-записи после
+// регистрации вебхука (DNS rebinding) и редиректы во внутреннюю сеть не помогают обойти проверку.
+func WebhookDialControl(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !IsPublicIP(ip) {
+		return ErrWebhookAddress
+	}
+	return nil
+}
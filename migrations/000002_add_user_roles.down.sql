@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS admin_actions;
+ALTER TABLE users DROP COLUMN IF EXISTS "role";
@@ -0,0 +1,12 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS "role" VARCHAR(20) NOT NULL DEFAULT 'user';
+
+CREATE TABLE IF NOT EXISTS admin_actions (
+    "id" 			BIGSERIAL PRIMARY KEY,
+	"operator" 		VARCHAR(250) NOT NULL,
+	"method" 		VARCHAR(10) NOT NULL,
+	"path" 			VARCHAR(250) NOT NULL,
+	"target" 		VARCHAR(250) NOT NULL DEFAULT '',
+	"status_code" 	INTEGER NOT NULL,
+	"created_at" 	TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS admin_actions_operator_idx ON admin_actions ("operator", "created_at");
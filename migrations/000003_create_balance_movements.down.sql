@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS order_changes;
+DROP TABLE IF EXISTS balance_movements;
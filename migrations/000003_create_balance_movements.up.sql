@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS balance_movements (
+    "id" 			BIGSERIAL PRIMARY KEY,
+	"login" 		VARCHAR(250) NOT NULL REFERENCES users("login"),
+	"kind" 			VARCHAR(30) NOT NULL,
+	"amount" 		DECIMAL NOT NULL,
+	"order_number" 	VARCHAR(50) NULL,
+	"reason" 		TEXT NOT NULL DEFAULT '',
+	"operator" 		VARCHAR(250) NULL,
+	"created_at" 	TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS balance_movements_login_idx ON balance_movements ("login", "created_at");
+
+CREATE TABLE IF NOT EXISTS order_changes (
+    "id" 			BIGSERIAL PRIMARY KEY,
+	"order_number" 	VARCHAR(50) NOT NULL REFERENCES orders("number"),
+	"operator" 		VARCHAR(250) NOT NULL,
+	"old_status" 	VARCHAR(15) NOT NULL,
+	"new_status" 	VARCHAR(15) NOT NULL,
+	"old_accrual" 	DECIMAL NOT NULL,
+	"new_accrual" 	DECIMAL NOT NULL,
+	"reason" 		TEXT NOT NULL,
+	"created_at" 	TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS order_changes_order_number_idx ON order_changes ("order_number");
@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS audit_events (
+    "id" 				BIGSERIAL PRIMARY KEY,
+	"actor" 			VARCHAR(250) NOT NULL,
+	"action" 			VARCHAR(50) NOT NULL,
+	"target" 			VARCHAR(250) NOT NULL DEFAULT '',
+	"amount_before" 	DECIMAL NULL,
+	"amount_after" 		DECIMAL NULL,
+	"request_id" 		VARCHAR(64) NOT NULL DEFAULT '',
+	"created_at" 		TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS audit_events_actor_idx ON audit_events ("actor", "created_at");
+CREATE INDEX IF NOT EXISTS audit_events_target_idx ON audit_events ("target", "created_at");
+CREATE INDEX IF NOT EXISTS audit_events_action_idx ON audit_events ("action", "created_at");
+
+CREATE OR REPLACE FUNCTION audit_events_append_only() RETURNS TRIGGER AS $$
+BEGIN
+	RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_events_append_only
+	BEFORE UPDATE OR DELETE ON audit_events
+	FOR EACH ROW EXECUTE FUNCTION audit_events_append_only();
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS api_keys (
+    "id" 			BIGSERIAL PRIMARY KEY,
+	"login" 		VARCHAR(250) NOT NULL REFERENCES users("login"),
+	"name" 			VARCHAR(100) NOT NULL,
+	"prefix" 		VARCHAR(16) NOT NULL,
+	"key_hash" 		VARCHAR(250) NOT NULL UNIQUE,
+	"scopes" 		TEXT[] NOT NULL,
+	"rate_limit" 	INTEGER NOT NULL DEFAULT 0,
+	"created_at" 	TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	"last_used_at" 	TIMESTAMPTZ NULL,
+	"revoked_at" 	TIMESTAMPTZ NULL
+);
+CREATE INDEX IF NOT EXISTS api_keys_login_idx ON api_keys ("login");
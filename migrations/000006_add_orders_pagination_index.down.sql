@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS orders_login_status_uploaded_at_idx;
+DROP INDEX IF EXISTS orders_login_uploaded_at_idx;
@@ -0,0 +1,2 @@
+CREATE INDEX IF NOT EXISTS orders_login_uploaded_at_idx ON orders ("login", "uploaded_at", "number");
+CREATE INDEX IF NOT EXISTS orders_login_status_uploaded_at_idx ON orders ("login", "status", "uploaded_at", "number");
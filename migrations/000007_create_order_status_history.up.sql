@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS order_status_history (
+    "id" 			BIGSERIAL PRIMARY KEY,
+	"order_number" 	VARCHAR(50) NOT NULL REFERENCES orders("number"),
+	"status" 		VARCHAR(15) NOT NULL,
+	"accrual" 		DECIMAL NOT NULL DEFAULT 0,
+	"created_at" 	TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS order_status_history_order_number_idx ON order_status_history ("order_number", "id");
+
+-- Заказы, созданные при списании баллов, сразу получают статус PROCESSED (processed_at = uploaded_at)
+-- и не проходят через NEW
+INSERT INTO order_status_history (order_number, status, accrual, created_at)
+SELECT number, 'NEW', 0, uploaded_at FROM orders
+WHERE NOT (status = 'PROCESSED' AND processed_at IS NOT DISTINCT FROM uploaded_at);
+
+INSERT INTO order_status_history (order_number, status, accrual, created_at)
+SELECT number, status, accrual, processed_at FROM orders WHERE status <> 'NEW' AND processed_at IS NOT NULL;
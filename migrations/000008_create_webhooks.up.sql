@@ -0,0 +1,36 @@
+CREATE TABLE IF NOT EXISTS webhooks (
+    "id" 			BIGSERIAL PRIMARY KEY,
+	"login" 		VARCHAR(250) NOT NULL REFERENCES users("login"),
+	"url" 			VARCHAR(2048) NOT NULL,
+	"secret" 		VARCHAR(128) NOT NULL,
+	"created_at" 	TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	"deleted_at" 	TIMESTAMPTZ NULL
+);
+CREATE INDEX IF NOT EXISTS webhooks_login_idx ON webhooks ("login");
+
+-- Сообщения пишутся в одной транзакции с изменением статуса заказа и доставляются фоновой задачей
+CREATE TABLE IF NOT EXISTS webhook_outbox (
+    "id" 				BIGSERIAL PRIMARY KEY,
+	"webhook_id" 		BIGINT NOT NULL REFERENCES webhooks("id"),
+	"event" 			VARCHAR(50) NOT NULL,
+	"payload" 			JSONB NOT NULL,
+	"attempts" 			INTEGER NOT NULL DEFAULT 0,
+	"next_attempt_at" 	TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	"delivered_at" 		TIMESTAMPTZ NULL,
+	"failed_at" 		TIMESTAMPTZ NULL,
+	"created_at" 		TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS webhook_outbox_pending_idx ON webhook_outbox ("next_attempt_at")
+	WHERE delivered_at IS NULL AND failed_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    "id" 			BIGSERIAL PRIMARY KEY,
+	"message_id" 	BIGINT NOT NULL REFERENCES webhook_outbox("id"),
+	"webhook_id" 	BIGINT NOT NULL REFERENCES webhooks("id"),
+	"attempt" 		INTEGER NOT NULL,
+	"status_code" 	INTEGER NOT NULL DEFAULT 0,
+	"error" 		TEXT NOT NULL DEFAULT '',
+	"duration_ms" 	BIGINT NOT NULL DEFAULT 0,
+	"created_at" 	TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS webhook_deliveries_webhook_id_idx ON webhook_deliveries ("webhook_id", "id");
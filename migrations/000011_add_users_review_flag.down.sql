@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS users_flagged_at_idx;
+ALTER TABLE users DROP COLUMN IF EXISTS "flag_reason";
+ALTER TABLE users DROP COLUMN IF EXISTS "flagged_at";
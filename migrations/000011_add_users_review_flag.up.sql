@@ -0,0 +1,3 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS "flagged_at" TIMESTAMPTZ NULL;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS "flag_reason" TEXT NOT NULL DEFAULT '';
+CREATE INDEX IF NOT EXISTS users_flagged_at_idx ON users ("flagged_at") WHERE flagged_at IS NOT NULL;
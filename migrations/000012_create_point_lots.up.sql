@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS point_lots (
+    "id" 			BIGSERIAL PRIMARY KEY,
+	"login" 		VARCHAR(250) NOT NULL REFERENCES users("login"),
+	"order_number" 	VARCHAR(50) NULL,
+	"amount" 		DECIMAL NOT NULL,
+	"remaining" 	DECIMAL NOT NULL,
+	"expires_at" 	TIMESTAMPTZ NULL,
+	"expired_at" 	TIMESTAMPTZ NULL,
+	"created_at" 	TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS point_lots_login_idx ON point_lots ("login", "expires_at") WHERE remaining > 0;
+CREATE INDEX IF NOT EXISTS point_lots_expires_at_idx ON point_lots ("expires_at") WHERE remaining > 0;
@@ -0,0 +1,14 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS "held" DECIMAL NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS holds (
+    "id" 			BIGSERIAL PRIMARY KEY,
+	"login" 		VARCHAR(250) NOT NULL REFERENCES users("login"),
+	"order_number" 	VARCHAR(50) NOT NULL,
+	"amount" 		DECIMAL NOT NULL,
+	"status" 		VARCHAR(15) NOT NULL,
+	"expires_at" 	TIMESTAMPTZ NOT NULL,
+	"closed_at" 	TIMESTAMPTZ NULL,
+	"created_at" 	TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS holds_login_idx ON holds ("login", "created_at");
+CREATE INDEX IF NOT EXISTS holds_expires_at_idx ON holds ("expires_at") WHERE status = 'active';
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS balance_movements_login_kind_idx;
+ALTER TABLE balance_movements DROP COLUMN IF EXISTS "counterparty";
@@ -0,0 +1,2 @@
+ALTER TABLE balance_movements ADD COLUMN IF NOT EXISTS "counterparty" VARCHAR(250) NULL;
+CREATE INDEX IF NOT EXISTS balance_movements_login_kind_idx ON balance_movements ("login", "kind", "created_at");
@@ -0,0 +1,2 @@
+ALTER TABLE orders DROP COLUMN IF EXISTS "tier_bonus";
+ALTER TABLE users DROP COLUMN IF EXISTS "tier";
@@ -0,0 +1,2 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS "tier" VARCHAR(30) NOT NULL DEFAULT '';
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS "tier_bonus" DECIMAL NOT NULL DEFAULT 0;
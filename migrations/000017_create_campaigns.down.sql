@@ -0,0 +1,3 @@
+ALTER TABLE orders DROP COLUMN IF EXISTS "campaign_bonus";
+ALTER TABLE balance_movements DROP COLUMN IF EXISTS "campaign_id";
+DROP TABLE IF EXISTS campaigns;
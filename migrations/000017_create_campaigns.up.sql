@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS campaigns (
+    "id" 				BIGSERIAL PRIMARY KEY,
+	"name" 				VARCHAR(250) NOT NULL,
+	"starts_at" 		TIMESTAMPTZ NOT NULL,
+	"ends_at" 			TIMESTAMPTZ NOT NULL,
+	"first_order_only" 	BOOLEAN NOT NULL DEFAULT FALSE,
+	"min_accrual" 		DECIMAL NOT NULL DEFAULT 0,
+	"tiers" 			TEXT[] NOT NULL DEFAULT '{}',
+	"bonus_type" 		VARCHAR(15) NOT NULL,
+	"bonus_value" 		DECIMAL NOT NULL,
+	"created_at" 		TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	"deleted_at" 		TIMESTAMPTZ NULL
+);
+CREATE INDEX IF NOT EXISTS campaigns_window_idx ON campaigns ("starts_at", "ends_at") WHERE deleted_at IS NULL;
+
+ALTER TABLE balance_movements ADD COLUMN IF NOT EXISTS "campaign_id" BIGINT NULL REFERENCES campaigns("id");
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS "campaign_bonus" DECIMAL NOT NULL DEFAULT 0;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS referrals;
+ALTER TABLE users DROP COLUMN IF EXISTS "registration_ip";
+ALTER TABLE users DROP COLUMN IF EXISTS "referral_code";
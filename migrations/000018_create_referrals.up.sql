@@ -0,0 +1,16 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS "referral_code" VARCHAR(20) NULL UNIQUE;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS "registration_ip" VARCHAR(45) NOT NULL DEFAULT '';
+UPDATE users SET referral_code = UPPER(SUBSTR(MD5(RANDOM()::TEXT || login), 1, 8)) WHERE referral_code IS NULL;
+
+CREATE TABLE IF NOT EXISTS referrals (
+    "id" 				BIGSERIAL PRIMARY KEY,
+	"referrer" 			VARCHAR(250) NOT NULL REFERENCES users("login"),
+	"referee" 			VARCHAR(250) NOT NULL UNIQUE REFERENCES users("login"),
+	"status" 			VARCHAR(15) NOT NULL,
+	"reject_reason" 	VARCHAR(250) NOT NULL DEFAULT '',
+	"referrer_bonus" 	DECIMAL NOT NULL DEFAULT 0,
+	"referee_bonus" 	DECIMAL NOT NULL DEFAULT 0,
+	"created_at" 		TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	"rewarded_at" 		TIMESTAMPTZ NULL
+);
+CREATE INDEX IF NOT EXISTS referrals_referrer_idx ON referrals ("referrer", "created_at");
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS fraud_reviews (
+    "id" 			BIGSERIAL PRIMARY KEY,
+	"login" 		VARCHAR(250) NOT NULL REFERENCES users("login"),
+	"action" 		VARCHAR(30) NOT NULL,
+	"order_number" 	VARCHAR(50) NOT NULL,
+	"sum" 			DECIMAL NOT NULL DEFAULT 0,
+	"score" 		BIGINT NOT NULL,
+	"decision" 		VARCHAR(15) NOT NULL,
+	"reasons" 		TEXT[] NOT NULL DEFAULT '{}',
+	"status" 		VARCHAR(15) NOT NULL,
+	"operator" 		VARCHAR(250) NULL,
+	"comment" 		TEXT NOT NULL DEFAULT '',
+	"created_at" 	TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	"resolved_at" 	TIMESTAMPTZ NULL
+);
+CREATE INDEX IF NOT EXISTS fraud_reviews_status_idx ON fraud_reviews ("status", "created_at");
+CREATE INDEX IF NOT EXISTS fraud_reviews_login_idx ON fraud_reviews ("login", "action", "order_number");
@@ -0,0 +1 @@
+ALTER TABLE fraud_reviews DROP COLUMN IF EXISTS "used_at";
@@ -0,0 +1 @@
+ALTER TABLE fraud_reviews ADD COLUMN IF NOT EXISTS "used_at" TIMESTAMPTZ NULL;
@@ -0,0 +1,3 @@
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS "polled_at" TIMESTAMPTZ NULL;
+CREATE INDEX IF NOT EXISTS orders_polled_at_idx ON orders ("polled_at" NULLS FIRST)
+    WHERE status IN ('NEW', 'PROCESSING', 'REGISTERED');